@@ -0,0 +1,298 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9/internal/pool"
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+// AutoPipelineOptions configures automatic pipelining.
+//
+// With automatic pipelining, the commands that goroutines run concurrently
+// share a few connections instead of taking a connection from the pool each.
+// The commands that queue up while a connection waits for the replies of
+// a batch are written together as the next batch, like a Pipeline does.
+// Blocking commands such as BLPOP, also when they are sent with Do,
+// commands that change the state of the connection such as SELECT,
+// pipelines and transactions keep using dedicated connections from the pool,
+// and so do the clients returned by WithTimeout.
+type AutoPipelineOptions struct {
+	// Number of connections shared by the commands.
+	// They are taken from the pool and count towards PoolSize.
+	// Default is 1.
+	Conns int
+	// Maximum number of commands written in a batch.
+	// Default is 100 commands.
+	MaxBatchSize int
+	// Maximum amount of time to wait for more commands before writing a batch
+	// that isn't full. Default is 0, which writes the queued commands as soon
+	// as a connection is free.
+	MaxFlushDelay time.Duration
+}
+
+func (opt *AutoPipelineOptions) init() {
+	if opt.Conns <= 0 {
+		opt.Conns = 1
+	}
+	if opt.MaxBatchSize <= 0 {
+		opt.MaxBatchSize = 100
+	}
+}
+
+func (opt *AutoPipelineOptions) clone() *AutoPipelineOptions {
+	if opt == nil {
+		return nil
+	}
+	clone := *opt
+	return &clone
+}
+
+// autoPipelined reports whether cmd can share a connection with the commands
+// of other goroutines.
+func autoPipelined(cmd Cmder) bool {
+	if isBlockingCmd(cmd) {
+		// Blocking commands would hold up the commands behind them.
+		return false
+	}
+	if _, ok := cmd.(*WriterCmd); ok {
+		return false
+	}
+	switch cmd.Name() {
+	case "auth", "hello", "select", "client", "readonly", "readwrite", "reset", "quit",
+		"multi", "exec", "discard", "watch", "unwatch",
+		"subscribe", "psubscribe", "ssubscribe", "unsubscribe", "punsubscribe", "sunsubscribe",
+		"monitor", "sync", "psync":
+		return false
+	}
+	return true
+}
+
+const (
+	autoCmdQueued int32 = iota
+	autoCmdAbandoned
+	autoCmdReading
+)
+
+type autoPipelineCmd struct {
+	ctx   context.Context
+	cmd   Cmder
+	state int32
+	done  chan error
+}
+
+type autoPipeliner struct {
+	c   *baseClient
+	opt *AutoPipelineOptions
+
+	queue chan *autoPipelineCmd
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newAutoPipeliner(c *baseClient, opt *AutoPipelineOptions) *autoPipeliner {
+	p := &autoPipeliner{
+		c:      c,
+		opt:    opt,
+		queue:  make(chan *autoPipelineCmd, opt.Conns*opt.MaxBatchSize),
+		closed: make(chan struct{}),
+	}
+	p.wg.Add(opt.Conns)
+	for i := 0; i < opt.Conns; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *autoPipeliner) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	p.wg.Wait()
+	return nil
+}
+
+// process queues cmd for the next batch and waits for its reply.
+func (p *autoPipeliner) process(ctx context.Context, cmd Cmder) error {
+	if limiter := p.c.opt.Limiter; limiter != nil {
+		if err := limiter.Allow(); err != nil {
+			return err
+		}
+		err := p._process(ctx, cmd)
+		limiter.ReportResult(err)
+		return err
+	}
+	return p._process(ctx, cmd)
+}
+
+func (p *autoPipeliner) _process(ctx context.Context, cmd Cmder) error {
+	acmd := &autoPipelineCmd{
+		ctx:  ctx,
+		cmd:  cmd,
+		done: make(chan error, 1),
+	}
+
+	select {
+	case p.queue <- acmd:
+	case <-p.closed:
+		return pool.ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-acmd.done:
+		return err
+	case <-ctx.Done():
+		if atomic.CompareAndSwapInt32(&acmd.state, autoCmdQueued, autoCmdAbandoned) {
+			return ctx.Err()
+		}
+		// The reply is being read into cmd already.
+		return <-acmd.done
+	}
+}
+
+// run processes batches on a connection that it keeps until the pipeliner
+// is closed or the connection breaks.
+func (p *autoPipeliner) run() {
+	defer p.wg.Done()
+
+	var cn *pool.Conn
+	defer func() {
+		if cn != nil {
+			p.c.connPool.Put(context.Background(), cn)
+		}
+	}()
+
+	batch := make([]*autoPipelineCmd, 0, p.opt.MaxBatchSize)
+	for {
+		select {
+		case acmd := <-p.queue:
+			batch = append(batch[:0], acmd)
+		case <-p.closed:
+			p.drain(pool.ErrClosed)
+			return
+		}
+		batch = p.fill(batch)
+
+		if cn == nil {
+			var err error
+			cn, err = p.c._getConn(context.Background())
+			if err != nil {
+				for _, acmd := range batch {
+					acmd.done <- err
+				}
+				continue
+			}
+		}
+
+		if err := p.processBatch(cn, batch); isBadConn(err, false, p.c.opt.Addr) {
+			p.c.connPool.Remove(context.Background(), cn, err)
+			cn = nil
+		}
+	}
+}
+
+// fill adds the queued commands to the batch.
+func (p *autoPipeliner) fill(batch []*autoPipelineCmd) []*autoPipelineCmd {
+	var timeout <-chan time.Time
+	if p.opt.MaxFlushDelay > 0 {
+		timer := time.NewTimer(p.opt.MaxFlushDelay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for len(batch) < p.opt.MaxBatchSize {
+		select {
+		case acmd := <-p.queue:
+			batch = append(batch, acmd)
+			continue
+		default:
+		}
+		if timeout == nil {
+			break
+		}
+		select {
+		case acmd := <-p.queue:
+			batch = append(batch, acmd)
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
+
+// drain fails the queued commands when the pipeliner is closed.
+func (p *autoPipeliner) drain(err error) {
+	for {
+		select {
+		case acmd := <-p.queue:
+			acmd.done <- err
+		default:
+			return
+		}
+	}
+}
+
+func (p *autoPipeliner) processBatch(cn *pool.Conn, batch []*autoPipelineCmd) error {
+	cmds := make([]Cmder, 0, len(batch))
+	pending := batch[:0]
+	for _, acmd := range batch {
+		// Don't send the commands whose callers gave up waiting.
+		if atomic.LoadInt32(&acmd.state) == autoCmdAbandoned {
+			continue
+		}
+		cmds = append(cmds, acmd.cmd)
+		pending = append(pending, acmd)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := cn.WithWriter(ctx, p.c.opt.WriteTimeout, func(wr *proto.Writer) error {
+		return writeCmds(wr, cmds)
+	}); err != nil {
+		for _, acmd := range pending {
+			if atomic.CompareAndSwapInt32(&acmd.state, autoCmdQueued, autoCmdReading) {
+				acmd.done <- err
+			}
+		}
+		return err
+	}
+
+	var i int
+	err := cn.WithReader(ctx, p.c.opt.ReadTimeout, func(rd *proto.Reader) error {
+		for ; i < len(pending); i++ {
+			acmd := pending[i]
+			if !atomic.CompareAndSwapInt32(&acmd.state, autoCmdQueued, autoCmdReading) {
+				// Read the reply of an abandoned command into a throwaway cmd.
+				if err := p.c.readReply(acmd.ctx, rd, NewCmd(acmd.ctx)); err != nil && !isRedisError(err) {
+					return err
+				}
+				continue
+			}
+
+			err := p.c.readReply(acmd.ctx, rd, acmd.cmd)
+			acmd.done <- err
+			if err != nil && !isRedisError(err) {
+				i++
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, acmd := range pending[i:] {
+			if atomic.CompareAndSwapInt32(&acmd.state, autoCmdQueued, autoCmdReading) {
+				acmd.done <- err
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9/internal/pool"
+)
+
+// ErrConnBudgetTimeout is returned when a connection can't be dialed because
+// the connection budget stays exhausted for ConnBudgetOptions.Timeout.
+var ErrConnBudgetTimeout = pool.ErrBudgetTimeout
+
+// ConnBudgetOptions limits the number of connections of all the nodes of
+// a ClusterClient or all the shards of a Ring together, on top of the
+// per node PoolSize and MaxActiveConns.
+//
+// The nodes that need a new connection while the budget is exhausted first
+// close the least recently used idle connection of the node with the most
+// idle connections. Without idle connections, they wait for a connection to
+// be closed in the order they started waiting, whichever node it belongs to.
+type ConnBudgetOptions struct {
+	// Maximum number of connections of all nodes together.
+	MaxConns int
+	// Amount of time a node waits for the budget before returning
+	// ErrConnBudgetTimeout. Default is PoolTimeout.
+	Timeout time.Duration
+}
+
+// ConnBudgetStats contains the state and accumulated stats of a connection budget.
+type ConnBudgetStats pool.BudgetStats
+
+// newConnBudget returns nil when opt doesn't limit the connections.
+// nodeOpt are the options of the nodes sharing the budget.
+func newConnBudget(opt *ConnBudgetOptions, nodeOpt *Options) *pool.Budget {
+	if opt == nil || opt.MaxConns <= 0 {
+		return nil
+	}
+
+	timeout := opt.Timeout
+	if timeout == 0 {
+		nodeOpt = nodeOpt.clone()
+		nodeOpt.init()
+		timeout = nodeOpt.PoolTimeout
+	}
+	return pool.NewBudget(opt.MaxConns, timeout)
+}
+
+func connBudgetStats(budget *pool.Budget) *ConnBudgetStats {
+	if budget == nil {
+		return new(ConnBudgetStats)
+	}
+	return (*ConnBudgetStats)(budget.Stats())
+}
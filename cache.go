@@ -0,0 +1,693 @@
+package redis
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9/internal"
+	"github.com/redis/go-redis/v9/internal/pool"
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+// CacheOptions configures the server-assisted client-side cache.
+//
+// The cache keeps the replies of read-only commands such as GET, HGETALL
+// and MGET in process memory and relies on CLIENT TRACKING to learn when
+// the server modifies the keys they were read from. It requires Redis 6.0
+// or greater. Pipelines and transactions always bypass the cache.
+type CacheOptions struct {
+	// Maximum number of replies kept in the cache.
+	// The least recently used reply is evicted when the limit is reached.
+	// Default is 10000 replies.
+	MaxEntries int
+	// Maximum amount of time a reply is served from the cache.
+	// Default is 0, replies are kept until they are invalidated or evicted.
+	TTL time.Duration
+
+	// Enables the broadcasting mode of CLIENT TRACKING, in which the server
+	// notifies about every modified key matching Prefixes instead of the keys
+	// read by the client. Pooled connections don't need to enable tracking then.
+	// It is always enabled for ClusterClient.
+	Broadcast bool
+	// Key prefixes tracked in the broadcasting mode. Only the replies of keys
+	// matching one of the prefixes are cached. Default is to track all keys.
+	Prefixes []string
+}
+
+func (opt *CacheOptions) init() {
+	if opt.MaxEntries == 0 {
+		opt.MaxEntries = 10000
+	}
+	if len(opt.Prefixes) > 0 {
+		opt.Broadcast = true
+	}
+}
+
+func (opt *CacheOptions) clone() *CacheOptions {
+	clone := *opt
+	return &clone
+}
+
+// tracked reports whether the server notifies about modifications of all the keys.
+func (opt *CacheOptions) tracked(keys []string) bool {
+	if !opt.Broadcast || len(opt.Prefixes) == 0 {
+		return true
+	}
+loop:
+	for _, key := range keys {
+		for _, prefix := range opt.Prefixes {
+			if strings.HasPrefix(key, prefix) {
+				continue loop
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// CacheStats contains client-side cache state information and accumulated stats.
+type CacheStats struct {
+	Hits          uint64 // number of replies served from the cache
+	Misses        uint64 // number of cacheable commands sent to the server
+	Invalidations uint64 // number of keys invalidated by the server
+	Evictions     uint64 // number of replies evicted due to MaxEntries or TTL
+
+	Entries uint32 // number of replies in the cache
+}
+
+// cacheableCmds lists the read-only commands whose replies are cached.
+// All of them read a single key at position 1, except MGET.
+var cacheableCmds = map[string]struct{}{
+	"get":        {},
+	"getrange":   {},
+	"strlen":     {},
+	"mget":       {},
+	"exists":     {},
+	"type":       {},
+	"hget":       {},
+	"hmget":      {},
+	"hgetall":    {},
+	"hexists":    {},
+	"hkeys":      {},
+	"hvals":      {},
+	"hlen":       {},
+	"hstrlen":    {},
+	"lindex":     {},
+	"llen":       {},
+	"lrange":     {},
+	"scard":      {},
+	"sismember":  {},
+	"smismember": {},
+	"smembers":   {},
+	"zcard":      {},
+	"zcount":     {},
+	"zrange":     {},
+	"zrank":      {},
+	"zrevrank":   {},
+	"zscore":     {},
+	"zmscore":    {},
+}
+
+// cacheKeys returns the keys read by cmd or nil if the reply of cmd can't be cached.
+func cacheKeys(cmd Cmder) []string {
+	if _, ok := cmd.(*WriterCmd); ok {
+		// Streamed replies are not buffered.
+		return nil
+	}
+
+	name := cmd.Name()
+	if _, ok := cacheableCmds[name]; !ok {
+		return nil
+	}
+
+	args := cmd.Args()
+	if len(args) < 2 {
+		return nil
+	}
+
+	if name != "mget" && name != "exists" {
+		return []string{cmd.stringArg(1)}
+	}
+
+	keys := make([]string, len(args)-1)
+	for i := range keys {
+		keys[i] = cmd.stringArg(i + 1)
+	}
+	return keys
+}
+
+// cacheWriteKeys returns the keys modified by the write cmd.
+func cacheWriteKeys(cmd Cmder) []string {
+	args := cmd.Args()
+	first := cmdFirstKeyPos(cmd)
+	if first <= 0 || first >= len(args) {
+		return nil
+	}
+
+	last, step := first, 1
+	switch cmd.Name() {
+	case "del", "unlink":
+		last = len(args) - 1
+	case "mset", "msetnx":
+		last, step = len(args)-1, 2
+	case "rename", "renamenx", "copy", "smove", "lmove", "rpoplpush", "blmove", "brpoplpush":
+		last = first + 1
+	}
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+
+	keys := make([]string, 0, (last-first)/step+1)
+	for i := first; i <= last; i += step {
+		keys = append(keys, cmd.stringArg(i))
+	}
+	return keys
+}
+
+// cacheKey encodes the command the same way it is sent to the server,
+// so that commands of different Cmder types share cached replies.
+func cacheKey(cmd Cmder) (string, error) {
+	var buf bytes.Buffer
+	if err := writeCmd(proto.NewWriter(&buf), cmd); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+//------------------------------------------------------------------------------
+
+type cacheEntry struct {
+	key       string
+	keys      []string
+	raw       []byte
+	expiresAt time.Time
+}
+
+// cacheFill tracks a reply that is being read from the server.
+// It is discarded when one of its keys is invalidated in the meantime.
+type cacheFill struct {
+	keys  []string
+	valid bool
+}
+
+type clientCache struct {
+	opt *CacheOptions
+
+	mu      sync.Mutex
+	enabled bool
+	lru     *list.List
+	entries map[string]*list.Element
+	byKey   map[string]map[*list.Element]struct{}
+	fills   map[string]map[*cacheFill]struct{}
+
+	stats CacheStats
+}
+
+func newClientCache(opt *CacheOptions) *clientCache {
+	return &clientCache{
+		opt: opt,
+
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		byKey:   make(map[string]map[*list.Element]struct{}),
+		fills:   make(map[string]map[*cacheFill]struct{}),
+	}
+}
+
+// process serves cmd from the cache or executes it using fn and caches the reply.
+func (c *clientCache) process(
+	ctx context.Context, cmd Cmder, keys []string, fn func(context.Context, Cmder) error,
+) error {
+	if !c.opt.tracked(keys) {
+		return fn(ctx, cmd)
+	}
+
+	key, err := cacheKey(cmd)
+	if err != nil {
+		return fn(ctx, cmd)
+	}
+
+	if raw, ok := c.get(key); ok {
+		atomic.AddUint64(&c.stats.Hits, 1)
+		return cmd.readReply(proto.NewReaderSize(bytes.NewReader(raw), len(raw)))
+	}
+	atomic.AddUint64(&c.stats.Misses, 1)
+
+	fill := c.reserve(keys)
+	if fill == nil {
+		return fn(ctx, cmd)
+	}
+
+	rawCmd := &rawReplyCmd{Cmder: cmd}
+	err = fn(ctx, rawCmd)
+	if err == nil || err == Nil {
+		c.put(fill, key, rawCmd.raw)
+	} else {
+		c.put(fill, "", nil)
+	}
+	return err
+}
+
+func (c *clientCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled {
+		return nil, false
+	}
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(el)
+		c.stats.Evictions++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return entry.raw, true
+}
+
+// reserve registers a reply that is about to be read from the server.
+// It returns nil when the cache is disabled.
+func (c *clientCache) reserve(keys []string) *cacheFill {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled {
+		return nil
+	}
+
+	fill := &cacheFill{keys: keys, valid: true}
+	for _, key := range keys {
+		fills, ok := c.fills[key]
+		if !ok {
+			fills = make(map[*cacheFill]struct{})
+			c.fills[key] = fills
+		}
+		fills[fill] = struct{}{}
+	}
+	return fill
+}
+
+// put releases the fill and caches the raw reply unless the fill was invalidated.
+func (c *clientCache) put(fill *cacheFill, key string, raw []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, k := range fill.keys {
+		if fills, ok := c.fills[k]; ok {
+			delete(fills, fill)
+			if len(fills) == 0 {
+				delete(c.fills, k)
+			}
+		}
+	}
+
+	if !fill.valid || !c.enabled || raw == nil {
+		return
+	}
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	entry := &cacheEntry{
+		key:  key,
+		keys: fill.keys,
+		raw:  raw,
+	}
+	if c.opt.TTL > 0 {
+		entry.expiresAt = time.Now().Add(c.opt.TTL)
+	}
+
+	el := c.lru.PushFront(entry)
+	c.entries[key] = el
+	for _, k := range entry.keys {
+		els, ok := c.byKey[k]
+		if !ok {
+			els = make(map[*list.Element]struct{})
+			c.byKey[k] = els
+		}
+		els[el] = struct{}{}
+	}
+
+	for c.lru.Len() > c.opt.MaxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *clientCache) remove(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	for _, k := range entry.keys {
+		if els, ok := c.byKey[k]; ok {
+			delete(els, el)
+			if len(els) == 0 {
+				delete(c.byKey, k)
+			}
+		}
+	}
+}
+
+// invalidate removes the replies that depend on the keys invalidated by the server.
+func (c *clientCache) invalidate(keys []string) {
+	c.mu.Lock()
+	c.stats.Invalidations += uint64(len(keys))
+	c.removeKeys(keys)
+	c.mu.Unlock()
+}
+
+// forget removes the replies that depend on the keys written by the client.
+func (c *clientCache) forget(keys []string) {
+	c.mu.Lock()
+	c.removeKeys(keys)
+	c.mu.Unlock()
+}
+
+func (c *clientCache) removeKeys(keys []string) {
+	for _, key := range keys {
+		for el := range c.byKey[key] {
+			c.remove(el)
+		}
+		for fill := range c.fills[key] {
+			fill.valid = false
+		}
+	}
+}
+
+// flush removes all replies from the cache.
+func (c *clientCache) flush() {
+	c.mu.Lock()
+	c._flush()
+	c.mu.Unlock()
+}
+
+// flushFunc removes the replies for which fn returns true.
+func (c *clientCache) flushFunc(fn func(keys []string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if fn(el.Value.(*cacheEntry).keys) {
+			c.remove(el)
+		}
+		el = next
+	}
+	for _, fills := range c.fills {
+		for fill := range fills {
+			if fn(fill.keys) {
+				fill.valid = false
+			}
+		}
+	}
+}
+
+func (c *clientCache) _flush() {
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.byKey = make(map[string]map[*list.Element]struct{})
+	for _, fills := range c.fills {
+		for fill := range fills {
+			fill.valid = false
+		}
+	}
+}
+
+// setEnabled enables or disables the cache. Disabling the cache flushes it.
+func (c *clientCache) setEnabled(enabled bool) {
+	c.mu.Lock()
+	c.enabled = enabled
+	if !enabled {
+		c._flush()
+	}
+	c.mu.Unlock()
+}
+
+func (c *clientCache) Stats() *CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &CacheStats{
+		Hits:          atomic.LoadUint64(&c.stats.Hits),
+		Misses:        atomic.LoadUint64(&c.stats.Misses),
+		Invalidations: c.stats.Invalidations,
+		Evictions:     c.stats.Evictions,
+
+		Entries: uint32(c.lru.Len()),
+	}
+}
+
+// rawReplyCmd keeps the raw reply of the wrapped command so it can be cached.
+type rawReplyCmd struct {
+	Cmder
+	raw []byte
+}
+
+func (cmd *rawReplyCmd) readReply(rd *proto.Reader) error {
+	raw, err := rd.ReadRawReply()
+	if err != nil {
+		return err
+	}
+	cmd.raw = raw
+	return cmd.Cmder.readReply(proto.NewReaderSize(bytes.NewReader(raw), len(raw)))
+}
+
+//------------------------------------------------------------------------------
+
+const cacheTrackerHealthCheck = 5 * time.Second
+
+// cacheTracker maintains the connection that receives invalidation messages.
+// Pooled connections redirect their invalidations to it, so that idle connections
+// never hold back invalidations. In the broadcasting mode the tracking connection
+// receives the invalidations of all keys and pooled connections are not tracked.
+type cacheTracker struct {
+	client   *baseClient
+	connPool *pool.ConnPool
+	cache    *clientCache
+
+	mu     sync.Mutex
+	cn     *pool.Conn
+	id     int64
+	closed bool
+}
+
+func newCacheTracker(opt *Options, connPool *pool.ConnPool, cache *clientCache) *cacheTracker {
+	return &cacheTracker{
+		client: &baseClient{
+			opt:      opt,
+			connPool: connPool,
+		},
+		connPool: connPool,
+		cache:    cache,
+	}
+}
+
+// tracks reports whether the invalidations of cn are redirected
+// to the current tracking connection.
+func (t *cacheTracker) tracks(cn *pool.Conn) bool {
+	if t.broadcast() {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cn != nil && cn.TrackingID == t.id
+}
+
+// ClientID returns the id of the tracking connection, connecting it if needed.
+func (t *cacheTracker) ClientID(ctx context.Context) (int64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return 0, pool.ErrClosed
+	}
+	if t.cn == nil {
+		if err := t.connect(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return t.id, nil
+}
+
+func (t *cacheTracker) connect(ctx context.Context) error {
+	cn, err := t.client.newConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn := newConn(t.client.opt, pool.NewSingleConnPool(t.connPool, cn))
+	id, err := conn.ClientID(ctx).Result()
+	if err != nil {
+		_ = t.connPool.CloseConn(cn)
+		return err
+	}
+
+	if t.broadcast() {
+		if err := conn.Process(ctx, t.broadcastCmd(ctx, id)); err != nil {
+			_ = t.connPool.CloseConn(cn)
+			return err
+		}
+	}
+
+	// RESP2 connections receive invalidations as Pub/Sub messages.
+	if t.client.opt.Protocol == 2 {
+		if err := t.writeCmd(cn, NewSliceCmd(ctx, "subscribe", "__redis__:invalidate")); err != nil {
+			_ = t.connPool.CloseConn(cn)
+			return err
+		}
+	}
+
+	t.cn = cn
+	t.id = id
+	t.cache.setEnabled(true)
+
+	go t.receive(cn)
+
+	return nil
+}
+
+func (t *cacheTracker) broadcast() bool {
+	return t.client.opt.ClientSideCache.Broadcast
+}
+
+func (t *cacheTracker) broadcastCmd(ctx context.Context, id int64) *StatusCmd {
+	args := []interface{}{"client", "tracking", "on"}
+	// RESP2 connections can't receive push messages, so the invalidations
+	// are redirected to the Pub/Sub channel of the connection itself.
+	if t.client.opt.Protocol == 2 {
+		args = append(args, "redirect", id)
+	}
+	args = append(args, "bcast")
+	for _, prefix := range t.client.opt.ClientSideCache.Prefixes {
+		args = append(args, "prefix", prefix)
+	}
+	return NewStatusCmd(ctx, args...)
+}
+
+func (t *cacheTracker) writeCmd(cn *pool.Conn, cmd Cmder) error {
+	return cn.WithWriter(context.Background(), t.client.opt.WriteTimeout, func(wr *proto.Writer) error {
+		return writeCmd(wr, cmd)
+	})
+}
+
+func (t *cacheTracker) receive(cn *pool.Conn) {
+	var pinged bool
+	for {
+		var reply interface{}
+		err := cn.WithReader(context.Background(), cacheTrackerHealthCheck, func(rd *proto.Reader) error {
+			var err error
+			reply, err = rd.ReadReply()
+			return err
+		})
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && !pinged {
+				pinged = true
+				if err = t.writeCmd(cn, NewCmd(context.Background(), "ping")); err == nil {
+					continue
+				}
+			}
+			t.reset(cn, err)
+			return
+		}
+
+		pinged = false
+		t.handle(reply)
+	}
+}
+
+func (t *cacheTracker) handle(reply interface{}) {
+	msg, ok := reply.([]interface{})
+	if !ok || len(msg) < 2 {
+		return
+	}
+
+	kind, _ := msg[0].(string)
+	switch kind {
+	case "invalidate":
+		t.invalidate(msg[1])
+	case "message":
+		if len(msg) == 3 {
+			t.invalidate(msg[2])
+		}
+	}
+}
+
+func (t *cacheTracker) invalidate(payload interface{}) {
+	// A nil payload is sent when the database is flushed.
+	keys, ok := payload.([]interface{})
+	if !ok {
+		t.cache.flush()
+		return
+	}
+
+	ss := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if s, ok := key.(string); ok {
+			ss = append(ss, s)
+		}
+	}
+	t.cache.invalidate(ss)
+}
+
+func (t *cacheTracker) reset(cn *pool.Conn, reason error) {
+	t.mu.Lock()
+	if t.cn == cn {
+		t.cn = nil
+		t.id = 0
+	}
+	closed := t.closed
+	t.mu.Unlock()
+
+	if closed {
+		return
+	}
+
+	t.cache.setEnabled(false)
+	_ = t.connPool.CloseConn(cn)
+
+	internal.Logger.Printf(context.Background(),
+		"redis: discarding bad client tracking connection: %s", reason)
+
+	if t.broadcast() {
+		return
+	}
+
+	// Invalidations of the pooled connections were redirected to the lost
+	// connection, so they have to be re-established with a new tracking connection.
+	// The connections in use are discarded when they are checked out again.
+	_ = t.connPool.Filter(func(*pool.Conn) bool {
+		return true
+	})
+}
+
+func (t *cacheTracker) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return pool.ErrClosed
+	}
+	t.closed = true
+
+	t.cache.setEnabled(false)
+	if t.cn == nil {
+		return nil
+	}
+	err := t.connPool.CloseConn(t.cn)
+	t.cn = nil
+	return err
+}
@@ -0,0 +1,147 @@
+package redis_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ = Describe("Client-side cache", func() {
+	var client, other *redis.Client
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.ClientSideCache = &redis.CacheOptions{MaxEntries: 2}
+		client = redis.NewClient(opt)
+		Expect(client.FlushDB(ctx).Err()).NotTo(HaveOccurred())
+
+		other = redis.NewClient(redisOptions())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+		Expect(other.Close()).NotTo(HaveOccurred())
+	})
+
+	It("serves replies from the cache", func() {
+		Expect(other.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+
+		for i := 0; i < 3; i++ {
+			val, err := client.Get(ctx, "key").Result()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(val).To(Equal("hello"))
+		}
+
+		stats := client.CacheStats()
+		Expect(stats.Hits).To(BeNumerically(">=", 1))
+		Expect(stats.Entries).To(Equal(uint32(1)))
+	})
+
+	It("caches nil replies", func() {
+		for i := 0; i < 3; i++ {
+			err := client.Get(ctx, "missing").Err()
+			Expect(err).To(Equal(redis.Nil))
+		}
+		Expect(client.CacheStats().Hits).To(BeNumerically(">=", 1))
+	})
+
+	It("evicts invalidated keys", func() {
+		Expect(other.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+
+		Expect(other.Set(ctx, "key", "world", 0).Err()).NotTo(HaveOccurred())
+		Eventually(func() string {
+			return client.Get(ctx, "key").Val()
+		}, time.Second).Should(Equal("world"))
+		Expect(client.CacheStats().Invalidations).To(BeNumerically(">=", 1))
+	})
+
+	It("invalidates keys written by the client", func() {
+		Expect(client.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+
+		Expect(client.Set(ctx, "key", "world", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("world"))
+	})
+
+	It("invalidates all keys written by the client", func() {
+		Expect(client.MSet(ctx, "k1", "hello", "k2", "hello").Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "k1").Val()).To(Equal("hello"))
+		Expect(client.Get(ctx, "k2").Val()).To(Equal("hello"))
+
+		Expect(client.MSet(ctx, "k1", "world", "k2", "world").Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "k1").Val()).To(Equal("world"))
+		Expect(client.Get(ctx, "k2").Val()).To(Equal("world"))
+
+		Expect(client.Del(ctx, "k1", "k2").Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "k1").Err()).To(Equal(redis.Nil))
+		Expect(client.Get(ctx, "k2").Err()).To(Equal(redis.Nil))
+	})
+
+	It("respects MaxEntries", func() {
+		for _, key := range []string{"k1", "k2", "k3", "k1", "k2", "k3"} {
+			_ = client.Get(ctx, key).Err()
+		}
+
+		stats := client.CacheStats()
+		Expect(stats.Entries).To(Equal(uint32(2)))
+		Expect(stats.Evictions).To(BeNumerically(">=", 1))
+	})
+
+	It("can be bypassed", func() {
+		Expect(other.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		hits := client.CacheStats().Hits
+
+		Expect(client.WithoutCache().Get(ctx, "key").Val()).To(Equal("hello"))
+		Expect(client.CacheStats().Hits).To(Equal(hits))
+	})
+
+	It("can be bypassed per call", func() {
+		Expect(other.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		hits := client.CacheStats().Hits
+
+		callCtx := redis.WithCallOptions(ctx, &redis.CallOptions{SkipCache: true})
+		Expect(client.Get(callCtx, "key").Val()).To(Equal("hello"))
+		Expect(client.CacheStats().Hits).To(Equal(hits))
+	})
+})
+
+var _ = Describe("ClusterClient client-side cache", func() {
+	var client *redis.ClusterClient
+	var other *redis.ClusterClient
+
+	BeforeEach(func() {
+		opt := redisClusterOptions()
+		opt.ClientSideCache = &redis.CacheOptions{}
+		client = cluster.newClusterClient(ctx, opt)
+		other = cluster.newClusterClient(ctx, redisClusterOptions())
+	})
+
+	AfterEach(func() {
+		_ = client.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return master.FlushDB(ctx).Err()
+		})
+		Expect(client.Close()).NotTo(HaveOccurred())
+		Expect(other.Close()).NotTo(HaveOccurred())
+	})
+
+	It("serves replies from the cache and tracks invalidations", func() {
+		Expect(other.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+		Expect(client.CacheStats().Hits).To(BeNumerically(">=", 1))
+
+		Expect(other.Set(ctx, "key", "world", 0).Err()).NotTo(HaveOccurred())
+		Eventually(func() string {
+			return client.Get(ctx, "key").Val()
+		}, time.Second).Should(Equal("world"))
+	})
+})
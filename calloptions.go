@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// CallOptions change how the commands of a call are processed, without
+// creating another client. They are carried by the context with
+// WithCallOptions and apply to the commands, pipelines and transactions
+// processed with it.
+type CallOptions struct {
+	// Master sends read-only commands to the master instead of a replica,
+	// e.g. to read back a write. It applies to ClusterClient, whose Watch
+	// always uses the master.
+	Master bool
+	// Node sends the commands, including the transactions of Watch, to the
+	// node of a ClusterClient with this address or to the shard of a Ring
+	// with this name. The node has to be part of the cluster state.
+	// The redirects of the cluster are still followed.
+	Node string
+
+	// NoRetry disables the retries of failed commands.
+	NoRetry bool
+	// ReadTimeout replaces the read timeout of the client for the commands
+	// that don't block. Use -1 for no timeout. Default is 0, which keeps
+	// the read timeout of the client.
+	ReadTimeout time.Duration
+	// SkipCache reads the keys from the server instead of the client-side
+	// cache, and doesn't cache the replies.
+	SkipCache bool
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a copy of ctx that carries opt. They replace
+// the call options ctx already carries.
+func WithCallOptions(ctx context.Context, opt *CallOptions) context.Context {
+	return context.WithValue(ctx, callOptionsKey{}, opt)
+}
+
+// CallOptionsFromContext returns the call options ctx carries, or nil.
+func CallOptionsFromContext(ctx context.Context) *CallOptions {
+	opt, _ := ctx.Value(callOptionsKey{}).(*CallOptions)
+	return opt
+}
+
+// callReadTimeout returns the read timeout of the call options of ctx,
+// or timeout when they don't replace it.
+func callReadTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	opt := CallOptionsFromContext(ctx)
+	switch {
+	case opt == nil || opt.ReadTimeout == 0:
+		return timeout
+	case opt.ReadTimeout < 0:
+		return 0
+	}
+	return opt.ReadTimeout
+}
+
+// callRetryPolicy returns policy, or a policy that doesn't retry
+// when the call options of ctx disable retries.
+func callRetryPolicy(ctx context.Context, policy RetryPolicy) RetryPolicy {
+	if opt := CallOptionsFromContext(ctx); opt != nil && opt.NoRetry {
+		return noRetryPolicy{}
+	}
+	return policy
+}
+
+type noRetryPolicy struct{}
+
+func (noRetryPolicy) ShouldRetry(context.Context, []Cmder, int, error) bool { return false }
+func (noRetryPolicy) Backoff(int) time.Duration                             { return 0 }
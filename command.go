@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"regexp"
 	"strconv"
@@ -43,6 +44,10 @@ type Cmder interface {
 
 	SetErr(error)
 	Err() error
+
+	// Attributes returns the RESP3 attributes that preceded the reply, if any.
+	Attributes() map[interface{}]interface{}
+	setAttributes([]proto.ValuePair)
 }
 
 func setCmdsErr(cmds []Cmder, e error) {
@@ -98,6 +103,42 @@ func cmdFirstKeyPos(cmd Cmder) int {
 	return 1
 }
 
+// blockingCmds lists the commands that block until their timeout,
+// like the commands with the blocking flag of COMMAND.
+var blockingCmds = map[string]struct{}{
+	"blpop":      {},
+	"brpop":      {},
+	"brpoplpush": {},
+	"blmove":     {},
+	"blmpop":     {},
+	"bzpopmin":   {},
+	"bzpopmax":   {},
+	"bzmpop":     {},
+	"wait":       {},
+	"waitaof":    {},
+}
+
+// isBlockingCmd reports whether cmd may block the connection until a timeout,
+// including the commands created with Do and XREAD or XREADGROUP with BLOCK.
+func isBlockingCmd(cmd Cmder) bool {
+	if cmd.readTimeout() != nil {
+		return true
+	}
+
+	name := cmd.Name()
+	if _, ok := blockingCmds[name]; ok {
+		return true
+	}
+	if name == "xread" || name == "xreadgroup" {
+		for i := 1; i < len(cmd.Args()); i++ {
+			if strings.EqualFold(cmd.stringArg(i), "block") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func cmdString(cmd Cmder, val interface{}) string {
 	b := make([]byte, 0, 64)
 
@@ -126,6 +167,7 @@ type baseCmd struct {
 	args   []interface{}
 	err    error
 	keyPos int8
+	attrs  []proto.ValuePair
 
 	_readTimeout *time.Duration
 }
@@ -189,6 +231,17 @@ func (cmd *baseCmd) Err() error {
 	return cmd.err
 }
 
+func (cmd *baseCmd) Attributes() map[interface{}]interface{} {
+	if cmd.attrs == nil {
+		return nil
+	}
+	return proto.PairsInterface(cmd.attrs)
+}
+
+func (cmd *baseCmd) setAttributes(attrs []proto.ValuePair) {
+	cmd.attrs = attrs
+}
+
 func (cmd *baseCmd) readTimeout() *time.Duration {
 	return cmd._readTimeout
 }
@@ -477,6 +530,28 @@ func (cmd *Cmd) BoolSlice() ([]bool, error) {
 	return bools, nil
 }
 
+// Decode decodes the reply into dst, which should be a pointer. RESP3 maps
+// and RESP2 key-value arrays are decoded into structs by the `redis:"field"`
+// tag of their fields, arrays into slices and maps into maps, recursively.
+// Fields of pointer types are allocated when the reply has a value for them.
+func (cmd *Cmd) Decode(dst interface{}) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	return hscan.Decode(dst, cmd.val)
+}
+
+// ScanInto decodes the reply of cmd into a new value of type T, e.g.
+//
+//	info, err := redis.ScanInto[StreamInfo](rdb.Do(ctx, "xinfo", "stream", "s", "full"))
+//
+// See Cmd.Decode for how the reply is mapped to T.
+func ScanInto[T any](cmd interface{ Decode(dst interface{}) error }) (T, error) {
+	var val T
+	err := cmd.Decode(&val)
+	return val, err
+}
+
 func (cmd *Cmd) readReply(rd *proto.Reader) (err error) {
 	cmd.val, err = rd.ReadReply()
 	return err
@@ -484,6 +559,71 @@ func (cmd *Cmd) readReply(rd *proto.Reader) (err error) {
 
 //------------------------------------------------------------------------------
 
+// Value is a reply that keeps its RESP type, see ValueCmd.
+type Value = proto.Value
+
+// ValuePair is a key-value pair of a map or an attribute Value.
+type ValuePair = proto.ValuePair
+
+// ValueCmd is like Cmd, but keeps the RESP types of the reply, e.g.
+// sets, doubles, big numbers, verbatim strings and the order of map keys.
+type ValueCmd struct {
+	baseCmd
+
+	val Value
+}
+
+var _ Cmder = (*ValueCmd)(nil)
+
+func NewValueCmd(ctx context.Context, args ...interface{}) *ValueCmd {
+	return &ValueCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *ValueCmd) String() string {
+	return cmdString(cmd, cmd.val.String())
+}
+
+func (cmd *ValueCmd) SetVal(val Value) {
+	cmd.val = val
+}
+
+func (cmd *ValueCmd) Val() Value {
+	return cmd.val
+}
+
+func (cmd *ValueCmd) Result() (Value, error) {
+	return cmd.val, cmd.err
+}
+
+// Decode decodes the reply into dst like Cmd.Decode does.
+func (cmd *ValueCmd) Decode(dst interface{}) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	return hscan.Decode(dst, cmd.val.Interface())
+}
+
+func (cmd *ValueCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadValue()
+	if err != nil {
+		return err
+	}
+	if cmd.attrs != nil {
+		cmd.val.Attrs = append(cmd.attrs[:len(cmd.attrs):len(cmd.attrs)], cmd.val.Attrs...)
+	}
+	if cmd.val.IsNil() {
+		return Nil
+	}
+	return cmd.val.Err()
+}
+
+//------------------------------------------------------------------------------
+
 type SliceCmd struct {
 	baseCmd
 
@@ -828,6 +968,50 @@ func (cmd *BoolCmd) readReply(rd *proto.Reader) (err error) {
 
 //------------------------------------------------------------------------------
 
+// WriterCmd copies a string reply to an io.Writer without buffering it.
+// Its value is the number of bytes written.
+type WriterCmd struct {
+	baseCmd
+
+	w   io.Writer
+	val int64
+}
+
+var _ Cmder = (*WriterCmd)(nil)
+
+func NewWriterCmd(ctx context.Context, w io.Writer, args ...interface{}) *WriterCmd {
+	return &WriterCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+		w: w,
+	}
+}
+
+func (cmd *WriterCmd) SetVal(val int64) {
+	cmd.val = val
+}
+
+func (cmd *WriterCmd) Val() int64 {
+	return cmd.val
+}
+
+func (cmd *WriterCmd) Result() (int64, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *WriterCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+func (cmd *WriterCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadStringTo(cmd.w)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
 type StringCmd struct {
 	baseCmd
 
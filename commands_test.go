@@ -1,11 +1,14 @@
 package redis_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/bsm/ginkgo/v2"
@@ -1346,6 +1349,41 @@ var _ = Describe("Commands", func() {
 			Expect(get.Val()).To(Equal("hello"))
 		})
 
+		It("should GetToWriter and SetFromReader", func() {
+			var buf bytes.Buffer
+			get := client.GetToWriter(ctx, "_", &buf)
+			Expect(get.Err()).To(Equal(redis.Nil))
+			Expect(get.Val()).To(Equal(int64(0)))
+
+			value := strings.Repeat("x", 1<<20)
+			set := client.SetFromReader(ctx, "key", strings.NewReader(value), int64(len(value)), 0)
+			Expect(set.Err()).NotTo(HaveOccurred())
+			Expect(set.Val()).To(Equal("OK"))
+
+			get = client.GetToWriter(ctx, "key", &buf)
+			Expect(get.Err()).NotTo(HaveOccurred())
+			Expect(get.Val()).To(Equal(int64(len(value))))
+			Expect(buf.String()).To(Equal(value))
+		})
+
+		It("should reuse the connection when GetToWriter fails to write", func() {
+			value := strings.Repeat("x", 1<<20)
+			Expect(client.Set(ctx, "key", value, 0).Err()).NotTo(HaveOccurred())
+
+			misses := client.PoolStats().Misses
+
+			err := client.GetToWriter(ctx, "key", badWriter{}).Err()
+			Expect(err).To(MatchError("bad writer"))
+			Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+			Expect(client.PoolStats().Misses).To(Equal(misses))
+		})
+
+		It("should fail SetFromReader with a short reader", func() {
+			err := client.SetFromReader(ctx, "key", strings.NewReader("hello"), 10, 0).Err()
+			Expect(err).To(MatchError("redis: streamed argument is shorter than 10 bytes"))
+			Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		})
+
 		It("should GetBit", func() {
 			setBit := client.SetBit(ctx, "key", 7, 1)
 			Expect(setBit.Err()).NotTo(HaveOccurred())
@@ -7007,3 +7045,9 @@ func deref(viface interface{}) interface{} {
 	}
 	return v.Interface()
 }
+
+type badWriter struct{}
+
+func (badWriter) Write([]byte) (int, error) {
+	return 0, errors.New("bad writer")
+}
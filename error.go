@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"strings"
@@ -37,7 +38,33 @@ type Error interface {
 
 var _ Error = proto.RedisError("")
 
+// ProtocolError is returned when a reply exceeds the limits
+// set by Options.MaxBulkLen, MaxAggregateLen or MaxReplyDepth.
+type ProtocolError = proto.ProtocolError
+
+// HandshakeError is returned when a command of the handshake that
+// initializes a new connection, e.g. HELLO or SELECT, fails.
+type HandshakeError struct {
+	// Command is the lower case name of the command, e.g. "select"
+	// or "client tracking".
+	Command string
+	Err     error
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("redis: %s failed during the connection handshake: %s",
+		strings.ToUpper(e.Command), e.Err)
+}
+
+func (e *HandshakeError) Unwrap() error {
+	return e.Err
+}
+
 func shouldRetry(err error, retryTimeout bool) bool {
+	if herr, ok := err.(*HandshakeError); ok {
+		err = herr.Err
+	}
+
 	switch err {
 	case io.EOF, io.ErrUnexpectedEOF:
 		return true
@@ -85,6 +112,11 @@ func isBadConn(err error, allowTimeout bool, addr string) bool {
 		return true
 	}
 
+	if _, ok := err.(*proto.WriterError); ok {
+		// The reply was read completely.
+		return false
+	}
+
 	if isRedisError(err) {
 		switch {
 		case isReadOnlyError(err):
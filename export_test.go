@@ -91,7 +91,7 @@ func GetSlavesAddrByName(ctx context.Context, c *SentinelClient, name string) []
 			name, err)
 		return []string{}
 	}
-	return parseReplicaAddrs(addrs, false)
+	return nodeAddrs(parseReplicas(addrs, false))
 }
 
 func (c *Ring) ShardByName(name string) *ringShard {
@@ -84,8 +84,14 @@ func InstrumentMetrics(rdb redis.UniversalClient, opts ...MetricsOption) error {
 
 func reportPoolStats(rdb *redis.Client, conf *config) error {
 	labels := conf.attrs
-	idleAttrs := append(labels, attribute.String("state", "idle"))
-	usedAttrs := append(labels, attribute.String("state", "used"))
+	idleAttrs := withAttr(labels, attribute.String("state", "idle"))
+	usedAttrs := withAttr(labels, attribute.String("state", "used"))
+	dialOKAttrs := withAttr(labels, attribute.String("status", "ok"))
+	dialErrorAttrs := withAttr(labels, attribute.String("status", "error"))
+	idleClosedAttrs := withAttr(labels, attribute.String("reason", "idle"))
+	lifetimeClosedAttrs := withAttr(labels, attribute.String("reason", "lifetime"))
+	badConnClosedAttrs := withAttr(labels, attribute.String("reason", "bad_conn"))
+	initFailedClosedAttrs := withAttr(labels, attribute.String("reason", "init_failed"))
 
 	idleMax, err := conf.meter.Int64ObservableUpDownCounter(
 		"db.client.connections.idle.max",
@@ -127,6 +133,48 @@ func reportPoolStats(rdb *redis.Client, conf *config) error {
 		return err
 	}
 
+	waits, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.waits",
+		metric.WithDescription("The number of times a connection was waited for"),
+	)
+	if err != nil {
+		return err
+	}
+
+	waitTime, err := conf.meter.Float64ObservableCounter(
+		"db.client.connections.waits_duration",
+		metric.WithDescription("The total time spent waiting for a connection"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return err
+	}
+
+	dials, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.dials",
+		metric.WithDescription("The number of times a connection was dialed with the status described by the status attribute"),
+	)
+	if err != nil {
+		return err
+	}
+
+	dialTime, err := conf.meter.Float64ObservableCounter(
+		"db.client.connections.dials_duration",
+		metric.WithDescription("The total time spent dialing connections"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return err
+	}
+
+	closed, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.closed",
+		metric.WithDescription("The number of connections closed for the reason described by the reason attribute"),
+	)
+	if err != nil {
+		return err
+	}
+
 	redisConf := rdb.Options()
 	_, err = conf.meter.RegisterCallback(
 		func(ctx context.Context, o metric.Observer) error {
@@ -137,9 +185,21 @@ func reportPoolStats(rdb *redis.Client, conf *config) error {
 			o.ObserveInt64(connsMax, int64(redisConf.PoolSize), metric.WithAttributes(labels...))
 
 			o.ObserveInt64(usage, int64(stats.IdleConns), metric.WithAttributes(idleAttrs...))
-			o.ObserveInt64(usage, int64(stats.TotalConns-stats.IdleConns), metric.WithAttributes(usedAttrs...))
+			o.ObserveInt64(usage, int64(stats.InUseConns), metric.WithAttributes(usedAttrs...))
 
 			o.ObserveInt64(timeouts, int64(stats.Timeouts), metric.WithAttributes(labels...))
+
+			o.ObserveInt64(waits, int64(stats.WaitCount), metric.WithAttributes(labels...))
+			o.ObserveFloat64(waitTime, milliseconds(stats.WaitDuration), metric.WithAttributes(labels...))
+
+			o.ObserveInt64(dials, int64(stats.DialCount-stats.DialErrors), metric.WithAttributes(dialOKAttrs...))
+			o.ObserveInt64(dials, int64(stats.DialErrors), metric.WithAttributes(dialErrorAttrs...))
+			o.ObserveFloat64(dialTime, milliseconds(stats.DialDuration), metric.WithAttributes(labels...))
+
+			o.ObserveInt64(closed, int64(stats.IdleClosed), metric.WithAttributes(idleClosedAttrs...))
+			o.ObserveInt64(closed, int64(stats.LifetimeClosed), metric.WithAttributes(lifetimeClosedAttrs...))
+			o.ObserveInt64(closed, int64(stats.BadConnClosed), metric.WithAttributes(badConnClosedAttrs...))
+			o.ObserveInt64(closed, int64(stats.InitFailedClosed), metric.WithAttributes(initFailedClosedAttrs...))
 			return nil
 		},
 		idleMax,
@@ -147,6 +207,11 @@ func reportPoolStats(rdb *redis.Client, conf *config) error {
 		connsMax,
 		usage,
 		timeouts,
+		waits,
+		waitTime,
+		dials,
+		dialTime,
+		closed,
 	)
 
 	return err
@@ -244,6 +309,14 @@ func (mh *metricsHook) ProcessPipelineHook(
 	}
 }
 
+// withAttr returns a copy of attrs with attr, so that the attributes
+// derived from the same labels don't share the backing array.
+func withAttr(attrs []attribute.KeyValue, attr attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs)+1)
+	out = append(out, attrs...)
+	return append(out, attr)
+}
+
 func milliseconds(d time.Duration) float64 {
 	return float64(d) / float64(time.Millisecond)
 }
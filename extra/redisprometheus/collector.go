@@ -21,6 +21,14 @@ type Collector struct {
 	totalDesc   *prometheus.Desc
 	idleDesc    *prometheus.Desc
 	staleDesc   *prometheus.Desc
+	inUseDesc   *prometheus.Desc
+
+	waitDesc         *prometheus.Desc
+	waitDurationDesc *prometheus.Desc
+	dialDesc         *prometheus.Desc
+	dialErrorDesc    *prometheus.Desc
+	dialDurationDesc *prometheus.Desc
+	closedDesc       *prometheus.Desc
 }
 
 var _ prometheus.Collector = (*Collector)(nil)
@@ -35,6 +43,13 @@ var _ prometheus.Collector = (*Collector)(nil)
 //   - pool_conn_total_current
 //   - pool_conn_idle_current
 //   - pool_conn_stale_total
+//   - pool_conn_in_use_current
+//   - pool_wait_total
+//   - pool_wait_duration_seconds_total
+//   - pool_dial_total
+//   - pool_dial_error_total
+//   - pool_dial_duration_seconds_total
+//   - pool_conn_closed_total, with the reason "idle", "lifetime", "bad_conn" or "init_failed"
 func NewCollector(namespace, subsystem string, getter StatGetter) *Collector {
 	return &Collector{
 		getter: getter,
@@ -68,6 +83,41 @@ func NewCollector(namespace, subsystem string, getter StatGetter) *Collector {
 			"Number of times a connection was removed from the pool because it was stale",
 			nil, nil,
 		),
+		inUseDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_conn_in_use_current"),
+			"Current number of connections in use",
+			nil, nil,
+		),
+		waitDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_wait_total"),
+			"Number of times a connection was waited for",
+			nil, nil,
+		),
+		waitDurationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_wait_duration_seconds_total"),
+			"Total time spent waiting for a connection",
+			nil, nil,
+		),
+		dialDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_dial_total"),
+			"Number of times a connection was dialed",
+			nil, nil,
+		),
+		dialErrorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_dial_error_total"),
+			"Number of times dialing a connection failed",
+			nil, nil,
+		),
+		dialDurationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_dial_duration_seconds_total"),
+			"Total time spent dialing connections",
+			nil, nil,
+		),
+		closedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pool_conn_closed_total"),
+			"Number of connections closed by the pool for the reason",
+			[]string{"reason"}, nil,
+		),
 	}
 }
 
@@ -79,6 +129,13 @@ func (s *Collector) Describe(descs chan<- *prometheus.Desc) {
 	descs <- s.totalDesc
 	descs <- s.idleDesc
 	descs <- s.staleDesc
+	descs <- s.inUseDesc
+	descs <- s.waitDesc
+	descs <- s.waitDurationDesc
+	descs <- s.dialDesc
+	descs <- s.dialErrorDesc
+	descs <- s.dialDurationDesc
+	descs <- s.closedDesc
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -114,4 +171,58 @@ func (s *Collector) Collect(metrics chan<- prometheus.Metric) {
 		prometheus.CounterValue,
 		float64(stats.StaleConns),
 	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.inUseDesc,
+		prometheus.GaugeValue,
+		float64(stats.InUseConns),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.waitDesc,
+		prometheus.CounterValue,
+		float64(stats.WaitCount),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.waitDurationDesc,
+		prometheus.CounterValue,
+		stats.WaitDuration.Seconds(),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.dialDesc,
+		prometheus.CounterValue,
+		float64(stats.DialCount),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.dialErrorDesc,
+		prometheus.CounterValue,
+		float64(stats.DialErrors),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.dialDurationDesc,
+		prometheus.CounterValue,
+		stats.DialDuration.Seconds(),
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.closedDesc,
+		prometheus.CounterValue,
+		float64(stats.IdleClosed),
+		"idle",
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.closedDesc,
+		prometheus.CounterValue,
+		float64(stats.LifetimeClosed),
+		"lifetime",
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.closedDesc,
+		prometheus.CounterValue,
+		float64(stats.BadConnClosed),
+		"bad_conn",
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		s.closedDesc,
+		prometheus.CounterValue,
+		float64(stats.InitFailedClosed),
+		"init_failed",
+	)
 }
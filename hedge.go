@@ -0,0 +1,362 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+// HedgeOptions enables hedged reads in a ClusterClient with ReadOnly.
+//
+// When the node that was picked for a read-only command doesn't reply within
+// the hedge delay, the command is also sent to another node serving the slot,
+// a replica or the master. The first reply is used and the context of the
+// other attempt is canceled. An attempt whose command was already sent still
+// reads its reply, so that its connection can be reused.
+//
+// A hedged command goes once through the hooks of the node it was picked
+// for, whichever node replies first, and the hooks of the other node are
+// skipped.
+//
+// Blocking commands and WriterCmd are never hedged, and neither are retries
+// and redirects.
+type HedgeOptions struct {
+	// Delay after which a read-only command is hedged.
+	// With Percentile, it is the minimum delay.
+	Delay time.Duration
+	// Percentile, between 0 and 100, of the recent latencies of the node
+	// after which a read-only command is hedged. Nodes are hedged with
+	// Delay until they replied to enough commands, or not at all when
+	// Delay is 0. Default is 95 when Delay is 0, otherwise Delay is used.
+	Percentile float64
+
+	// Budget is the ratio of read-only commands that may be hedged,
+	// e.g. 0.05 for 5%. Unused hedges accumulate up to 10 hedges.
+	// Default is 0.1.
+	Budget float64
+}
+
+func (opt *HedgeOptions) init() {
+	if opt.Delay <= 0 && opt.Percentile <= 0 {
+		opt.Percentile = 95
+	}
+	if opt.Percentile > 100 {
+		opt.Percentile = 100
+	}
+	if opt.Budget <= 0 {
+		opt.Budget = 0.1
+	}
+}
+
+// HedgeStats contains the accumulated stats of hedged reads.
+type HedgeStats struct {
+	Reads    uint64 // number of read-only commands that could be hedged
+	Hedges   uint64 // number of hedges sent
+	Wins     uint64 // number of hedges that replied first
+	Rejected uint64 // number of hedges not sent because of the budget
+}
+
+const (
+	// hedgeMaxTokens is the number of hedges the budget accumulates.
+	hedgeMaxTokens = 10
+	// hedgeLatencies is the number of latencies kept per node.
+	hedgeLatencies = 128
+	// hedgeMinLatencies is the number of latencies needed to compute
+	// a percentile, and how often it is recomputed.
+	hedgeMinLatencies = 16
+)
+
+type hedger struct {
+	stats HedgeStats // atomic
+
+	opt *HedgeOptions
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+func newHedger(opt *HedgeOptions) *hedger {
+	if opt == nil {
+		return nil
+	}
+	return &hedger{
+		opt:    opt,
+		tokens: hedgeMaxTokens,
+	}
+}
+
+// delay returns how long to wait for node before hedging.
+func (h *hedger) delay(node *clusterNode) (time.Duration, bool) {
+	if node.latencies != nil {
+		if d, ok := node.latencies.percentile(); ok {
+			if d < h.opt.Delay {
+				d = h.opt.Delay
+			}
+			return d, true
+		}
+	}
+	return h.opt.Delay, h.opt.Delay > 0
+}
+
+// read adds the share of a read-only command to the budget.
+func (h *hedger) read() {
+	atomic.AddUint64(&h.stats.Reads, 1)
+
+	h.mu.Lock()
+	h.tokens += h.opt.Budget
+	if h.tokens > hedgeMaxTokens {
+		h.tokens = hedgeMaxTokens
+	}
+	h.mu.Unlock()
+}
+
+// allow reports whether the budget allows a hedge.
+func (h *hedger) allow() bool {
+	h.mu.Lock()
+	ok := h.tokens >= 1
+	if ok {
+		h.tokens--
+	}
+	h.mu.Unlock()
+
+	if ok {
+		atomic.AddUint64(&h.stats.Hedges, 1)
+	} else {
+		atomic.AddUint64(&h.stats.Rejected, 1)
+	}
+	return ok
+}
+
+func (h *hedger) Stats() *HedgeStats {
+	return &HedgeStats{
+		Reads:    atomic.LoadUint64(&h.stats.Reads),
+		Hedges:   atomic.LoadUint64(&h.stats.Hedges),
+		Wins:     atomic.LoadUint64(&h.stats.Wins),
+		Rejected: atomic.LoadUint64(&h.stats.Rejected),
+	}
+}
+
+// hedgeable reports whether cmd can be sent to two nodes at once.
+func hedgeable(cmd Cmder) bool {
+	if isBlockingCmd(cmd) {
+		return false
+	}
+	_, ok := cmd.(*WriterCmd)
+	return !ok
+}
+
+//------------------------------------------------------------------------------
+
+// latencyWindow keeps the latest latencies of a node and their percentile.
+type latencyWindow struct {
+	p float64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	added     int // since the percentile was computed
+	value     time.Duration
+	ok        bool
+}
+
+func newLatencyWindow(p float64) *latencyWindow {
+	return &latencyWindow{
+		p:         p,
+		latencies: make([]time.Duration, 0, hedgeLatencies),
+	}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.latencies) < hedgeLatencies {
+		w.latencies = append(w.latencies, d)
+	} else {
+		w.latencies[w.next] = d
+		w.next = (w.next + 1) % hedgeLatencies
+	}
+
+	if w.added++; w.added < hedgeMinLatencies {
+		return
+	}
+	w.added = 0
+
+	sorted := make([]time.Duration, len(w.latencies))
+	copy(sorted, w.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(w.p / 100 * float64(len(sorted)-1))
+	w.value, w.ok = sorted[i], true
+}
+
+func (w *latencyWindow) percentile() (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.value, w.ok
+}
+
+//------------------------------------------------------------------------------
+
+// hedgeCmd is an attempt of a hedged read. It keeps the raw reply and the
+// error of the attempt instead of setting them on the command, since both
+// attempts run at the same time.
+type hedgeCmd struct {
+	Cmder
+	node *clusterNode
+
+	raw   []byte
+	attrs []proto.ValuePair
+	err   error
+}
+
+func (cmd *hedgeCmd) String() string {
+	return cmdString(cmd, nil)
+}
+
+func (cmd *hedgeCmd) SetErr(e error) {
+	cmd.err = e
+}
+
+func (cmd *hedgeCmd) Err() error {
+	return cmd.err
+}
+
+func (cmd *hedgeCmd) Attributes() map[interface{}]interface{} {
+	return nil
+}
+
+func (cmd *hedgeCmd) setAttributes(attrs []proto.ValuePair) {
+	cmd.attrs = attrs
+}
+
+func (cmd *hedgeCmd) readReply(rd *proto.Reader) error {
+	raw, err := rd.ReadRawReply()
+	if err != nil {
+		return err
+	}
+	cmd.raw = raw
+
+	switch raw[0] {
+	case proto.RespError, proto.RespBlobError:
+		// Return the error so that it is handled like the error of the command.
+		_, err = cmd.reader().ReadReply()
+		return err
+	}
+	return nil
+}
+
+func (cmd *hedgeCmd) reader() *proto.Reader {
+	return proto.NewReaderSize(bytes.NewReader(cmd.raw), len(cmd.raw))
+}
+
+// apply sets the reply of the attempt on the command.
+func (cmd *hedgeCmd) apply(c Cmder) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	c.setAttributes(cmd.attrs)
+	return c.readReply(cmd.reader())
+}
+
+// processHedged sends a read-only command to node and, if it doesn't reply
+// within the hedge delay, to another node serving the slot.
+func (c *ClusterClient) processHedged(ctx context.Context, cmd Cmder, slot int, node *clusterNode) error {
+	h := c.hedger
+	h.read()
+
+	delay, ok := h.delay(node)
+	if !ok {
+		start := time.Now()
+		err := node.process(ctx, cmd)
+		if err == nil || err == Nil {
+			node.addLatency(time.Since(start))
+		}
+		return err
+	}
+
+	// The attempts run below the hooks, which see the command itself.
+	err := node.Client.withProcessHook(ctx, cmd, func(ctx context.Context, cmd Cmder) error {
+		return c.hedge(ctx, cmd, slot, node, delay)
+	})
+	cmd.SetErr(err)
+	return err
+}
+
+// hedge sends the attempts of a hedged read.
+func (c *ClusterClient) hedge(
+	ctx context.Context, cmd Cmder, slot int, node *clusterNode, delay time.Duration,
+) error {
+	h := c.hedger
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan *hedgeCmd, 2)
+	run := func(node *clusterNode) {
+		attempt := &hedgeCmd{Cmder: cmd, node: node}
+		start := time.Now()
+		attempt.err = node.processAttempt(ctx, attempt)
+		if attempt.err == nil || attempt.err == Nil {
+			node.addLatency(time.Since(start))
+		}
+		done <- attempt
+	}
+	go run(node)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var failed *hedgeCmd
+	pending, hedged := 1, false
+	for {
+		select {
+		case attempt := <-done:
+			pending--
+			if attempt.err == nil {
+				if attempt.node != node {
+					atomic.AddUint64(&h.stats.Wins, 1)
+				}
+				return attempt.apply(cmd)
+			}
+			if !hedged || pending == 0 {
+				if failed != nil && failed.node == node {
+					// Report the error of the node picked for the command.
+					attempt = failed
+				}
+				return attempt.apply(cmd)
+			}
+			failed = attempt
+		case <-timer.C:
+			hedged = true
+			other := c.hedgeNode(ctx, slot, node)
+			if other != nil && h.allow() {
+				pending++
+				go run(other)
+			}
+		}
+	}
+}
+
+// hedgeNode returns a random node serving the slot other than node,
+// or nil if they are all failing.
+func (c *ClusterClient) hedgeNode(ctx context.Context, slot int, node *clusterNode) *clusterNode {
+	state, err := c.state.Get(ctx)
+	if err != nil {
+		return nil
+	}
+
+	nodes := state.slotNodes(slot)
+	for _, i := range rand.Perm(len(nodes)) {
+		if n := nodes[i]; n != node && !n.Failing() {
+			return n
+		}
+	}
+	return nil
+}
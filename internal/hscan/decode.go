@@ -0,0 +1,231 @@
+package hscan
+
+import (
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+	"github.com/redis/go-redis/v9/internal/util"
+)
+
+// Decode decodes a reply as returned by proto.Reader.ReadReply into dst,
+// which should be a non-nil pointer. Maps and arrays with an even number
+// of elements are decoded into structs by the `redis` tags of their fields,
+// arrays into slices and maps into maps, recursively.
+func Decode(dst interface{}, src interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("redis.Decode(non-pointer %T)", dst)
+	}
+	return decodeValue(v.Elem(), src)
+}
+
+func decodeValue(v reflect.Value, src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case proto.RedisError:
+		return src
+	}
+
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	if ok, err := decodeUnmarshaler(v, src); ok {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return decodeValue(v.Elem(), src)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			break
+		}
+		v.Set(reflect.ValueOf(src))
+		return nil
+	case reflect.Struct:
+		return decodeStruct(v, src)
+	case reflect.Map:
+		return decodeMap(v, src)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return decodeSliceValue(v, src)
+		}
+		return decodeScalar(v, src)
+	case reflect.Array:
+		return decodeArray(v, src)
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return decodeScalar(v, src)
+	}
+	return fmt.Errorf("redis.Decode(unsupported %s)", v.Type())
+}
+
+// decodeUnmarshaler uses Scanner and encoding.TextUnmarshaler for scalar replies.
+func decodeUnmarshaler(v reflect.Value, src interface{}) (bool, error) {
+	if v.Kind() != reflect.Ptr {
+		if v.Type().Name() == "" || !v.CanAddr() {
+			return false, nil
+		}
+		v = v.Addr()
+	}
+	if v.Type().NumMethod() == 0 || !v.CanInterface() {
+		return false, nil
+	}
+
+	switch scan := v.Interface().(type) {
+	case Scanner:
+		s, ok := scalarString(src)
+		if !ok {
+			return false, nil
+		}
+		return true, scan.ScanRedis(s)
+	case encoding.TextUnmarshaler:
+		s, ok := scalarString(src)
+		if !ok {
+			return false, nil
+		}
+		return true, scan.UnmarshalText(util.StringToBytes(s))
+	}
+	return false, nil
+}
+
+func decodeScalar(v reflect.Value, src interface{}) error {
+	s, ok := scalarString(src)
+	if !ok {
+		return decodeTypeErr(v, src)
+	}
+	if err := decoders[v.Kind()](v, s); err != nil {
+		return fmt.Errorf("redis.Decode(%q into %s): %w", s, v.Type(), err)
+	}
+	return nil
+}
+
+func decodeStruct(v reflect.Value, src interface{}) error {
+	t := v.Type()
+	spec := globalStructMap.get(t)
+	return decodePairs(v, src, func(key, val interface{}) error {
+		k, ok := scalarString(key)
+		if !ok {
+			return nil
+		}
+		field, ok := spec.m[k]
+		if !ok {
+			return nil
+		}
+		f := v.Field(field.index)
+		if !f.CanSet() {
+			return nil
+		}
+		if err := decodeValue(f, val); err != nil {
+			return fmt.Errorf("redis.Decode(field %s.%s): %w", t.Name(), t.Field(field.index).Name, err)
+		}
+		return nil
+	})
+}
+
+func decodeMap(v reflect.Value, src interface{}) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	return decodePairs(v, src, func(key, val interface{}) error {
+		k := reflect.New(t.Key()).Elem()
+		if err := decodeValue(k, key); err != nil {
+			return err
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := decodeValue(e, val); err != nil {
+			return fmt.Errorf("redis.Decode(key %v): %w", key, err)
+		}
+		v.SetMapIndex(k, e)
+		return nil
+	})
+}
+
+// decodePairs calls fn for the pairs of RESP3 maps and of RESP2 arrays
+// that hold keys and values in turn.
+func decodePairs(v reflect.Value, src interface{}, fn func(key, val interface{}) error) error {
+	switch src := src.(type) {
+	case map[interface{}]interface{}:
+		for key, val := range src {
+			if err := fn(key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(src)%2 != 0 {
+			return fmt.Errorf("redis.Decode(odd array of %d elements into %s)", len(src), v.Type())
+		}
+		for i := 0; i < len(src); i += 2 {
+			if err := fn(src[i], src[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return decodeTypeErr(v, src)
+}
+
+func decodeSliceValue(v reflect.Value, src interface{}) error {
+	elems, ok := src.([]interface{})
+	if !ok {
+		return decodeTypeErr(v, src)
+	}
+	s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := decodeValue(s.Index(i), elem); err != nil {
+			return fmt.Errorf("redis.Decode(index %d): %w", i, err)
+		}
+	}
+	v.Set(s)
+	return nil
+}
+
+func decodeArray(v reflect.Value, src interface{}) error {
+	elems, ok := src.([]interface{})
+	if !ok {
+		return decodeTypeErr(v, src)
+	}
+	if len(elems) > v.Len() {
+		return fmt.Errorf("redis.Decode(array of %d elements into %s)", len(elems), v.Type())
+	}
+	for i := 0; i < v.Len(); i++ {
+		var elem interface{}
+		if i < len(elems) {
+			elem = elems[i]
+		}
+		if err := decodeValue(v.Index(i), elem); err != nil {
+			return fmt.Errorf("redis.Decode(index %d): %w", i, err)
+		}
+	}
+	return nil
+}
+
+func decodeTypeErr(v reflect.Value, src interface{}) error {
+	return fmt.Errorf("redis.Decode(%T into %s)", src, v.Type())
+}
+
+// scalarString formats the scalar replies of ReadReply like ReadString does.
+func scalarString(src interface{}) (string, bool) {
+	switch src := src.(type) {
+	case string:
+		return src, true
+	case int64:
+		return strconv.FormatInt(src, 10), true
+	case float64:
+		return strconv.FormatFloat(src, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(src), true
+	case *big.Int:
+		return src.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,138 @@
+package hscan
+
+import (
+	"math/big"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+type (
+	m = map[interface{}]interface{}
+	a = []interface{}
+)
+
+type consumer struct {
+	Name    string `redis:"name"`
+	SeenAt  int64  `redis:"seen-time"`
+	Pending []struct {
+		ID    string `redis:"id"`
+		Count int    `redis:"count"`
+	} `redis:"pending"`
+}
+
+type group struct {
+	Name      string      `redis:"name"`
+	Consumers []*consumer `redis:"consumers"`
+}
+
+type streamInfo struct {
+	Length  int64             `redis:"length"`
+	Groups  []group           `redis:"groups"`
+	Entries [][2]interface{}  `redis:"entries"`
+	Flags   map[string]bool   `redis:"flags"`
+	Meta    *map[string]int64 `redis:"meta"`
+	Missing *group            `redis:"missing"`
+	Created *TimeRFC3339Nano  `redis:"created"`
+	Raw     interface{}       `redis:"raw"`
+}
+
+var _ = Describe("Decode", func() {
+	It("decodes nested RESP3 maps and arrays", func() {
+		now := time.Now()
+		reply := m{
+			"length": int64(2),
+			"groups": a{
+				m{
+					"name": "g1",
+					"consumers": a{
+						m{
+							"name":      "c1",
+							"seen-time": int64(42),
+							"pending":   a{m{"id": "1-0", "count": int64(3)}},
+						},
+					},
+				},
+			},
+			"entries": a{a{"1-0", a{"f", "v"}}},
+			"flags":   m{"a": true, "b": int64(0)},
+			"meta":    m{"x": "7"},
+			"missing": nil,
+			"created": now.Format(time.RFC3339Nano),
+			"raw":     a{"x", int64(1)},
+			"ignored": "value",
+		}
+
+		var info streamInfo
+		Expect(Decode(&info, reply)).NotTo(HaveOccurred())
+		Expect(info.Length).To(Equal(int64(2)))
+		Expect(info.Groups).To(HaveLen(1))
+		Expect(info.Groups[0].Name).To(Equal("g1"))
+		Expect(info.Groups[0].Consumers).To(HaveLen(1))
+		c := info.Groups[0].Consumers[0]
+		Expect(c.Name).To(Equal("c1"))
+		Expect(c.SeenAt).To(Equal(int64(42)))
+		Expect(c.Pending).To(HaveLen(1))
+		Expect(c.Pending[0].ID).To(Equal("1-0"))
+		Expect(c.Pending[0].Count).To(Equal(3))
+		Expect(info.Entries).To(Equal([][2]interface{}{{"1-0", a{"f", "v"}}}))
+		Expect(info.Flags).To(Equal(map[string]bool{"a": true, "b": false}))
+		Expect(*info.Meta).To(Equal(map[string]int64{"x": 7}))
+		Expect(info.Missing).To(BeNil())
+		Expect(info.Created.UnixNano()).To(Equal(now.UnixNano()))
+		Expect(info.Raw).To(Equal(a{"x", int64(1)}))
+	})
+
+	It("decodes RESP2 key-value arrays into structs and maps", func() {
+		var c consumer
+		Expect(Decode(&c, a{"name", "c1", "seen-time", "42"})).NotTo(HaveOccurred())
+		Expect(c.Name).To(Equal("c1"))
+		Expect(c.SeenAt).To(Equal(int64(42)))
+
+		var mm map[string]float64
+		Expect(Decode(&mm, a{"a", "1.5", "b", int64(2)})).NotTo(HaveOccurred())
+		Expect(mm).To(Equal(map[string]float64{"a": 1.5, "b": 2}))
+
+		Expect(Decode(&c, a{"name"})).To(MatchError(ContainSubstring("odd array")))
+	})
+
+	It("decodes scalars", func() {
+		var s string
+		Expect(Decode(&s, big.NewInt(123))).NotTo(HaveOccurred())
+		Expect(s).To(Equal("123"))
+
+		var b []byte
+		Expect(Decode(&b, "bytes")).NotTo(HaveOccurred())
+		Expect(b).To(Equal([]byte("bytes")))
+
+		var u uint8
+		Expect(Decode(&u, int64(256))).To(HaveOccurred())
+
+		var n int
+		Expect(Decode(&n, 1.5)).To(HaveOccurred())
+
+		var keys map[int64]string
+		Expect(Decode(&keys, m{int64(1): "one"})).NotTo(HaveOccurred())
+		Expect(keys).To(Equal(map[int64]string{1: "one"}))
+	})
+
+	It("catches bad args", func() {
+		var c consumer
+		Expect(Decode(c, m{})).To(MatchError("redis.Decode(non-pointer hscan.consumer)"))
+		Expect(Decode(&c, "string")).To(MatchError("redis.Decode(string into hscan.consumer)"))
+		Expect(Decode(&c, m{"pending": "x"})).To(MatchError(
+			"redis.Decode(field consumer.Pending): redis.Decode(string into []struct { ID string \"redis:\\\"id\\\"\"; Count int \"redis:\\\"count\\\"\" })"))
+
+		var arr [1]string
+		Expect(Decode(&arr, a{"a", "b"})).To(HaveOccurred())
+
+		var ch chan int
+		Expect(Decode(&ch, "x")).To(MatchError("redis.Decode(unsupported chan int)"))
+
+		err := Decode(&c, m{"name": proto.RedisError("ERR nested")})
+		Expect(err).To(MatchError(ContainSubstring("ERR nested")))
+	})
+})
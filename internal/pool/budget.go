@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBudgetTimeout timed out waiting for the connection budget.
+var ErrBudgetTimeout = errors.New("redis: connection budget timeout")
+
+var errBudgetFull = errors.New("redis: connection budget is full")
+
+// BudgetStats contains the state and accumulated stats of a budget.
+type BudgetStats struct {
+	Conns        uint32        // number of connections counted towards the budget
+	WaitCount    uint32        // number of times a connection waited for the budget
+	WaitDuration time.Duration // total time spent waiting for the budget
+	Timeouts     uint32        // number of times a wait timeout occurred
+	Evictions    uint32        // number of idle connections closed to make room
+}
+
+// Budget limits the number of connections of several pools together.
+// When it is exhausted, the least recently used idle connection of the pool
+// with the most idle connections is closed to make room. Without idle
+// connections, pools wait for the budget in the order they started waiting
+// and the connections put back in the meantime are closed instead of
+// becoming idle.
+type Budget struct {
+	waitDuration int64 // atomic, first to be 64-bit aligned
+
+	sem     *semaphore
+	timeout time.Duration
+
+	mu    sync.Mutex
+	pools []*ConnPool
+
+	stats BudgetStats
+}
+
+func NewBudget(maxConns int, timeout time.Duration) *Budget {
+	return &Budget{
+		sem:     newSemaphore(maxConns),
+		timeout: timeout,
+	}
+}
+
+func (b *Budget) Stats() *BudgetStats {
+	return &BudgetStats{
+		Conns:        atomic.LoadUint32(&b.stats.Conns),
+		WaitCount:    atomic.LoadUint32(&b.stats.WaitCount),
+		WaitDuration: time.Duration(atomic.LoadInt64(&b.waitDuration)),
+		Timeouts:     atomic.LoadUint32(&b.stats.Timeouts),
+		Evictions:    atomic.LoadUint32(&b.stats.Evictions),
+	}
+}
+
+func (b *Budget) addPool(p *ConnPool) {
+	b.mu.Lock()
+	b.pools = append(b.pools, p)
+	b.mu.Unlock()
+}
+
+func (b *Budget) removePool(p *ConnPool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, other := range b.pools {
+		if other == p {
+			b.pools = append(b.pools[:i], b.pools[i+1:]...)
+			return
+		}
+	}
+}
+
+// acquire counts a new connection towards the budget.
+func (b *Budget) acquire(ctx context.Context) error {
+	if b.sem.tryAcquire() || b.evictIdle() && b.sem.tryAcquire() {
+		atomic.AddUint32(&b.stats.Conns, 1)
+		return nil
+	}
+
+	start := time.Now()
+	// Connections may have become idle since the fast path.
+	err := b.sem.acquire(ctx, b.timeout, func() { b.evictIdle() })
+	atomic.AddUint32(&b.stats.WaitCount, 1)
+	atomic.AddInt64(&b.waitDuration, int64(time.Since(start)))
+	if err == ErrPoolTimeout {
+		atomic.AddUint32(&b.stats.Timeouts, 1)
+		return ErrBudgetTimeout
+	}
+	if err != nil {
+		return err
+	}
+	atomic.AddUint32(&b.stats.Conns, 1)
+	return nil
+}
+
+// tryAcquire counts a new connection towards the budget if there is room
+// without evicting idle connections or waiting.
+func (b *Budget) tryAcquire() bool {
+	if !b.sem.tryAcquire() {
+		return false
+	}
+	atomic.AddUint32(&b.stats.Conns, 1)
+	return true
+}
+
+// release returns the budget of a closed connection.
+func (b *Budget) release() {
+	atomic.AddUint32(&b.stats.Conns, ^uint32(0))
+	b.sem.release()
+}
+
+// waiting reports whether pools wait for the budget.
+func (b *Budget) waiting() bool {
+	return b.sem.waiting()
+}
+
+func (b *Budget) addEviction() {
+	atomic.AddUint32(&b.stats.Evictions, 1)
+}
+
+// evictIdle closes an idle connection of the pool with the most
+// idle connections.
+func (b *Budget) evictIdle() bool {
+	b.mu.Lock()
+	pools := make([]*ConnPool, len(b.pools))
+	copy(pools, b.pools)
+	b.mu.Unlock()
+
+	var victim *ConnPool
+	var maxIdle int
+	for _, p := range pools {
+		if n := p.IdleLen(); n > maxIdle {
+			victim, maxIdle = p, n
+		}
+	}
+	if victim == nil || !victim.evictIdle() {
+		return false
+	}
+	b.addEviction()
+	return true
+}
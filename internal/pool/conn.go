@@ -23,6 +23,10 @@ type Conn struct {
 	Inited    bool
 	pooled    bool
 	createdAt time.Time
+
+	TrackingID int64 // id of the client the invalidations are redirected to
+
+	budgeted uint32 // atomic, whether the conn counts towards Options.Budget
 }
 
 func NewConn(netConn net.Conn) *Conn {
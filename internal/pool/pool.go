@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9/internal"
+	"github.com/redis/go-redis/v9/internal/proto"
 )
 
 var (
@@ -37,9 +38,26 @@ type Stats struct {
 	Misses   uint32 // number of times free connection was NOT found in the pool
 	Timeouts uint32 // number of times a wait timeout occurred
 
+	WaitCount    uint32        // number of times a connection was waited for
+	WaitDuration time.Duration // total time spent waiting for a connection
+
 	TotalConns uint32 // number of total connections in the pool
 	IdleConns  uint32 // number of idle connections in the pool
 	StaleConns uint32 // number of stale connections removed from the pool
+	InUseConns uint32 // number of connections in use
+
+	DialCount    uint32        // number of times a connection was dialed
+	DialErrors   uint32        // number of times dialing a connection failed
+	DialDuration time.Duration // total time spent dialing connections
+
+	IdleClosed       uint32 // number of connections closed after ConnMaxIdleTime
+	LifetimeClosed   uint32 // number of connections closed after ConnMaxLifetime
+	BadConnClosed    uint32 // number of connections closed because they were broken
+	InitFailedClosed uint32 // number of connections closed because their initialization failed
+
+	MaintenanceRuns   uint32 // number of times the idle connections were maintained in the background
+	HealthChecks      uint32 // number of times an idle connection was checked with HealthCheck
+	HealthCheckErrors uint32 // number of times HealthCheck failed
 }
 
 type Pooler interface {
@@ -68,6 +86,19 @@ type Options struct {
 	MaxActiveConns  int
 	ConnMaxIdleTime time.Duration
 	ConnMaxLifetime time.Duration
+
+	// IdleCheckFrequency enables the background maintenance of the idle
+	// connections, which runs at this interval.
+	IdleCheckFrequency time.Duration
+	// HealthCheck checks the idle connections that haven't been used
+	// for HealthCheckInterval during the maintenance.
+	HealthCheck         func(context.Context, *Conn) error
+	HealthCheckInterval time.Duration
+
+	// Budget limits the connections of this and other pools together.
+	Budget *Budget
+
+	ReaderLimits proto.Limits
 }
 
 type lastDialErrorWrap struct {
@@ -75,12 +106,16 @@ type lastDialErrorWrap struct {
 }
 
 type ConnPool struct {
+	// Accessed atomically, first to be 64-bit aligned.
+	waitDuration int64
+	dialDuration int64
+
 	cfg *Options
 
 	dialErrorsNum uint32 // atomic
 	lastDialError atomic.Value
 
-	queue chan struct{}
+	queue *semaphore
 
 	connsMu   sync.Mutex
 	conns     []*Conn
@@ -89,9 +124,15 @@ type ConnPool struct {
 	poolSize     int
 	idleConnsLen int
 
+	// The limits that Resize changes.
+	size           int
+	minIdleConns   int
+	maxActiveConns int
+
 	stats Stats
 
-	_closed uint32 // atomic
+	_closed  uint32 // atomic
+	closedCh chan struct{}
 }
 
 var _ Pooler = (*ConnPool)(nil)
@@ -100,47 +141,58 @@ func NewConnPool(opt *Options) *ConnPool {
 	p := &ConnPool{
 		cfg: opt,
 
-		queue:     make(chan struct{}, opt.PoolSize),
+		queue:     newSemaphore(opt.PoolSize),
 		conns:     make([]*Conn, 0, opt.PoolSize),
 		idleConns: make([]*Conn, 0, opt.PoolSize),
+		closedCh:  make(chan struct{}),
+
+		size:           opt.PoolSize,
+		minIdleConns:   opt.MinIdleConns,
+		maxActiveConns: opt.MaxActiveConns,
+	}
+
+	if opt.Budget != nil {
+		opt.Budget.addPool(p)
 	}
 
 	p.connsMu.Lock()
 	p.checkMinIdleConns()
 	p.connsMu.Unlock()
 
+	if opt.IdleCheckFrequency > 0 {
+		go p.reaper(opt.IdleCheckFrequency)
+	}
+
 	return p
 }
 
 func (p *ConnPool) checkMinIdleConns() {
-	if p.cfg.MinIdleConns == 0 {
+	if p.minIdleConns == 0 {
 		return
 	}
-	for p.poolSize < p.cfg.PoolSize && p.idleConnsLen < p.cfg.MinIdleConns {
-		select {
-		case p.queue <- struct{}{}:
-			p.poolSize++
-			p.idleConnsLen++
-
-			go func() {
-				err := p.addIdleConn()
-				if err != nil && err != ErrClosed {
-					p.connsMu.Lock()
-					p.poolSize--
-					p.idleConnsLen--
-					p.connsMu.Unlock()
-				}
-
-				p.freeTurn()
-			}()
-		default:
+	for p.poolSize < p.size && p.idleConnsLen < p.minIdleConns {
+		if !p.queue.tryAcquire() {
 			return
 		}
+		p.poolSize++
+		p.idleConnsLen++
+
+		go func() {
+			err := p.addIdleConn()
+			if err != nil && err != ErrClosed {
+				p.connsMu.Lock()
+				p.poolSize--
+				p.idleConnsLen--
+				p.connsMu.Unlock()
+			}
+
+			p.freeTurn()
+		}()
 	}
 }
 
 func (p *ConnPool) addIdleConn() error {
-	cn, err := p.dialConn(context.TODO(), true)
+	cn, err := p.dialConn(context.TODO(), true, true)
 	if err != nil {
 		return err
 	}
@@ -150,7 +202,7 @@ func (p *ConnPool) addIdleConn() error {
 
 	// It is not allowed to add new connections to the closed connection pool.
 	if p.closed() {
-		_ = cn.Close()
+		_ = p.closeConn(cn)
 		return ErrClosed
 	}
 
@@ -168,11 +220,14 @@ func (p *ConnPool) newConn(ctx context.Context, pooled bool) (*Conn, error) {
 		return nil, ErrClosed
 	}
 
-	if p.cfg.MaxActiveConns > 0 && p.poolSize >= p.cfg.MaxActiveConns {
+	p.connsMu.Lock()
+	exhausted := p.maxActiveConns > 0 && p.poolSize >= p.maxActiveConns
+	p.connsMu.Unlock()
+	if exhausted {
 		return nil, ErrPoolExhausted
 	}
 
-	cn, err := p.dialConn(ctx, pooled)
+	cn, err := p.dialConn(ctx, pooled, false)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +238,7 @@ func (p *ConnPool) newConn(ctx context.Context, pooled bool) (*Conn, error) {
 	p.conns = append(p.conns, cn)
 	if pooled {
 		// If pool is full remove the cn on next Put.
-		if p.poolSize >= p.cfg.PoolSize {
+		if p.poolSize >= p.size {
 			cn.pooled = false
 		} else {
 			p.poolSize++
@@ -193,7 +248,10 @@ func (p *ConnPool) newConn(ctx context.Context, pooled bool) (*Conn, error) {
 	return cn, nil
 }
 
-func (p *ConnPool) dialConn(ctx context.Context, pooled bool) (*Conn, error) {
+// dialConn dials a new connection. The idle connections dialed for
+// MinIdleConns only use free budget: they neither evict the idle connections
+// of other pools nor wait for the budget.
+func (p *ConnPool) dialConn(ctx context.Context, pooled, idle bool) (*Conn, error) {
 	if p.closed() {
 		return nil, ErrClosed
 	}
@@ -202,8 +260,21 @@ func (p *ConnPool) dialConn(ctx context.Context, pooled bool) (*Conn, error) {
 		return nil, p.getLastDialError()
 	}
 
-	netConn, err := p.cfg.Dialer(ctx)
+	if b := p.cfg.Budget; b != nil {
+		if idle {
+			if !b.tryAcquire() {
+				return nil, errBudgetFull
+			}
+		} else if err := b.acquire(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	netConn, err := p.dial(ctx)
 	if err != nil {
+		if p.cfg.Budget != nil {
+			p.cfg.Budget.release()
+		}
 		p.setLastDialError(err)
 		if atomic.AddUint32(&p.dialErrorsNum, 1) == uint32(p.cfg.PoolSize) {
 			go p.tryDial()
@@ -212,7 +283,11 @@ func (p *ConnPool) dialConn(ctx context.Context, pooled bool) (*Conn, error) {
 	}
 
 	cn := NewConn(netConn)
+	cn.rd.SetLimits(p.cfg.ReaderLimits)
 	cn.pooled = pooled
+	if p.cfg.Budget != nil {
+		cn.budgeted = 1
+	}
 	return cn, nil
 }
 
@@ -222,7 +297,7 @@ func (p *ConnPool) tryDial() {
 			return
 		}
 
-		conn, err := p.cfg.Dialer(context.Background())
+		conn, err := p.dial(context.Background())
 		if err != nil {
 			p.setLastDialError(err)
 			time.Sleep(time.Second)
@@ -235,6 +310,17 @@ func (p *ConnPool) tryDial() {
 	}
 }
 
+func (p *ConnPool) dial(ctx context.Context) (net.Conn, error) {
+	start := time.Now()
+	conn, err := p.cfg.Dialer(ctx)
+	atomic.AddInt64(&p.dialDuration, int64(time.Since(start)))
+	atomic.AddUint32(&p.stats.DialCount, 1)
+	if err != nil {
+		atomic.AddUint32(&p.stats.DialErrors, 1)
+	}
+	return conn, err
+}
+
 func (p *ConnPool) setLastDialError(err error) {
 	p.lastDialError.Store(&lastDialErrorWrap{err: err})
 }
@@ -298,37 +384,25 @@ func (p *ConnPool) waitTurn(ctx context.Context) error {
 	default:
 	}
 
-	select {
-	case p.queue <- struct{}{}:
+	if p.queue.tryAcquire() {
 		return nil
-	default:
 	}
 
-	timer := timers.Get().(*time.Timer)
-	timer.Reset(p.cfg.PoolTimeout)
+	start := time.Now()
+	defer func() {
+		atomic.AddUint32(&p.stats.WaitCount, 1)
+		atomic.AddInt64(&p.waitDuration, int64(time.Since(start)))
+	}()
 
-	select {
-	case <-ctx.Done():
-		if !timer.Stop() {
-			<-timer.C
-		}
-		timers.Put(timer)
-		return ctx.Err()
-	case p.queue <- struct{}{}:
-		if !timer.Stop() {
-			<-timer.C
-		}
-		timers.Put(timer)
-		return nil
-	case <-timer.C:
-		timers.Put(timer)
+	err := p.queue.acquire(ctx, p.cfg.PoolTimeout, nil)
+	if err == ErrPoolTimeout {
 		atomic.AddUint32(&p.stats.Timeouts, 1)
-		return ErrPoolTimeout
 	}
+	return err
 }
 
 func (p *ConnPool) freeTurn() {
-	<-p.queue
+	p.queue.release()
 }
 
 func (p *ConnPool) popIdle() (*Conn, error) {
@@ -371,7 +445,16 @@ func (p *ConnPool) Put(ctx context.Context, cn *Conn) {
 
 	p.connsMu.Lock()
 
-	if p.cfg.MaxIdleConns == 0 || p.idleConnsLen < p.cfg.MaxIdleConns {
+	if p.poolSize > p.size {
+		// The pool shrank.
+		p.removeConn(cn)
+		shouldCloseConn = true
+	} else if p.cfg.Budget != nil && p.cfg.Budget.waiting() {
+		// Make room for the pools waiting for the budget.
+		p.dropConn(cn)
+		shouldCloseConn = true
+		p.cfg.Budget.addEviction()
+	} else if p.cfg.MaxIdleConns == 0 || p.idleConnsLen < p.cfg.MaxIdleConns {
 		p.idleConns = append(p.idleConns, cn)
 		p.idleConnsLen++
 	} else {
@@ -388,7 +471,24 @@ func (p *ConnPool) Put(ctx context.Context, cn *Conn) {
 	}
 }
 
+// InitError is the reason to remove a connection whose initialization failed.
+type InitError struct {
+	Err error
+}
+
+func (e *InitError) Error() string { return e.Err.Error() }
+func (e *InitError) Unwrap() error { return e.Err }
+
 func (p *ConnPool) Remove(_ context.Context, cn *Conn, reason error) {
+	var initErr *InitError
+	switch {
+	case reason == nil || errors.Is(reason, ErrClosed):
+		// The connection was removed deliberately.
+	case errors.As(reason, &initErr):
+		atomic.AddUint32(&p.stats.InitFailedClosed, 1)
+	default:
+		atomic.AddUint32(&p.stats.BadConnClosed, 1)
+	}
 	p.removeConnWithLock(cn)
 	p.freeTurn()
 	_ = p.closeConn(cn)
@@ -406,20 +506,32 @@ func (p *ConnPool) removeConnWithLock(cn *Conn) {
 }
 
 func (p *ConnPool) removeConn(cn *Conn) {
+	if p.dropConn(cn) {
+		p.checkMinIdleConns()
+	}
+}
+
+// dropConn removes cn without dialing a replacement for MinIdleConns, e.g.
+// when cn makes room in the budget. It reports whether cn was pooled.
+func (p *ConnPool) dropConn(cn *Conn) bool {
+	atomic.AddUint32(&p.stats.StaleConns, 1)
 	for i, c := range p.conns {
 		if c == cn {
 			p.conns = append(p.conns[:i], p.conns[i+1:]...)
 			if cn.pooled {
 				p.poolSize--
-				p.checkMinIdleConns()
+				return true
 			}
-			break
+			return false
 		}
 	}
-	atomic.AddUint32(&p.stats.StaleConns, 1)
+	return false
 }
 
 func (p *ConnPool) closeConn(cn *Conn) error {
+	if atomic.CompareAndSwapUint32(&cn.budgeted, 1, 0) {
+		p.cfg.Budget.release()
+	}
 	return cn.Close()
 }
 
@@ -440,17 +552,95 @@ func (p *ConnPool) IdleLen() int {
 }
 
 func (p *ConnPool) Stats() *Stats {
+	p.connsMu.Lock()
+	totalConns, idleConns := len(p.conns), p.idleConnsLen
+	p.connsMu.Unlock()
+
 	return &Stats{
 		Hits:     atomic.LoadUint32(&p.stats.Hits),
 		Misses:   atomic.LoadUint32(&p.stats.Misses),
 		Timeouts: atomic.LoadUint32(&p.stats.Timeouts),
 
-		TotalConns: uint32(p.Len()),
-		IdleConns:  uint32(p.IdleLen()),
+		WaitCount:    atomic.LoadUint32(&p.stats.WaitCount),
+		WaitDuration: time.Duration(atomic.LoadInt64(&p.waitDuration)),
+
+		TotalConns: uint32(totalConns),
+		IdleConns:  uint32(idleConns),
 		StaleConns: atomic.LoadUint32(&p.stats.StaleConns),
+		InUseConns: uint32(totalConns - idleConns),
+
+		DialCount:    atomic.LoadUint32(&p.stats.DialCount),
+		DialErrors:   atomic.LoadUint32(&p.stats.DialErrors),
+		DialDuration: time.Duration(atomic.LoadInt64(&p.dialDuration)),
+
+		IdleClosed:       atomic.LoadUint32(&p.stats.IdleClosed),
+		LifetimeClosed:   atomic.LoadUint32(&p.stats.LifetimeClosed),
+		BadConnClosed:    atomic.LoadUint32(&p.stats.BadConnClosed),
+		InitFailedClosed: atomic.LoadUint32(&p.stats.InitFailedClosed),
+
+		MaintenanceRuns:   atomic.LoadUint32(&p.stats.MaintenanceRuns),
+		HealthChecks:      atomic.LoadUint32(&p.stats.HealthChecks),
+		HealthCheckErrors: atomic.LoadUint32(&p.stats.HealthCheckErrors),
 	}
 }
 
+// Size returns the size of the pool.
+func (p *ConnPool) Size() int {
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+	return p.size
+}
+
+// Resize changes the size of the pool, the minimum number of idle connections
+// and the maximum number of active connections. When the pool shrinks, the
+// idle connections above the new size are closed right away and the
+// connections in use when they are put back.
+func (p *ConnPool) Resize(poolSize, minIdleConns, maxActiveConns int) {
+	p.queue.resize(poolSize)
+
+	var closed []*Conn
+	p.connsMu.Lock()
+	p.size = poolSize
+	p.minIdleConns = minIdleConns
+	p.maxActiveConns = maxActiveConns
+	for p.poolSize > p.size && len(p.idleConns) > 0 {
+		closed = append(closed, p.removeOldestIdle())
+	}
+	p.checkMinIdleConns()
+	p.connsMu.Unlock()
+
+	for _, cn := range closed {
+		_ = p.closeConn(cn)
+	}
+}
+
+// evictIdle closes the least recently used idle connection
+// to make room in the budget.
+func (p *ConnPool) evictIdle() bool {
+	p.connsMu.Lock()
+	if len(p.idleConns) == 0 {
+		p.connsMu.Unlock()
+		return false
+	}
+	cn := p.removeOldestIdle()
+	p.connsMu.Unlock()
+
+	_ = p.closeConn(cn)
+	return true
+}
+
+// removeOldestIdle removes the least recently used idle connection
+// without dialing a replacement.
+func (p *ConnPool) removeOldestIdle() *Conn {
+	cn := p.idleConns[0]
+	copy(p.idleConns, p.idleConns[1:])
+	p.idleConns[len(p.idleConns)-1] = nil
+	p.idleConns = p.idleConns[:len(p.idleConns)-1]
+	p.idleConnsLen--
+	p.dropConn(cn)
+	return cn
+}
+
 func (p *ConnPool) closed() bool {
 	return atomic.LoadUint32(&p._closed) == 1
 }
@@ -474,6 +664,10 @@ func (p *ConnPool) Close() error {
 	if !atomic.CompareAndSwapUint32(&p._closed, 0, 1) {
 		return ErrClosed
 	}
+	close(p.closedCh)
+	if p.cfg.Budget != nil {
+		p.cfg.Budget.removePool(p)
+	}
 
 	var firstErr error
 	p.connsMu.Lock()
@@ -494,17 +688,129 @@ func (p *ConnPool) Close() error {
 func (p *ConnPool) isHealthyConn(cn *Conn) bool {
 	now := time.Now()
 
-	if p.cfg.ConnMaxLifetime > 0 && now.Sub(cn.createdAt) >= p.cfg.ConnMaxLifetime {
+	if p.isStaleConn(cn, now) {
 		return false
 	}
+
+	cn.SetUsedAt(now)
+	return true
+}
+
+// isStaleConn reports whether cn expired or broke and counts
+// the reason it has to be closed.
+func (p *ConnPool) isStaleConn(cn *Conn, now time.Time) bool {
+	if p.cfg.ConnMaxLifetime > 0 && now.Sub(cn.createdAt) >= p.cfg.ConnMaxLifetime {
+		atomic.AddUint32(&p.stats.LifetimeClosed, 1)
+		return true
+	}
 	if p.cfg.ConnMaxIdleTime > 0 && now.Sub(cn.UsedAt()) >= p.cfg.ConnMaxIdleTime {
-		return false
+		atomic.AddUint32(&p.stats.IdleClosed, 1)
+		return true
 	}
 
 	if connCheck(cn.netConn) != nil {
-		return false
+		atomic.AddUint32(&p.stats.BadConnClosed, 1)
+		return true
 	}
+	return false
+}
 
-	cn.SetUsedAt(now)
-	return true
+//------------------------------------------------------------------------------
+
+func (p *ConnPool) reaper(frequency time.Duration) {
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.maintain()
+		case <-p.closedCh:
+			return
+		}
+	}
+}
+
+// maintain closes the stale idle connections, checks the idle connections
+// that haven't been used for HealthCheckInterval and dials connections
+// up to MinIdleConns.
+func (p *ConnPool) maintain() {
+	if p.closed() {
+		return
+	}
+	atomic.AddUint32(&p.stats.MaintenanceRuns, 1)
+
+	now := time.Now()
+	var stale []*Conn
+
+	p.connsMu.Lock()
+	idleConns := p.idleConns[:0]
+	for _, cn := range p.idleConns {
+		if p.isStaleConn(cn, now) {
+			stale = append(stale, cn)
+			continue
+		}
+		idleConns = append(idleConns, cn)
+	}
+	for i := len(idleConns); i < len(p.idleConns); i++ {
+		p.idleConns[i] = nil
+	}
+	p.idleConns = idleConns
+	p.idleConnsLen -= len(stale)
+	for _, cn := range stale {
+		p.removeConn(cn)
+	}
+	p.checkMinIdleConns()
+	p.connsMu.Unlock()
+
+	for _, cn := range stale {
+		_ = p.closeConn(cn)
+	}
+
+	if p.cfg.HealthCheck != nil && p.cfg.HealthCheckInterval > 0 {
+		p.checkIdleConns(now.Add(-p.cfg.HealthCheckInterval))
+	}
+}
+
+// checkIdleConns checks the idle connections last used before usedBefore
+// one at a time. Every check takes a turn like Get does and skips the
+// remaining connections when the pool is busy.
+func (p *ConnPool) checkIdleConns(usedBefore time.Time) {
+	ctx := context.Background()
+	// UsedAt has a resolution of seconds, so a checked connection can
+	// still look unused. Check every connection at most once.
+	for n := p.IdleLen(); n > 0 && !p.closed(); n-- {
+		if !p.queue.tryAcquire() {
+			return
+		}
+
+		p.connsMu.Lock()
+		cn := p.popIdleUsedBefore(usedBefore)
+		p.connsMu.Unlock()
+
+		if cn == nil {
+			p.freeTurn()
+			return
+		}
+
+		atomic.AddUint32(&p.stats.HealthChecks, 1)
+		if err := p.cfg.HealthCheck(ctx, cn); err != nil {
+			atomic.AddUint32(&p.stats.HealthCheckErrors, 1)
+			p.Remove(ctx, cn, err)
+			continue
+		}
+		cn.SetUsedAt(time.Now())
+		p.Put(ctx, cn)
+	}
+}
+
+func (p *ConnPool) popIdleUsedBefore(tm time.Time) *Conn {
+	for i, cn := range p.idleConns {
+		if cn.UsedAt().Before(tm) {
+			p.idleConns = append(p.idleConns[:i], p.idleConns[i+1:]...)
+			p.idleConnsLen--
+			return cn
+		}
+	}
+	return nil
 }
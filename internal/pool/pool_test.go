@@ -2,6 +2,7 @@ package pool_test
 
 import (
 	"context"
+	"errors"
 	"net"
 	"sync"
 	"testing"
@@ -56,13 +57,17 @@ var _ = Describe("ConnPool", func() {
 		// We wait for 1 second and believe that checkMinIdleConns has been executed.
 		time.Sleep(time.Second)
 
-		Expect(connPool.Stats()).To(Equal(&pool.Stats{
+		stats := connPool.Stats()
+		Expect(stats.DialDuration).To(BeNumerically(">", 0))
+		stats.DialDuration = 0
+		Expect(stats).To(Equal(&pool.Stats{
 			Hits:       0,
 			Misses:     0,
 			Timeouts:   0,
 			TotalConns: 0,
 			IdleConns:  0,
 			StaleConns: 0,
+			DialCount:  minIdleConns,
 		}))
 	})
 
@@ -117,6 +122,426 @@ var _ = Describe("ConnPool", func() {
 	})
 })
 
+var _ = Describe("Stats", func() {
+	ctx := context.Background()
+
+	It("reports waits, dials and closed connections", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        2,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Millisecond,
+		})
+		defer connPool.Close()
+
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats := connPool.Stats()
+		Expect(stats.DialCount).To(Equal(uint32(2)))
+		Expect(stats.DialErrors).To(Equal(uint32(0)))
+		Expect(stats.InUseConns).To(Equal(uint32(2)))
+		Expect(stats.WaitCount).To(Equal(uint32(0)))
+
+		done := make(chan *pool.Conn)
+		go func() {
+			defer GinkgoRecover()
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			done <- cn
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		connPool.Remove(ctx, cn1, errors.New("broken"))
+		cn3 := <-done
+
+		stats = connPool.Stats()
+		Expect(stats.WaitCount).To(Equal(uint32(1)))
+		Expect(stats.WaitDuration).To(BeNumerically(">=", 10*time.Millisecond))
+		Expect(stats.BadConnClosed).To(Equal(uint32(1)))
+		Expect(stats.DialCount).To(Equal(uint32(3)))
+
+		connPool.Put(ctx, cn2)
+		connPool.Put(ctx, cn3)
+		time.Sleep(2 * time.Millisecond)
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+
+		stats = connPool.Stats()
+		Expect(stats.IdleClosed).To(Equal(uint32(2)))
+		Expect(stats.InUseConns).To(Equal(uint32(0)))
+		Expect(stats.IdleConns).To(Equal(uint32(1)))
+	})
+
+	It("reports dial errors", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer: func(ctx context.Context) (net.Conn, error) {
+				return nil, errors.New("dial error")
+			},
+			PoolSize:    10,
+			PoolTimeout: time.Hour,
+		})
+		defer connPool.Close()
+
+		_, err := connPool.Get(ctx)
+		Expect(err).To(MatchError("dial error"))
+
+		stats := connPool.Stats()
+		Expect(stats.DialCount).To(Equal(uint32(1)))
+		Expect(stats.DialErrors).To(Equal(uint32(1)))
+		Expect(stats.TotalConns).To(Equal(uint32(0)))
+	})
+
+	It("reports connections closed by reason", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:      dummyDialer,
+			PoolSize:    10,
+			PoolTimeout: time.Hour,
+		})
+		defer connPool.Close()
+
+		for _, reason := range []error{
+			nil,
+			pool.ErrClosed,
+			&pool.InitError{Err: errors.New("handshake failed")},
+			errors.New("broken"),
+		} {
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			connPool.Remove(ctx, cn, reason)
+		}
+
+		stats := connPool.Stats()
+		Expect(stats.InitFailedClosed).To(Equal(uint32(1)))
+		Expect(stats.BadConnClosed).To(Equal(uint32(1)))
+	})
+})
+
+var _ = Describe("Maintenance", func() {
+	ctx := context.Background()
+
+	It("closes expired idle connections and refills MinIdleConns", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			PoolTimeout:        time.Hour,
+			MinIdleConns:       2,
+			ConnMaxLifetime:    50 * time.Millisecond,
+			IdleCheckFrequency: 10 * time.Millisecond,
+		})
+		defer connPool.Close()
+
+		Eventually(func() uint32 {
+			return connPool.Stats().LifetimeClosed
+		}).Should(BeNumerically(">=", 2))
+		Eventually(func() uint32 {
+			return connPool.Stats().DialCount
+		}).Should(BeNumerically(">=", 4))
+		Eventually(connPool.IdleLen).Should(Equal(2))
+		Expect(connPool.Stats().MaintenanceRuns).To(BeNumerically(">", 0))
+	})
+
+	It("checks idle connections", func() {
+		var mu sync.Mutex
+		var checked []*pool.Conn
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			PoolTimeout:        time.Hour,
+			IdleCheckFrequency: 10 * time.Millisecond,
+			HealthCheck: func(ctx context.Context, cn *pool.Conn) error {
+				mu.Lock()
+				defer mu.Unlock()
+				checked = append(checked, cn)
+				if len(checked) == 1 {
+					return errors.New("unhealthy")
+				}
+				return nil
+			},
+			HealthCheckInterval: time.Nanosecond,
+		})
+		defer connPool.Close()
+
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn1)
+		connPool.Put(ctx, cn2)
+
+		Eventually(func() uint32 {
+			return connPool.Stats().HealthChecks
+		}).Should(BeNumerically(">=", 3))
+
+		stats := connPool.Stats()
+		Expect(stats.HealthCheckErrors).To(Equal(uint32(1)))
+		Expect(stats.BadConnClosed).To(Equal(uint32(1)))
+		Expect(stats.TotalConns).To(Equal(uint32(1)))
+
+		mu.Lock()
+		Expect(checked[1:]).NotTo(ContainElement(checked[0]))
+		mu.Unlock()
+	})
+})
+
+var _ = Describe("Resize", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	BeforeEach(func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:      dummyDialer,
+			PoolSize:    2,
+			PoolTimeout: 100 * time.Millisecond,
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("unblocks waiting goroutines when the pool grows", func() {
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		done := make(chan *pool.Conn)
+		go func() {
+			defer GinkgoRecover()
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			done <- cn
+		}()
+
+		Consistently(done, 20*time.Millisecond).ShouldNot(Receive())
+		connPool.Resize(3, 0, 0)
+		cn3 := <-done
+
+		Expect(connPool.Size()).To(Equal(3))
+		for _, cn := range []*pool.Conn{cn1, cn2, cn3} {
+			connPool.Put(ctx, cn)
+		}
+		Expect(connPool.IdleLen()).To(Equal(3))
+	})
+
+	It("closes connections above the size when the pool shrinks", func() {
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn1)
+
+		connPool.Resize(1, 0, 0)
+		Expect(connPool.Len()).To(Equal(1))
+		Expect(connPool.IdleLen()).To(Equal(0))
+
+		// The turn of cn2 is still in use.
+		_, err = connPool.Get(ctx)
+		Expect(err).To(Equal(pool.ErrPoolTimeout))
+
+		connPool.Put(ctx, cn2)
+		Expect(connPool.Len()).To(Equal(1))
+		Expect(connPool.IdleLen()).To(Equal(1))
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cn).To(Equal(cn2))
+		connPool.Put(ctx, cn)
+	})
+
+	It("dials MinIdleConns", func() {
+		connPool.Resize(4, 2, 0)
+		Eventually(connPool.Len).Should(Equal(2))
+		Expect(connPool.IdleLen()).To(Equal(2))
+	})
+
+	It("limits MaxActiveConns", func() {
+		connPool.Resize(4, 0, 1)
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = connPool.Get(ctx)
+		Expect(err).To(Equal(pool.ErrPoolExhausted))
+		connPool.Put(ctx, cn)
+	})
+})
+
+var _ = Describe("Budget", func() {
+	ctx := context.Background()
+	var budget *pool.Budget
+	var p1, p2 *pool.ConnPool
+
+	BeforeEach(func() {
+		budget = pool.NewBudget(2, 100*time.Millisecond)
+		newPool := func() *pool.ConnPool {
+			return pool.NewConnPool(&pool.Options{
+				Dialer:      dummyDialer,
+				PoolSize:    2,
+				PoolTimeout: time.Second,
+				Budget:      budget,
+			})
+		}
+		p1, p2 = newPool(), newPool()
+	})
+
+	AfterEach(func() {
+		p1.Close()
+		p2.Close()
+	})
+
+	It("limits the connections of all pools together", func() {
+		cn1, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := p2.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = p1.Get(ctx)
+		Expect(err).To(Equal(pool.ErrBudgetTimeout))
+
+		stats := budget.Stats()
+		Expect(stats.Conns).To(Equal(uint32(2)))
+		Expect(stats.WaitCount).To(Equal(uint32(1)))
+		Expect(stats.WaitDuration).To(BeNumerically(">=", 100*time.Millisecond))
+		Expect(stats.Timeouts).To(Equal(uint32(1)))
+
+		p1.Remove(ctx, cn1, nil)
+		Expect(budget.Stats().Conns).To(Equal(uint32(1)))
+
+		cn, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		p1.Put(ctx, cn)
+		p2.Put(ctx, cn2)
+	})
+
+	It("closes idle connections of other pools to make room", func() {
+		cn1, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		p1.Put(ctx, cn1)
+		p1.Put(ctx, cn2)
+
+		cn, err := p2.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p1.Len()).To(Equal(1))
+		Expect(p1.IdleLen()).To(Equal(1))
+
+		stats := budget.Stats()
+		Expect(stats.Conns).To(Equal(uint32(2)))
+		Expect(stats.WaitCount).To(Equal(uint32(0)))
+		Expect(stats.Evictions).To(Equal(uint32(1)))
+
+		// The least recently used connection was closed.
+		got, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(cn2))
+		p1.Put(ctx, got)
+		p2.Put(ctx, cn)
+	})
+
+	It("closes connections put back while pools wait", func() {
+		cn1, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		done := make(chan *pool.Conn)
+		go func() {
+			defer GinkgoRecover()
+			cn, err := p2.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			done <- cn
+		}()
+
+		Consistently(done, 20*time.Millisecond).ShouldNot(Receive())
+		p1.Put(ctx, cn1)
+		cn := <-done
+		Expect(p1.Len()).To(Equal(1))
+		Expect(p1.IdleLen()).To(Equal(0))
+		Expect(budget.Stats().Evictions).To(Equal(uint32(1)))
+
+		p1.Put(ctx, cn2)
+		p2.Put(ctx, cn)
+	})
+
+	It("grants the budget in the order the pools started waiting", func() {
+		cn1, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := p2.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		order := make(chan *pool.ConnPool, 2)
+		for _, p := range []*pool.ConnPool{p2, p1} {
+			go func(p *pool.ConnPool) {
+				defer GinkgoRecover()
+				cn, err := p.NewConn(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				order <- p
+				Expect(p.CloseConn(cn)).To(Succeed())
+			}(p)
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		// The budget of p1 goes to p2, which waits longer.
+		p1.Remove(ctx, cn1, nil)
+		Expect(<-order).To(Equal(p2))
+		Expect(<-order).To(Equal(p1))
+		p2.Put(ctx, cn2)
+	})
+
+	It("doesn't evict connections to keep MinIdleConns", func() {
+		budget := pool.NewBudget(3, 100*time.Millisecond)
+		newPool := func() *pool.ConnPool {
+			return pool.NewConnPool(&pool.Options{
+				Dialer:       dummyDialer,
+				PoolSize:     4,
+				MinIdleConns: 2,
+				PoolTimeout:  time.Second,
+				Budget:       budget,
+			})
+		}
+		p1 := newPool()
+		defer p1.Close()
+		Eventually(p1.IdleLen).Should(Equal(2))
+		p2 := newPool()
+		defer p2.Close()
+
+		dials := func() uint32 {
+			return p1.Stats().DialCount + p2.Stats().DialCount
+		}
+		Eventually(dials).Should(Equal(uint32(3)))
+		Consistently(dials, 50*time.Millisecond).Should(Equal(uint32(3)))
+		Expect(p1.IdleLen()).To(Equal(2))
+		Expect(p2.IdleLen()).To(Equal(1))
+
+		// The idle connection taken from p2 isn't replaced at the expense of p1.
+		cn, err := p2.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(dials, 50*time.Millisecond).Should(Equal(uint32(3)))
+		Expect(p1.IdleLen()).To(Equal(2))
+
+		stats := budget.Stats()
+		Expect(stats.Conns).To(Equal(uint32(3)))
+		Expect(stats.Evictions).To(Equal(uint32(0)))
+		p2.Put(ctx, cn)
+	})
+
+	It("returns the budget of closed pools", func() {
+		cn, err := p1.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		p1.Put(ctx, cn)
+		Expect(budget.Stats().Conns).To(Equal(uint32(1)))
+
+		Expect(p1.Close()).To(Succeed())
+		Expect(budget.Stats().Conns).To(Equal(uint32(0)))
+	})
+})
+
 var _ = Describe("MinIdleConns", func() {
 	const poolSize = 100
 	ctx := context.Background()
@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// semaphore limits the number of connections in use. Unlike a buffered
+// channel, its size can change at runtime. Waiters get turns in the order
+// they started waiting.
+type semaphore struct {
+	mu      sync.Mutex
+	size    int
+	used    int
+	waiters list.List // of chan struct{}
+}
+
+func newSemaphore(size int) *semaphore {
+	return &semaphore{size: size}
+}
+
+// tryAcquire takes a turn if one is free.
+func (s *semaphore) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used < s.size && s.waiters.Len() == 0 {
+		s.used++
+		return true
+	}
+	return false
+}
+
+// acquire waits for a turn until ctx is done or the timeout expires.
+// If not nil, queued is called once the caller waits in line.
+func (s *semaphore) acquire(ctx context.Context, timeout time.Duration, queued func()) error {
+	s.mu.Lock()
+	if s.used < s.size && s.waiters.Len() == 0 {
+		s.used++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(ready)
+	s.mu.Unlock()
+
+	if queued != nil {
+		queued()
+	}
+
+	timer := timers.Get().(*time.Timer)
+	timer.Reset(timeout)
+
+	var err error
+	select {
+	case <-ready:
+		if !timer.Stop() {
+			<-timer.C
+		}
+		timers.Put(timer)
+		return nil
+	case <-ctx.Done():
+		if !timer.Stop() {
+			<-timer.C
+		}
+		timers.Put(timer)
+		err = ctx.Err()
+	case <-timer.C:
+		timers.Put(timer)
+		err = ErrPoolTimeout
+	}
+
+	s.mu.Lock()
+	select {
+	case <-ready:
+		// The turn was granted while giving up, so pass it on.
+		s.used--
+		s.grant()
+	default:
+		s.waiters.Remove(elem)
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// waiting reports whether callers wait for a turn.
+func (s *semaphore) waiting() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len() > 0
+}
+
+// release returns a turn.
+func (s *semaphore) release() {
+	s.mu.Lock()
+	s.used--
+	s.grant()
+	s.mu.Unlock()
+}
+
+// resize changes the number of turns. When it shrinks, the turns in use
+// above the new size aren't granted again once they are released.
+func (s *semaphore) resize(size int) {
+	s.mu.Lock()
+	s.size = size
+	s.grant()
+	s.mu.Unlock()
+}
+
+func (s *semaphore) grant() {
+	for s.used < s.size && s.waiters.Len() > 0 {
+		ready := s.waiters.Remove(s.waiters.Front()).(chan struct{})
+		s.used++
+		close(ready)
+	}
+}
@@ -2,6 +2,7 @@ package proto
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -29,12 +30,10 @@ const (
 	RespSet       = '~' // ~<len>\r\n... (same as Array)
 	RespAttr      = '|' // |<len>\r\n(key)\r\n(value)\r\n... + command reply
 	RespPush      = '>' // ><len>\r\n... (same as Array)
-)
 
-// Not used temporarily.
-// Redis has not used these two data types for the time being, and will implement them later.
-// Streamed           = "EOF:"
-// StreamedAggregated = '?'
+	RespStreamedChunk = ';' // ;<len>\r\n<bytes>\r\n (chunk of $?\r\n, ;0\r\n ends the string)
+	RespStreamedEnd   = '.' // .\r\n (ends the aggregate of *?\r\n, %?\r\n, ~?\r\n or >?\r\n)
+)
 
 //------------------------------------------------------------------------------
 
@@ -50,10 +49,50 @@ func ParseErrorReply(line []byte) error {
 	return RedisError(line[1:])
 }
 
+// WriterError wraps the error returned by the destination of ReadStringTo.
+// The reply has been consumed completely, so the connection can be reused.
+type WriterError struct {
+	Err error
+}
+
+func (e *WriterError) Error() string { return e.Err.Error() }
+
+func (e *WriterError) Unwrap() error { return e.Err }
+
+// ProtocolError is returned when a reply exceeds the Limits of the Reader.
+type ProtocolError struct {
+	msg string
+}
+
+func (e *ProtocolError) Error() string { return e.msg }
+
+func protocolError(format string, args ...interface{}) error {
+	return &ProtocolError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Limits bounds the replies accepted by the Reader.
+// Zero values disable the corresponding limit.
+type Limits struct {
+	// MaxBulkLen is the maximum length of buffered bulk strings
+	// and of the lines of simple strings, errors and numbers.
+	MaxBulkLen int
+	// MaxAggregateLen is the maximum number of elements of arrays, sets and maps.
+	MaxAggregateLen int
+	// MaxDepth is the maximum nesting depth of aggregates.
+	MaxDepth int
+}
+
 //------------------------------------------------------------------------------
 
 type Reader struct {
 	rd *bufio.Reader
+
+	// buf holds the elements of streamed aggregates
+	// that are read before the elements in rd.
+	buf []byte
+
+	limits Limits
+	depth  int
 }
 
 func NewReader(rd io.Reader) *Reader {
@@ -62,22 +101,100 @@ func NewReader(rd io.Reader) *Reader {
 	}
 }
 
+// NewReaderSize returns a new Reader whose buffer has at least the specified size.
+func NewReaderSize(rd io.Reader, size int) *Reader {
+	return &Reader{
+		rd: bufio.NewReaderSize(rd, size),
+	}
+}
+
+// SetLimits sets the limits of the replies read afterwards.
+func (r *Reader) SetLimits(limits Limits) {
+	r.limits = limits
+}
+
+func (r *Reader) checkLen(typ byte, n int) error {
+	switch typ {
+	case RespString, RespVerbatim, RespBlobError, RespStreamedChunk:
+		if r.limits.MaxBulkLen > 0 && n > r.limits.MaxBulkLen {
+			return protocolError("redis: bulk string of %d bytes exceeds the limit of %d", n, r.limits.MaxBulkLen)
+		}
+	default:
+		if r.limits.MaxAggregateLen > 0 && n > r.limits.MaxAggregateLen {
+			return protocolError("redis: aggregate of %d elements exceeds the limit of %d", n, r.limits.MaxAggregateLen)
+		}
+	}
+	return nil
+}
+
+// enter increases the nesting depth of aggregates. It must be followed by leave.
+func (r *Reader) enter() error {
+	r.depth++
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return protocolError("redis: nesting depth of aggregates exceeds the limit of %d", r.limits.MaxDepth)
+	}
+	return nil
+}
+
+func (r *Reader) leave() {
+	r.depth--
+}
+
+func isAggregate(typ byte) bool {
+	switch typ {
+	case RespArray, RespSet, RespPush, RespMap, RespAttr:
+		return true
+	}
+	return false
+}
+
 func (r *Reader) Buffered() int {
-	return r.rd.Buffered()
+	return len(r.buf) + r.rd.Buffered()
 }
 
 func (r *Reader) Peek(n int) ([]byte, error) {
-	return r.rd.Peek(n)
+	if len(r.buf) == 0 {
+		return r.rd.Peek(n)
+	}
+	if n <= len(r.buf) {
+		return r.buf[:n], nil
+	}
+	b, err := r.rd.Peek(n - len(r.buf))
+	return append(r.buf[:len(r.buf):len(r.buf)], b...), err
 }
 
 func (r *Reader) Reset(rd io.Reader) {
+	r.buf = nil
 	r.rd.Reset(rd)
 }
 
+// Read implements io.Reader for the buffered elements and the underlying reader.
+func (r *Reader) Read(b []byte) (int, error) {
+	if len(r.buf) == 0 {
+		return r.rd.Read(b)
+	}
+	n := copy(b, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *Reader) discard(n int) error {
+	if len(r.buf) == 0 {
+		_, err := r.rd.Discard(n)
+		return err
+	}
+	if n > len(r.buf) {
+		// Replies never span the buffered elements and the underlying reader.
+		return io.ErrUnexpectedEOF
+	}
+	r.buf = r.buf[n:]
+	return nil
+}
+
 // PeekReplyType returns the data type of the next response without advancing the Reader,
 // and discard the attribute type.
 func (r *Reader) PeekReplyType() (byte, error) {
-	b, err := r.rd.Peek(1)
+	b, err := r.Peek(1)
 	if err != nil {
 		return 0, err
 	}
@@ -128,26 +245,58 @@ func (r *Reader) ReadLine() ([]byte, error) {
 //   - there is a pending read error;
 //   - or line does not end with \r\n.
 func (r *Reader) readLine() ([]byte, error) {
-	b, err := r.rd.ReadSlice('\n')
-	if err != nil {
-		if err != bufio.ErrBufferFull {
-			return nil, err
-		}
+	if len(r.buf) > 0 {
+		return r.readBufferedLine()
+	}
 
+	b, err := r.rd.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Read the line in chunks of the buffer size, so that the limit
+		// is checked before the line is read completely.
 		full := make([]byte, len(b))
 		copy(full, b)
 
-		b, err = r.rd.ReadBytes('\n')
-		if err != nil {
-			return nil, err
+		for err == bufio.ErrBufferFull {
+			if err := r.checkLineLen(len(full)); err != nil {
+				return nil, err
+			}
+			b, err = r.rd.ReadSlice('\n')
+			full = append(full, b...) //nolint:makezero
 		}
-
-		full = append(full, b...) //nolint:makezero
 		b = full
 	}
+	if err != nil {
+		return nil, err
+	}
 	if len(b) <= 2 || b[len(b)-1] != '\n' || b[len(b)-2] != '\r' {
 		return nil, fmt.Errorf("redis: invalid reply: %q", b)
 	}
+	if err := r.checkLineLen(len(b) - 2); err != nil {
+		return nil, err
+	}
+	return b[:len(b)-2], nil
+}
+
+// checkLineLen checks the length n of a line, e.g. of a simple string,
+// against MaxBulkLen. The type of the line doesn't count.
+func (r *Reader) checkLineLen(n int) error {
+	if r.limits.MaxBulkLen > 0 && n-1 > r.limits.MaxBulkLen {
+		return protocolError("redis: line exceeds the limit of %d bytes", r.limits.MaxBulkLen)
+	}
+	return nil
+}
+
+func (r *Reader) readBufferedLine() ([]byte, error) {
+	i := bytes.IndexByte(r.buf, '\n')
+	if i == -1 {
+		return nil, fmt.Errorf("redis: invalid reply: %q", r.buf)
+	}
+	b := r.buf[:i+1]
+	r.buf = r.buf[i+1:]
+
+	if len(b) <= 2 || b[len(b)-2] != '\r' {
+		return nil, fmt.Errorf("redis: invalid reply: %q", b)
+	}
 	return b[:len(b)-2], nil
 }
 
@@ -214,13 +363,31 @@ func (r *Reader) readBigInt(line []byte) (*big.Int, error) {
 }
 
 func (r *Reader) readStringReply(line []byte) (string, error) {
+	if isStreamed(line) {
+		var b []byte
+		err := r.readStringChunks(func(chunk []byte) error {
+			if err := r.checkLen(RespString, len(b)+len(chunk)); err != nil {
+				return err
+			}
+			b = append(b, chunk...)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return util.BytesToString(b), nil
+	}
+
 	n, err := replyLen(line)
 	if err != nil {
 		return "", err
 	}
+	if err = r.checkLen(line[0], n); err != nil {
+		return "", err
+	}
 
 	b := make([]byte, n+2)
-	_, err = io.ReadFull(r.rd, b)
+	_, err = io.ReadFull(r, b)
 	if err != nil {
 		return "", err
 	}
@@ -228,6 +395,41 @@ func (r *Reader) readStringReply(line []byte) (string, error) {
 	return util.BytesToString(b[:n]), nil
 }
 
+// readStringChunks passes the chunks of a streamed string to fn.
+func (r *Reader) readStringChunks(fn func(chunk []byte) error) error {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return err
+		}
+		if line[0] != RespStreamedChunk {
+			return fmt.Errorf("redis: can't parse streamed string chunk: %.100q", line)
+		}
+
+		n, err := util.Atoi(line[1:])
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("redis: invalid reply: %q", line)
+		}
+		if n == 0 {
+			return nil
+		}
+		if err = r.checkLen(RespStreamedChunk, n); err != nil {
+			return err
+		}
+
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return err
+		}
+		if err = fn(b[:n]); err != nil {
+			return err
+		}
+	}
+}
+
 func (r *Reader) readVerb(line []byte) (string, error) {
 	s, err := r.readStringReply(line)
 	if err != nil {
@@ -240,7 +442,12 @@ func (r *Reader) readVerb(line []byte) (string, error) {
 }
 
 func (r *Reader) readSlice(line []byte) ([]interface{}, error) {
-	n, err := replyLen(line)
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	n, err := r.aggregateLen(line)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +472,12 @@ func (r *Reader) readSlice(line []byte) ([]interface{}, error) {
 }
 
 func (r *Reader) readMap(line []byte) (map[interface{}]interface{}, error) {
-	n, err := replyLen(line)
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	n, err := r.aggregateLen(line)
 	if err != nil {
 		return nil, err
 	}
@@ -394,6 +606,93 @@ func (r *Reader) ReadString() (string, error) {
 	return "", fmt.Errorf("redis: can't parse reply=%.100q reading string", line)
 }
 
+// ReadStringTo copies the next string reply to w without buffering the whole
+// reply and returns the number of bytes written. When w fails, the rest of
+// the reply is discarded and the error is returned as *WriterError.
+func (r *Reader) ReadStringTo(w io.Writer) (int64, error) {
+	line, err := r.ReadLine()
+	if err != nil {
+		return 0, err
+	}
+
+	switch line[0] {
+	case RespStatus, RespInt, RespFloat:
+		n, err := w.Write(line[1:])
+		if err != nil {
+			return int64(n), &WriterError{Err: err}
+		}
+		return int64(n), nil
+	case RespString:
+		if isStreamed(line) {
+			return r.readStringChunksTo(w)
+		}
+
+		n, err := replyLen(line)
+		if err != nil {
+			return 0, err
+		}
+
+		ew := &errWriter{w: w}
+		lr := &io.LimitedReader{R: r, N: int64(n)}
+		written, err := io.Copy(ew, lr)
+		if err != nil && ew.err == nil {
+			return written, err
+		}
+		if written < int64(n) && ew.err == nil {
+			return written, io.ErrUnexpectedEOF
+		}
+		if err = r.discard(int(lr.N)); err != nil {
+			return written, err
+		}
+
+		// Discard \r\n.
+		if err = r.discard(2); err != nil {
+			return written, err
+		}
+		if ew.err != nil {
+			return written, &WriterError{Err: ew.err}
+		}
+		return written, nil
+	}
+	return 0, fmt.Errorf("redis: can't parse reply=%.100q reading string", line)
+}
+
+func (r *Reader) readStringChunksTo(w io.Writer) (int64, error) {
+	var written int64
+	var writeErr error
+	err := r.readStringChunks(func(chunk []byte) error {
+		if writeErr != nil {
+			// Discard the remaining chunks.
+			return nil
+		}
+		n, err := w.Write(chunk)
+		written += int64(n)
+		writeErr = err
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	if writeErr != nil {
+		return written, &WriterError{Err: writeErr}
+	}
+	return written, nil
+}
+
+// errWriter remembers the error of w to tell it apart from read errors.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
 func (r *Reader) ReadBool() (bool, error) {
 	s, err := r.ReadString()
 	if err != nil {
@@ -430,12 +729,26 @@ func (r *Reader) ReadArrayLen() (int, error) {
 	}
 	switch line[0] {
 	case RespArray, RespSet, RespPush:
-		return replyLen(line)
+		return r.aggregateLen(line)
 	default:
 		return 0, fmt.Errorf("redis: can't parse array/set/push reply: %.100q", line)
 	}
 }
 
+// ReadStrictArrayLen reads the length of an array, which may be streamed,
+// e.g. of the reply of EXEC. Unlike ReadArrayLen, it doesn't accept sets
+// and pushes.
+func (r *Reader) ReadStrictArrayLen() (int, error) {
+	line, err := r.ReadLine()
+	if err != nil {
+		return 0, err
+	}
+	if line[0] != RespArray {
+		return 0, fmt.Errorf("redis: expected '*', but got line %q", line)
+	}
+	return r.aggregateLen(line)
+}
+
 // ReadFixedMapLen reads fixed map length.
 func (r *Reader) ReadFixedMapLen(fixedLen int) error {
 	n, err := r.ReadMapLen()
@@ -459,10 +772,10 @@ func (r *Reader) ReadMapLen() (int, error) {
 	}
 	switch line[0] {
 	case RespMap:
-		return replyLen(line)
+		return r.aggregateLen(line)
 	case RespArray, RespSet, RespPush:
 		// Some commands and RESP2 protocol may respond to array types.
-		n, err := replyLen(line)
+		n, err := r.aggregateLen(line)
 		if err != nil {
 			return 0, err
 		}
@@ -475,6 +788,18 @@ func (r *Reader) ReadMapLen() (int, error) {
 	}
 }
 
+// ReadAttributes reads the attribute type that precedes a reply.
+func (r *Reader) ReadAttributes() ([]ValuePair, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line[0] != RespAttr {
+		return nil, fmt.Errorf("redis: can't parse attribute reply: %.100q", line)
+	}
+	return r.readValuePairs(line)
+}
+
 // DiscardNext read and discard the data represented by the next line.
 func (r *Reader) DiscardNext() error {
 	line, err := r.readLine()
@@ -494,16 +819,33 @@ func (r *Reader) Discard(line []byte) (err error) {
 		return nil
 	}
 
-	n, err := replyLen(line)
-	if err != nil && err != Nil {
+	if line[0] == RespString && isStreamed(line) {
+		var size int
+		return r.readStringChunks(func(chunk []byte) error {
+			size += len(chunk)
+			return r.checkLen(RespString, size)
+		})
+	}
+
+	if isAggregate(line[0]) {
+		if err = r.enter(); err != nil {
+			return err
+		}
+		defer r.leave()
+	}
+
+	n, err := r.aggregateLen(line)
+	if err == Nil {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
 	switch line[0] {
 	case RespBlobError, RespString, RespVerbatim:
 		// +\r\n
-		_, err = r.rd.Discard(n + 2)
-		return err
+		return r.discard(n + 2)
 	case RespArray, RespSet, RespPush:
 		for i := 0; i < n; i++ {
 			if err = r.DiscardNext(); err != nil {
@@ -524,6 +866,145 @@ func (r *Reader) Discard(line []byte) (err error) {
 	return fmt.Errorf("redis: can't parse %.100q", line)
 }
 
+// ReadRawReply reads the next reply, including all nested elements and
+// attributes, and returns it in its RESP encoding.
+func (r *Reader) ReadRawReply() ([]byte, error) {
+	return r.appendRawReply(nil)
+}
+
+func (r *Reader) appendRawReply(b []byte) ([]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	switch line[0] {
+	case RespStatus, RespError, RespInt, RespNil, RespFloat, RespBool, RespBigInt:
+		b = append(b, line...)
+		return append(b, '\r', '\n'), nil
+	}
+
+	if line[0] == RespString && isStreamed(line) {
+		s, err := r.readStringReply(line)
+		if err != nil {
+			return nil, err
+		}
+		b = appendLen(b, RespString, len(s))
+		b = append(b, s...)
+		return append(b, '\r', '\n'), nil
+	}
+
+	if isAggregate(line[0]) {
+		if err = r.enter(); err != nil {
+			return nil, err
+		}
+		defer r.leave()
+	}
+
+	n, err := r.aggregateLen(line)
+	if err == Nil {
+		b = append(b, line...)
+		return append(b, '\r', '\n'), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Streamed aggregates are stored with a fixed length.
+	b = appendLen(b, line[0], n)
+
+	switch line[0] {
+	case RespBlobError, RespString, RespVerbatim:
+		start := len(b)
+		b = append(b, make([]byte, n+2)...)
+		if _, err := io.ReadFull(r, b[start:]); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case RespArray, RespSet, RespPush:
+	case RespMap:
+		n *= 2
+	case RespAttr:
+		// Attributes are followed by the reply they describe.
+		n = n*2 + 1
+	default:
+		return nil, fmt.Errorf("redis: can't parse %.100q", line)
+	}
+
+	for i := 0; i < n; i++ {
+		if b, err = r.appendRawReply(b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendLen(b []byte, typ byte, n int) []byte {
+	b = append(b, typ)
+	b = strconv.AppendInt(b, int64(n), 10)
+	return append(b, '\r', '\n')
+}
+
+// aggregateLen returns the length of the reply like replyLen. Since the length
+// of a streamed aggregate is not known in advance, its elements are read and
+// buffered to be read again like the elements of an aggregate with a fixed length.
+// The length of maps is the number of key-value pairs.
+func (r *Reader) aggregateLen(line []byte) (int, error) {
+	if !isStreamed(line) {
+		n, err := replyLen(line)
+		if err != nil {
+			return 0, err
+		}
+		return n, r.checkLen(line[0], n)
+	}
+
+	switch line[0] {
+	case RespArray, RespSet, RespPush, RespMap:
+	default:
+		return 0, fmt.Errorf("redis: invalid reply: %q", line)
+	}
+
+	var b []byte
+	var n int
+	for {
+		p, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if p[0] == RespStreamedEnd {
+			if _, err = r.readLine(); err != nil {
+				return 0, err
+			}
+			break
+		}
+
+		if b, err = r.appendRawReply(b); err != nil {
+			return 0, err
+		}
+		n++
+
+		limitN := n
+		if line[0] == RespMap {
+			limitN = (n + 1) / 2
+		}
+		if err = r.checkLen(line[0], limitN); err != nil {
+			return 0, err
+		}
+	}
+	r.buf = append(b, r.buf...)
+
+	if line[0] == RespMap {
+		if n%2 != 0 {
+			return 0, fmt.Errorf("redis: got %d elements in the streamed map, wanted a multiple of 2", n)
+		}
+		n /= 2
+	}
+	return n, nil
+}
+
+func isStreamed(line []byte) bool {
+	return len(line) == 2 && line[1] == '?'
+}
+
 func replyLen(line []byte) (n int, err error) {
 	n, err = util.Atoi(line[1:])
 	if err != nil {
@@ -2,7 +2,10 @@ package proto_test
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/redis/go-redis/v9/internal/proto"
@@ -98,3 +101,288 @@ func benchmarkParseReply(b *testing.B, reply string, wanterr bool) {
 		}
 	}
 }
+
+func TestReader_ReadRawReply(t *testing.T) {
+	replies := []string{
+		"+OK\r\n",
+		":42\r\n",
+		"_\r\n",
+		"$-1\r\n",
+		"*-1\r\n",
+		"$5\r\nhello\r\n",
+		"*2\r\n$5\r\nhello\r\n:1\r\n",
+		"%1\r\n+key\r\n*1\r\n$5\r\nvalue\r\n",
+		"|1\r\n+ttl\r\n:3600\r\n+OK\r\n",
+	}
+
+	var stream []byte
+	for _, reply := range replies {
+		stream = append(stream, reply...)
+	}
+
+	r := proto.NewReader(bytes.NewReader(stream))
+	for _, reply := range replies {
+		raw, err := r.ReadRawReply()
+		if err != nil {
+			t.Fatalf("ReadRawReply(%q) failed: %v", reply, err)
+		}
+		if string(raw) != reply {
+			t.Errorf("got %q, wanted %q", raw, reply)
+		}
+	}
+}
+
+func TestReader_ReadAttributes(t *testing.T) {
+	r := proto.NewReader(strings.NewReader("|1\r\n+ttl\r\n:3600\r\n+OK\r\n"))
+
+	attrs, err := r.ReadAttributes()
+	if err != nil {
+		t.Fatalf("ReadAttributes failed: %v", err)
+	}
+	if ttl := proto.PairsInterface(attrs)["ttl"]; ttl != int64(3600) {
+		t.Errorf("got %v, wanted 3600", ttl)
+	}
+
+	if _, err = r.ReadAttributes(); err == nil {
+		t.Errorf("ReadAttributes of a status reply succeeded")
+	}
+}
+
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if len(b) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("short write")
+	}
+	w.n -= len(b)
+	return len(b), nil
+}
+
+func TestReader_ReadStringTo(t *testing.T) {
+	r := proto.NewReader(strings.NewReader("$5\r\nhello\r\n+OK\r\n$5\r\nworld\r\n$-1\r\n+OK\r\n"))
+
+	var buf bytes.Buffer
+	if n, err := r.ReadStringTo(&buf); err != nil || n != 5 || buf.String() != "hello" {
+		t.Fatalf("got %d %q %v, wanted 5 \"hello\"", n, buf.String(), err)
+	}
+
+	buf.Reset()
+	if n, err := r.ReadStringTo(&buf); err != nil || n != 2 || buf.String() != "OK" {
+		t.Fatalf("got %d %q %v, wanted 2 \"OK\"", n, buf.String(), err)
+	}
+
+	n, err := r.ReadStringTo(&failingWriter{n: 2})
+	var wErr *proto.WriterError
+	if !errors.As(err, &wErr) || n != 2 {
+		t.Fatalf("got %d %v, wanted 2 and *WriterError", n, err)
+	}
+
+	if _, err = r.ReadStringTo(&buf); err != proto.Nil {
+		t.Fatalf("got %v, wanted proto.Nil", err)
+	}
+
+	// The reply stream stays in sync.
+	if line, err := r.ReadLine(); err != nil || string(line) != "+OK" {
+		t.Fatalf("got %q %v, wanted \"+OK\"", line, err)
+	}
+}
+
+func TestReader_Streamed(t *testing.T) {
+	tests := []struct {
+		reply  string
+		parsed interface{}
+		raw    string
+	}{
+		{
+			reply:  "$?\r\n;4\r\nHell\r\n;1\r\no\r\n;0\r\n",
+			parsed: "Hello",
+			raw:    "$5\r\nHello\r\n",
+		},
+		{
+			reply:  "*?\r\n:1\r\n*?\r\n$?\r\n;1\r\na\r\n;0\r\n.\r\n$1\r\nb\r\n.\r\n",
+			parsed: []interface{}{int64(1), []interface{}{"a"}, "b"},
+			raw:    "*3\r\n:1\r\n*1\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			reply:  "%?\r\n+key\r\n~?\r\n:1\r\n.\r\n.\r\n",
+			parsed: map[interface{}]interface{}{"key": []interface{}{int64(1)}},
+			raw:    "%1\r\n+key\r\n~1\r\n:1\r\n",
+		},
+		{
+			reply:  "*?\r\n.\r\n",
+			parsed: []interface{}{},
+			raw:    "*0\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		r := proto.NewReader(strings.NewReader(test.reply + test.reply + test.reply + "+OK\r\n"))
+
+		parsed, err := r.ReadReply()
+		if err != nil {
+			t.Fatalf("ReadReply(%q) failed: %v", test.reply, err)
+		}
+		if !reflect.DeepEqual(parsed, test.parsed) {
+			t.Errorf("ReadReply(%q) = %#v, wanted %#v", test.reply, parsed, test.parsed)
+		}
+
+		raw, err := r.ReadRawReply()
+		if err != nil {
+			t.Fatalf("ReadRawReply(%q) failed: %v", test.reply, err)
+		}
+		if string(raw) != test.raw {
+			t.Errorf("ReadRawReply(%q) = %q, wanted %q", test.reply, raw, test.raw)
+		}
+
+		if err = r.DiscardNext(); err != nil {
+			t.Fatalf("DiscardNext(%q) failed: %v", test.reply, err)
+		}
+
+		// The reply stream stays in sync.
+		if line, err := r.ReadLine(); err != nil || string(line) != "+OK" {
+			t.Fatalf("got %q %v, wanted \"+OK\"", line, err)
+		}
+	}
+}
+
+func TestReader_ReadStreamedLen(t *testing.T) {
+	r := proto.NewReader(strings.NewReader(
+		"*?\r\n:1\r\n:2\r\n.\r\n" +
+			"%?\r\n+a\r\n:1\r\n+b\r\n:2\r\n.\r\n" +
+			"*?\r\n+a\r\n:1\r\n.\r\n" +
+			"$?\r\n;5\r\nhello\r\n;6\r\n world\r\n;0\r\n",
+	))
+
+	n, err := r.ReadArrayLen()
+	if err != nil || n != 2 {
+		t.Fatalf("ReadArrayLen() = %d %v, wanted 2", n, err)
+	}
+	for i := int64(1); i <= 2; i++ {
+		if v, err := r.ReadInt(); err != nil || v != i {
+			t.Fatalf("ReadInt() = %d %v, wanted %d", v, err, i)
+		}
+	}
+
+	for _, want := range []int{2, 1} {
+		n, err = r.ReadMapLen()
+		if err != nil || n != want {
+			t.Fatalf("ReadMapLen() = %d %v, wanted %d", n, err, want)
+		}
+		for i := 0; i < n; i++ {
+			if _, err = r.ReadString(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = r.ReadInt(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if n, err := r.ReadStringTo(&buf); err != nil || n != 11 || buf.String() != "hello world" {
+		t.Fatalf("ReadStringTo() = %d %q %v, wanted \"hello world\"", n, buf.String(), err)
+	}
+	if r.Buffered() != 0 {
+		t.Fatalf("got %d buffered bytes, wanted 0", r.Buffered())
+	}
+}
+
+func TestReader_LongLine(t *testing.T) {
+	line := "+" + strings.Repeat("a", 100) + "\r\n"
+
+	r := proto.NewReaderSize(strings.NewReader(line+line), 16)
+	if s, err := r.ReadString(); err != nil || len(s) != 100 {
+		t.Fatalf("ReadString() = %q %v, wanted 100 bytes", s, err)
+	}
+
+	r.SetLimits(proto.Limits{MaxBulkLen: 50})
+	var protoErr *proto.ProtocolError
+	if _, err := r.ReadString(); !errors.As(err, &protoErr) {
+		t.Fatalf("ReadString() = %v, wanted *ProtocolError", err)
+	}
+}
+
+func TestReader_ReadStrictArrayLen(t *testing.T) {
+	tests := []struct {
+		reply string
+		n     int
+		ok    bool
+	}{
+		{reply: "*2\r\n:1\r\n:2\r\n", n: 2, ok: true},
+		{reply: "*?\r\n:1\r\n:2\r\n.\r\n", n: 2, ok: true},
+		{reply: "~2\r\n:1\r\n:2\r\n"},
+		{reply: ">2\r\n:1\r\n:2\r\n"},
+		{reply: "%1\r\n:1\r\n:2\r\n"},
+		{reply: "+OK\r\n"},
+	}
+
+	for _, test := range tests {
+		r := proto.NewReader(strings.NewReader(test.reply))
+		n, err := r.ReadStrictArrayLen()
+		if test.ok && (err != nil || n != test.n) {
+			t.Errorf("ReadStrictArrayLen(%q) = %d %v, wanted %d", test.reply, n, err, test.n)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("ReadStrictArrayLen(%q) succeeded, wanted an error", test.reply)
+		}
+	}
+}
+
+func TestReader_Limits(t *testing.T) {
+	limits := proto.Limits{MaxBulkLen: 5, MaxAggregateLen: 2, MaxDepth: 2}
+
+	tests := []struct {
+		reply string
+		ok    bool
+	}{
+		{reply: "$5\r\nhello\r\n", ok: true},
+		{reply: "$6\r\nhello!\r\n"},
+		{reply: "$?\r\n;3\r\nhel\r\n;3\r\nlo!\r\n;0\r\n"},
+		{reply: "*2\r\n:1\r\n:2\r\n", ok: true},
+		{reply: "*3\r\n:1\r\n:2\r\n:3\r\n"},
+		{reply: "*?\r\n:1\r\n:2\r\n:3\r\n.\r\n"},
+		{reply: "%2\r\n:1\r\n:1\r\n:2\r\n:2\r\n", ok: true},
+		{reply: "*1\r\n*1\r\n:1\r\n", ok: true},
+		{reply: "*1\r\n*1\r\n*1\r\n:1\r\n"},
+		{reply: "+hello\r\n", ok: true},
+		{reply: "+hello!\r\n"},
+		{reply: "-ERR hello\r\n"},
+		{reply: ":123456\r\n"},
+	}
+
+	for _, test := range tests {
+		for name, read := range map[string]func(r *proto.Reader) error{
+			"ReadReply": func(r *proto.Reader) error {
+				_, err := r.ReadReply()
+				return err
+			},
+			"ReadRawReply": func(r *proto.Reader) error {
+				_, err := r.ReadRawReply()
+				return err
+			},
+			"DiscardNext": func(r *proto.Reader) error {
+				return r.DiscardNext()
+			},
+		} {
+			r := proto.NewReader(strings.NewReader(test.reply))
+			r.SetLimits(limits)
+
+			err := read(r)
+			if test.ok {
+				if err != nil {
+					t.Errorf("%s(%q) failed: %v", name, test.reply, err)
+				}
+				continue
+			}
+
+			var protoErr *proto.ProtocolError
+			if !errors.As(err, &protoErr) {
+				t.Errorf("%s(%q) = %v, wanted *ProtocolError", name, test.reply, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package proto
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/redis/go-redis/v9/internal/util"
+)
+
+// Value is a reply that keeps the RESP type it was sent with,
+// unlike ReadReply that flattens RESP3 types to Go types.
+type Value struct {
+	// Type is the RESP type of the value, e.g. RespMap.
+	// Null bulk strings and arrays of RESP2 are RespNil as well.
+	Type byte
+
+	Str    string   // RespStatus, RespError, RespBlobError, RespString and RespVerbatim
+	Format string   // RespVerbatim, e.g. "txt" or "mkd"
+	Int    int64    // RespInt
+	Float  float64  // RespFloat
+	Bool   bool     // RespBool
+	BigInt *big.Int // RespBigInt
+
+	Elems []Value     // RespArray, RespSet and RespPush
+	Pairs []ValuePair // RespMap in wire order
+
+	// Attrs holds the attributes that preceded the value.
+	Attrs []ValuePair
+}
+
+// ValuePair is a key-value pair of a map or an attribute.
+type ValuePair struct {
+	Key   Value
+	Value Value
+}
+
+// IsNil reports whether the value is a null reply.
+func (v Value) IsNil() bool {
+	return v.Type == RespNil
+}
+
+// Err returns the RedisError of error values and nil otherwise.
+func (v Value) Err() error {
+	switch v.Type {
+	case RespError, RespBlobError:
+		return RedisError(v.Str)
+	}
+	return nil
+}
+
+// Interface converts the value like ReadReply does.
+func (v Value) Interface() interface{} {
+	switch v.Type {
+	case RespStatus, RespString, RespVerbatim:
+		return v.Str
+	case RespError, RespBlobError:
+		return RedisError(v.Str)
+	case RespInt:
+		return v.Int
+	case RespFloat:
+		return v.Float
+	case RespBool:
+		return v.Bool
+	case RespBigInt:
+		return v.BigInt
+	case RespArray, RespSet, RespPush:
+		s := make([]interface{}, len(v.Elems))
+		for i := range v.Elems {
+			s[i] = v.Elems[i].Interface()
+		}
+		return s
+	case RespMap:
+		return PairsInterface(v.Pairs)
+	}
+	return nil
+}
+
+// PairsInterface converts the pairs of a map or an attribute like ReadReply does.
+func PairsInterface(pairs []ValuePair) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, len(pairs))
+	for i := range pairs {
+		m[pairs[i].Key.Interface()] = pairs[i].Value.Interface()
+	}
+	return m
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case RespNil:
+		return "<nil>"
+	case RespVerbatim:
+		return v.Format + ":" + v.Str
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Walk calls fn for the value and all nested values in depth-first order,
+// including attributes and the keys of maps. It stops at the first error.
+func (v Value) Walk(fn func(v Value) error) error {
+	if err := fn(v); err != nil {
+		return err
+	}
+	if err := walkPairs(v.Attrs, fn); err != nil {
+		return err
+	}
+	for i := range v.Elems {
+		if err := v.Elems[i].Walk(fn); err != nil {
+			return err
+		}
+	}
+	return walkPairs(v.Pairs, fn)
+}
+
+func walkPairs(pairs []ValuePair, fn func(v Value) error) error {
+	for i := range pairs {
+		if err := pairs[i].Key.Walk(fn); err != nil {
+			return err
+		}
+		if err := pairs[i].Value.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AsString converts the value like ReadString does.
+func (v Value) AsString() (string, error) {
+	switch v.Type {
+	case RespStatus, RespString, RespVerbatim:
+		return v.Str, nil
+	case RespInt:
+		return strconv.FormatInt(v.Int, 10), nil
+	case RespFloat:
+		return strconv.FormatFloat(v.Float, 'f', -1, 64), nil
+	case RespBool:
+		return strconv.FormatBool(v.Bool), nil
+	case RespBigInt:
+		return v.BigInt.String(), nil
+	}
+	return "", v.convErr("string")
+}
+
+// AsInt64 converts the value like ReadInt does.
+func (v Value) AsInt64() (int64, error) {
+	switch v.Type {
+	case RespInt:
+		return v.Int, nil
+	case RespStatus, RespString:
+		return util.ParseInt([]byte(v.Str), 10, 64)
+	case RespBigInt:
+		if !v.BigInt.IsInt64() {
+			return 0, fmt.Errorf("bigInt(%s) value out of range", v.BigInt.String())
+		}
+		return v.BigInt.Int64(), nil
+	}
+	return 0, v.convErr("int")
+}
+
+// AsFloat64 converts the value like ReadFloat does.
+func (v Value) AsFloat64() (float64, error) {
+	switch v.Type {
+	case RespFloat:
+		return v.Float, nil
+	case RespInt:
+		return float64(v.Int), nil
+	case RespStatus, RespString:
+		return strconv.ParseFloat(v.Str, 64)
+	}
+	return 0, v.convErr("float")
+}
+
+// AsBool converts the value like ReadBool does.
+func (v Value) AsBool() (bool, error) {
+	switch v.Type {
+	case RespBool:
+		return v.Bool, nil
+	case RespInt:
+		return v.Int == 1, nil
+	}
+	s, err := v.AsString()
+	if err != nil {
+		return false, err
+	}
+	return s == "OK" || s == "1" || s == "true", nil
+}
+
+// AsSlice returns the elements of arrays, sets and pushes.
+func (v Value) AsSlice() ([]Value, error) {
+	switch v.Type {
+	case RespArray, RespSet, RespPush:
+		return v.Elems, nil
+	}
+	return nil, v.convErr("slice")
+}
+
+// AsMap returns the pairs of maps by their string keys. Like ReadMapLen,
+// it accepts arrays with an even number of elements returned by RESP2.
+func (v Value) AsMap() (map[string]Value, error) {
+	switch v.Type {
+	case RespMap:
+		m := make(map[string]Value, len(v.Pairs))
+		for i := range v.Pairs {
+			k, err := v.Pairs[i].Key.AsString()
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v.Pairs[i].Value
+		}
+		return m, nil
+	case RespArray, RespSet, RespPush:
+		if len(v.Elems)%2 != 0 {
+			return nil, fmt.Errorf("redis: the length of the array must be a multiple of 2, got: %d", len(v.Elems))
+		}
+		m := make(map[string]Value, len(v.Elems)/2)
+		for i := 0; i < len(v.Elems); i += 2 {
+			k, err := v.Elems[i].AsString()
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v.Elems[i+1]
+		}
+		return m, nil
+	}
+	return nil, v.convErr("map")
+}
+
+func (v Value) convErr(to string) error {
+	switch v.Type {
+	case RespNil:
+		return Nil
+	case RespError, RespBlobError:
+		return RedisError(v.Str)
+	}
+	return fmt.Errorf("redis: can't convert %q reply to %s", v.Type, to)
+}
+
+//------------------------------------------------------------------------------
+
+// ReadValue reads the next reply, including the attributes that precede it,
+// without flattening its RESP type. Errors are returned as values.
+func (r *Reader) ReadValue() (Value, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	return r.readValue(line)
+}
+
+func (r *Reader) readValue(line []byte) (Value, error) {
+	// Compatible with RESP2
+	if IsNilReply(line) {
+		return Value{Type: RespNil}, nil
+	}
+
+	v := Value{Type: line[0]}
+	var err error
+	switch line[0] {
+	case RespStatus, RespError:
+		v.Str = string(line[1:])
+	case RespNil:
+	case RespInt:
+		v.Int, err = util.ParseInt(line[1:], 10, 64)
+	case RespFloat:
+		v.Float, err = r.readFloat(line)
+	case RespBool:
+		v.Bool, err = r.readBool(line)
+	case RespBigInt:
+		v.BigInt, err = r.readBigInt(line)
+	case RespString, RespBlobError:
+		v.Str, err = r.readStringReply(line)
+	case RespVerbatim:
+		var s string
+		if s, err = r.readStringReply(line); err != nil {
+			break
+		}
+		if len(s) < 4 || s[3] != ':' {
+			return Value{}, fmt.Errorf("redis: can't parse verbatim string reply: %q", line)
+		}
+		v.Format, v.Str = s[:3], s[4:]
+	case RespArray, RespSet, RespPush:
+		v.Elems, err = r.readValues(line)
+	case RespMap:
+		v.Pairs, err = r.readValuePairs(line)
+	case RespAttr:
+		attrs, err := r.readValuePairs(line)
+		if err != nil {
+			return Value{}, err
+		}
+		// Attributes are followed by the reply they describe.
+		if v, err = r.ReadValue(); err != nil {
+			return Value{}, err
+		}
+		v.Attrs = append(attrs, v.Attrs...)
+		return v, nil
+	default:
+		return Value{}, fmt.Errorf("redis: can't parse %.100q", line)
+	}
+	if err != nil {
+		return Value{}, err
+	}
+	return v, nil
+}
+
+func (r *Reader) readValues(line []byte) ([]Value, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	n, err := r.aggregateLen(line)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]Value, n)
+	for i := range vals {
+		if vals[i], err = r.ReadValue(); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+func (r *Reader) readValuePairs(line []byte) ([]ValuePair, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	n, err := r.aggregateLen(line)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]ValuePair, n)
+	for i := range pairs {
+		if pairs[i].Key, err = r.ReadValue(); err != nil {
+			return nil, err
+		}
+		if pairs[i].Value, err = r.ReadValue(); err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
@@ -0,0 +1,118 @@
+package proto_test
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+func TestReader_ReadValue(t *testing.T) {
+	tests := []struct {
+		reply string
+		value proto.Value
+	}{
+		{"+OK\r\n", proto.Value{Type: proto.RespStatus, Str: "OK"}},
+		{"-ERR oops\r\n", proto.Value{Type: proto.RespError, Str: "ERR oops"}},
+		{":42\r\n", proto.Value{Type: proto.RespInt, Int: 42}},
+		{",1.5\r\n", proto.Value{Type: proto.RespFloat, Float: 1.5}},
+		{"#t\r\n", proto.Value{Type: proto.RespBool, Bool: true}},
+		{"(12345678901234567890\r\n", proto.Value{Type: proto.RespBigInt, BigInt: bigInt("12345678901234567890")}},
+		{"_\r\n", proto.Value{Type: proto.RespNil}},
+		{"$-1\r\n", proto.Value{Type: proto.RespNil}},
+		{"*-1\r\n", proto.Value{Type: proto.RespNil}},
+		{"$5\r\nhello\r\n", proto.Value{Type: proto.RespString, Str: "hello"}},
+		{"=9\r\ntxt:hello\r\n", proto.Value{Type: proto.RespVerbatim, Format: "txt", Str: "hello"}},
+		{
+			"~2\r\n:1\r\n-ERR oops\r\n",
+			proto.Value{Type: proto.RespSet, Elems: []proto.Value{
+				{Type: proto.RespInt, Int: 1},
+				{Type: proto.RespError, Str: "ERR oops"},
+			}},
+		},
+		{
+			"%2\r\n+b\r\n:2\r\n+a\r\n:1\r\n",
+			proto.Value{Type: proto.RespMap, Pairs: []proto.ValuePair{
+				{Key: proto.Value{Type: proto.RespStatus, Str: "b"}, Value: proto.Value{Type: proto.RespInt, Int: 2}},
+				{Key: proto.Value{Type: proto.RespStatus, Str: "a"}, Value: proto.Value{Type: proto.RespInt, Int: 1}},
+			}},
+		},
+		{
+			"*1\r\n|1\r\n+ttl\r\n:3600\r\n$5\r\nhello\r\n",
+			proto.Value{Type: proto.RespArray, Elems: []proto.Value{{
+				Type: proto.RespString,
+				Str:  "hello",
+				Attrs: []proto.ValuePair{
+					{Key: proto.Value{Type: proto.RespStatus, Str: "ttl"}, Value: proto.Value{Type: proto.RespInt, Int: 3600}},
+				},
+			}}},
+		},
+	}
+
+	for _, test := range tests {
+		r := proto.NewReader(strings.NewReader(test.reply))
+		v, err := r.ReadValue()
+		if err != nil {
+			t.Fatalf("ReadValue(%q) failed: %v", test.reply, err)
+		}
+		if !reflect.DeepEqual(v, test.value) {
+			t.Errorf("ReadValue(%q) = %#v, wanted %#v", test.reply, v, test.value)
+		}
+	}
+}
+
+func TestValue_Convert(t *testing.T) {
+	r := proto.NewReader(strings.NewReader("%2\r\n$1\r\na\r\n:1\r\n$1\r\nb\r\n~1\r\n,2.5\r\n"))
+	v, err := r.ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := v.AsMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := m["a"]
+	if n, err := a.AsInt64(); err != nil || n != 1 {
+		t.Errorf("AsInt64() = %d %v, wanted 1", n, err)
+	}
+	if s, err := a.AsString(); err != nil || s != "1" {
+		t.Errorf("AsString() = %q %v, wanted \"1\"", s, err)
+	}
+
+	b := m["b"]
+	elems, err := b.AsSlice()
+	if err != nil || len(elems) != 1 {
+		t.Fatalf("AsSlice() = %v %v, wanted 1 element", elems, err)
+	}
+	if f, err := elems[0].AsFloat64(); err != nil || f != 2.5 {
+		t.Errorf("AsFloat64() = %v %v, wanted 2.5", f, err)
+	}
+
+	var types []byte
+	_ = v.Walk(func(v proto.Value) error {
+		types = append(types, v.Type)
+		return nil
+	})
+	if string(types) != "%$:$~," {
+		t.Errorf("Walk() visited %q, wanted %q", types, "%$:$~,")
+	}
+
+	want := map[interface{}]interface{}{"a": int64(1), "b": []interface{}{2.5}}
+	if got := v.Interface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Interface() = %#v, wanted %#v", got, want)
+	}
+
+	nilValue := proto.Value{Type: proto.RespNil}
+	if _, err := nilValue.AsString(); err != proto.Nil {
+		t.Errorf("AsString() = %v, wanted proto.Nil", err)
+	}
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
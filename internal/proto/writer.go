@@ -2,6 +2,7 @@ package proto
 
 import (
 	"encoding"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -34,6 +35,20 @@ func NewWriter(wr writer) *Writer {
 	}
 }
 
+// StreamArg is a bulk string argument of N bytes that is copied from R
+// without buffering it. The reader is consumed, so it can be written only once.
+type StreamArg struct {
+	R io.Reader
+	N int64
+
+	written bool
+	err     error
+}
+
+func (a *StreamArg) String() string {
+	return fmt.Sprintf("<%d bytes>", a.N)
+}
+
 func (w *Writer) WriteArgs(args []interface{}) error {
 	if err := w.WriteByte(RespArray); err != nil {
 		return err
@@ -132,6 +147,8 @@ func (w *Writer) WriteArg(v interface{}) error {
 		return w.bytes(w.numBuf)
 	case time.Duration:
 		return w.int(v.Nanoseconds())
+	case *StreamArg:
+		return w.stream(v)
 	case encoding.BinaryMarshaler:
 		b, err := v.MarshalBinary()
 		if err != nil {
@@ -162,6 +179,38 @@ func (w *Writer) bytes(b []byte) error {
 	return w.crlf()
 }
 
+func (w *Writer) stream(arg *StreamArg) error {
+	if arg.written {
+		if arg.err != nil {
+			return fmt.Errorf("redis: streamed argument can't be retried: %w", arg.err)
+		}
+		return errors.New("redis: streamed argument can't be written twice")
+	}
+	arg.written = true
+
+	arg.err = w.writeStream(arg)
+	return arg.err
+}
+
+func (w *Writer) writeStream(arg *StreamArg) error {
+	if err := w.WriteByte(RespString); err != nil {
+		return err
+	}
+
+	if err := w.writeLen(int(arg.N)); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w.writer, arg.R, arg.N); err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("redis: streamed argument is shorter than %d bytes", arg.N)
+		}
+		return err
+	}
+
+	return w.crlf()
+}
+
 func (w *Writer) string(s string) error {
 	return w.bytes(util.StringToBytes(s))
 }
@@ -5,6 +5,7 @@ import (
 	"encoding"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -74,6 +75,22 @@ var _ = Describe("WriteBuffer", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(buf.String()).To(Equal(fmt.Sprintf("*1\r\n$16\r\n%s\r\n", bytes.NewBuffer(ip))))
 	})
+
+	It("should stream args", func() {
+		arg := &proto.StreamArg{R: strings.NewReader("hello world"), N: 5}
+		err := wr.WriteArgs([]interface{}{"set", "key", arg})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(Equal("*3\r\n$3\r\nset\r\n$3\r\nkey\r\n$5\r\nhello\r\n"))
+
+		err = wr.WriteArgs([]interface{}{"set", "key", arg})
+		Expect(err).To(MatchError("redis: streamed argument can't be written twice"))
+	})
+
+	It("should fail on short streamed args", func() {
+		arg := &proto.StreamArg{R: strings.NewReader("hello"), N: 10}
+		err := wr.WriteArgs([]interface{}{arg})
+		Expect(err).To(MatchError("redis: streamed argument is shorter than 10 bytes"))
+	})
 })
 
 type discard struct{}
@@ -3,7 +3,10 @@ package redis
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -299,6 +302,49 @@ func (e timeoutErr) Error() string {
 	return "i/o timeout"
 }
 
+var _ = Describe("isIdempotent", func() {
+	It("rejects writes and scripts", func() {
+		Expect(isIdempotent(&CommandInfo{Flags: []string{"readonly", "fast"}})).To(BeTrue())
+		Expect(isIdempotent(&CommandInfo{Flags: []string{"write", "denyoom"}})).To(BeFalse())
+		Expect(isIdempotent(nil)).To(BeFalse())
+
+		// EVAL before Redis 6.2.
+		Expect(isIdempotent(&CommandInfo{Flags: []string{"noscript", "movablekeys"}})).To(BeFalse())
+	})
+
+	It("looks at the options of SET", func() {
+		Expect(isIdempotentCmd(NewStatusCmd(ctx, "set", "key", "nx"))).To(BeTrue())
+		Expect(isIdempotentCmd(NewStatusCmd(ctx, "set", "key", "value", "ex", 10))).To(BeTrue())
+		Expect(isIdempotentCmd(NewStatusCmd(ctx, "set", "key", "value", "NX"))).To(BeFalse())
+		Expect(isIdempotentCmd(NewStringCmd(ctx, "set", "key", "value", "get"))).To(BeFalse())
+		Expect(isIdempotentCmd(NewIntCmd(ctx, "del", "key"))).To(BeFalse())
+		Expect(isIdempotentCmd(NewStatusCmd(ctx, "mset", "key", "value"))).To(BeTrue())
+	})
+})
+
+var _ = Describe("retryPolicy", func() {
+	policy := &retryPolicy{maxRetries: 1}
+	cmds := []Cmder{NewIntCmd(ctx, "incr", "key")}
+
+	It("retries writes that were never sent", func() {
+		for _, err := range []error{pool.ErrPoolTimeout, pool.ErrPoolExhausted, pool.ErrBudgetTimeout} {
+			Expect(mayBeApplied(err)).To(BeFalse())
+			Expect(policy.ShouldRetry(ctx, cmds, 0, err)).To(BeTrue())
+			Expect(policy.ShouldRetry(ctx, cmds, 1, err)).To(BeFalse())
+		}
+		Expect(policy.ShouldRetry(ctx, cmds, 0, &net.OpError{Op: "dial", Err: io.EOF})).To(BeTrue())
+
+		// The client is closed.
+		Expect(mayBeApplied(pool.ErrClosed)).To(BeFalse())
+		Expect(policy.ShouldRetry(ctx, cmds, 0, pool.ErrClosed)).To(BeFalse())
+	})
+
+	It("doesn't retry writes that may have been applied", func() {
+		Expect(policy.ShouldRetry(ctx, cmds, 0, io.EOF)).To(BeFalse())
+		Expect(policy.ShouldRetry(ctx, []Cmder{NewStatusCmd(ctx, "ping")}, 0, io.EOF)).To(BeTrue())
+	})
+})
+
 var _ = Describe("withConn", func() {
 	var client *Client
 
@@ -352,3 +398,67 @@ var _ = Describe("withConn", func() {
 		Expect(client.connPool.Len()).To(Equal(1))
 	})
 })
+
+var _ = Describe("readReply", func() {
+	var client *Client
+	var pushes []*Push
+
+	BeforeEach(func() {
+		pushes = nil
+		client = NewClient(&Options{
+			OnPush: func(ctx context.Context, push *Push) {
+				pushes = append(pushes, push)
+			},
+		})
+	})
+
+	AfterEach(func() {
+		client.Close()
+	})
+
+	It("dispatches push messages preceding the reply", func() {
+		rd := proto.NewReader(strings.NewReader(
+			">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nkey\r\n$5\r\nhello\r\n",
+		))
+
+		cmd := NewStringCmd(ctx, "get", "key")
+		Expect(client.readReply(ctx, rd, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal("hello"))
+
+		Expect(pushes).To(Equal([]*Push{{
+			Kind:    "invalidate",
+			Payload: []interface{}{[]interface{}{"key"}},
+		}}))
+	})
+
+	It("reads the push message replying to the command", func() {
+		rd := proto.NewReader(strings.NewReader(
+			">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nkey\r\n" +
+				">3\r\n$9\r\nsubscribe\r\n$2\r\nch\r\n:1\r\n",
+		))
+
+		cmd := NewCmd(ctx, "subscribe", "ch")
+		Expect(client.readReply(ctx, rd, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal([]interface{}{"subscribe", "ch", int64(1)}))
+		Expect(pushes).To(HaveLen(1))
+		Expect(pushes[0].Kind).To(Equal("invalidate"))
+	})
+
+	It("keeps the attributes preceding the reply", func() {
+		rd := proto.NewReader(strings.NewReader(
+			"|1\r\n+key-popularity\r\n%1\r\n$3\r\nkey\r\n,0.5\r\n$5\r\nhello\r\n$5\r\nworld\r\n",
+		))
+
+		cmd := NewStringCmd(ctx, "get", "key")
+		Expect(client.readReply(ctx, rd, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal("hello"))
+		Expect(cmd.Attributes()).To(Equal(map[interface{}]interface{}{
+			"key-popularity": map[interface{}]interface{}{"key": 0.5},
+		}))
+
+		cmd = NewStringCmd(ctx, "get", "key")
+		Expect(client.readReply(ctx, rd, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal("world"))
+		Expect(cmd.Attributes()).To(BeNil())
+	})
+})
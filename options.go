@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9/internal/pool"
+	"github.com/redis/go-redis/v9/internal/proto"
 )
 
 // Limiter is the interface of a rate limiter or a circuit breaker.
@@ -45,6 +46,11 @@ type Options struct {
 	// Hook that is called when new connection is established.
 	OnConnect func(ctx context.Context, cn *Conn) error
 
+	// Hook that is called with the RESP3 push messages, e.g. client-side caching
+	// invalidations, received in between the command replies.
+	// Push messages are discarded when the hook is not set.
+	OnPush func(ctx context.Context, push *Push)
+
 	// Protocol 2 or 3. Use the version to negotiate RESP version with redis-server.
 	// Default is 3.
 	Protocol int
@@ -73,6 +79,12 @@ type Options struct {
 	// Maximum backoff between each retry.
 	// Default is 512 milliseconds; -1 disables backoff.
 	MaxRetryBackoff time.Duration
+	// RetryPolicy decides which failed commands are retried and how long
+	// to back off. The default policy retries up to MaxRetries times with
+	// a backoff between MinRetryBackoff and MaxRetryBackoff. It doesn't retry
+	// the commands that the server may have applied already, e.g. after a read
+	// timeout, unless COMMAND doesn't flag them as writes, scripts or publishing.
+	RetryPolicy RetryPolicy
 
 	// Dial timeout for establishing new connections.
 	// Default is 5 seconds.
@@ -93,6 +105,20 @@ type Options struct {
 	// See https://redis.uptrace.dev/guide/go-redis-debugging.html#timeouts
 	ContextTimeoutEnabled bool
 
+	// Maximum length of a bulk string reply and of the line of a simple string,
+	// error or number reply. Longer replies fail with a ProtocolError and close
+	// the connection, which protects against misbehaving servers and proxies.
+	// Default is 0, which disables the limit.
+	MaxBulkLen int
+	// Maximum number of elements of an array, set or map reply. Larger replies
+	// fail with a ProtocolError and close the connection.
+	// Default is 0, which disables the limit.
+	MaxAggregateLen int
+	// Maximum nesting depth of aggregate replies. Deeper replies fail with
+	// a ProtocolError and close the connection.
+	// Default is 0, which disables the limit.
+	MaxReplyDepth int
+
 	// Type of connection pool.
 	// true for FIFO pool, false for LIFO pool.
 	// Note that FIFO has slightly higher overhead compared to LIFO,
@@ -132,16 +158,43 @@ type Options struct {
 	//
 	// Default is to not close idle connections.
 	ConnMaxLifetime time.Duration
+	// IdleCheckFrequency enables a background goroutine that maintains the idle
+	// connections at this interval. It closes the connections that exceeded
+	// ConnMaxIdleTime or ConnMaxLifetime, checks the connections that haven't
+	// been used for HealthCheckInterval with PING and dials connections up to
+	// MinIdleConns, so requests after a quiet period don't pay for that.
+	//
+	// Default is 0, which disables the maintenance.
+	IdleCheckFrequency time.Duration
+	// HealthCheckInterval is the amount of time after which the maintenance
+	// checks an idle connection with PING. It requires IdleCheckFrequency.
+	//
+	// Default is 0, which disables the health checks.
+	HealthCheckInterval time.Duration
 
 	// TLS Config to use. When set, TLS will be negotiated.
 	TLSConfig *tls.Config
 
 	// Limiter interface used to implement circuit breaker or rate limiter.
+	// The redislimit package implements both.
 	Limiter Limiter
 
+	// ClientSideCache enables server-assisted client-side caching of
+	// read-only commands using CLIENT TRACKING. See CacheOptions.
+	// Default is nil, which disables the cache.
+	ClientSideCache *CacheOptions
+
+	// AutoPipeline enables automatic pipelining, which lets the commands
+	// of concurrent goroutines share connections. See AutoPipelineOptions.
+	// Default is nil, which gives every command a connection of the pool.
+	AutoPipeline *AutoPipelineOptions
+
 	// Enables read only queries on slave/follower nodes.
 	readOnly bool
 
+	// Shared by the nodes of a ClusterClient or the shards of a Ring.
+	connBudget *pool.Budget
+
 	// Disable set-lib on connect. Default is false.
 	DisableIndentity bool
 
@@ -196,6 +249,13 @@ func (opt *Options) init() {
 		opt.ConnMaxIdleTime = 30 * time.Minute
 	}
 
+	if opt.ClientSideCache != nil {
+		opt.ClientSideCache.init()
+	}
+	if opt.AutoPipeline != nil {
+		opt.AutoPipeline.init()
+	}
+
 	if opt.MaxRetries == -1 {
 		opt.MaxRetries = 0
 	} else if opt.MaxRetries == 0 {
@@ -475,6 +535,11 @@ func setupConnParams(u *url.URL, o *Options) (*Options, error) {
 	} else {
 		o.ConnMaxLifetime = q.duration("max_conn_age")
 	}
+	o.IdleCheckFrequency = q.duration("idle_check_frequency")
+	o.MaxBulkLen = q.int("max_bulk_len")
+	o.MaxAggregateLen = q.int("max_aggregate_len")
+	o.MaxReplyDepth = q.int("max_reply_depth")
+	o.HealthCheckInterval = q.duration("health_check_interval")
 	if q.err != nil {
 		return nil, q.err
 	}
@@ -501,6 +566,7 @@ func getUserPassword(u *url.URL) (string, string) {
 func newConnPool(
 	opt *Options,
 	dialer func(ctx context.Context, network, addr string) (net.Conn, error),
+	healthCheck func(ctx context.Context, cn *pool.Conn) error,
 ) *pool.ConnPool {
 	return pool.NewConnPool(&pool.Options{
 		Dialer: func(ctx context.Context) (net.Conn, error) {
@@ -514,5 +580,17 @@ func newConnPool(
 		MaxActiveConns:  opt.MaxActiveConns,
 		ConnMaxIdleTime: opt.ConnMaxIdleTime,
 		ConnMaxLifetime: opt.ConnMaxLifetime,
+
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheck:         healthCheck,
+		HealthCheckInterval: opt.HealthCheckInterval,
+
+		Budget: opt.connBudget,
+
+		ReaderLimits: proto.Limits{
+			MaxBulkLen:      opt.MaxBulkLen,
+			MaxAggregateLen: opt.MaxAggregateLen,
+			MaxDepth:        opt.MaxReplyDepth,
+		},
 	})
 }
@@ -64,6 +64,10 @@ func TestParseURL(t *testing.T) {
 		}, {
 			url: "redis://localhost:123/?db=2&protocol=2", // RESP Protocol
 			o:   &Options{Addr: "localhost:123", DB: 2, Protocol: 2},
+		}, {
+			// reply limits
+			url: "redis://localhost:123/?max_bulk_len=1024&max_aggregate_len=100&max_reply_depth=4",
+			o:   &Options{Addr: "localhost:123", MaxBulkLen: 1024, MaxAggregateLen: 100, MaxReplyDepth: 4},
 		}, {
 			url: "unix:///tmp/redis.sock",
 			o:   &Options{Addr: "/tmp/redis.sock"},
@@ -194,6 +198,15 @@ func comprareOptions(t *testing.T, actual, expected *Options) {
 	if actual.ConnMaxLifetime != expected.ConnMaxLifetime {
 		t.Errorf("ConnMaxLifetime: got %v, expected %v", actual.ConnMaxLifetime, expected.ConnMaxLifetime)
 	}
+	if actual.MaxBulkLen != expected.MaxBulkLen {
+		t.Errorf("MaxBulkLen: got %v, expected %v", actual.MaxBulkLen, expected.MaxBulkLen)
+	}
+	if actual.MaxAggregateLen != expected.MaxAggregateLen {
+		t.Errorf("MaxAggregateLen: got %v, expected %v", actual.MaxAggregateLen, expected.MaxAggregateLen)
+	}
+	if actual.MaxReplyDepth != expected.MaxReplyDepth {
+		t.Errorf("MaxReplyDepth: got %v, expected %v", actual.MaxReplyDepth, expected.MaxReplyDepth)
+	}
 }
 
 // Test ReadTimeout option initialization, including special values -1 and 0.
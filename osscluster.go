@@ -48,6 +48,11 @@ type ClusterOptions struct {
 	// Allows routing read-only commands to the random master or slave node.
 	// It automatically enables ReadOnly.
 	RouteRandomly bool
+	// ReadRouter picks the master or slave node for read-only commands.
+	// It automatically enables ReadOnly and takes precedence over
+	// RouteByLatency and RouteRandomly. Set RouteByLatency as well
+	// to pass the latencies of the nodes to it.
+	ReadRouter ReadRouter
 
 	// Optional function that returns cluster slots information.
 	// It is useful to manually create cluster of standalone Redis servers
@@ -61,6 +66,7 @@ type ClusterOptions struct {
 	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
 
 	OnConnect func(ctx context.Context, cn *Conn) error
+	OnPush    func(ctx context.Context, push *Push)
 
 	Protocol int
 	Username string
@@ -69,25 +75,51 @@ type ClusterOptions struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+	// RetryPolicy decides which failed commands are retried. The default
+	// policy retries up to MaxRedirects times. See Options.RetryPolicy.
+	RetryPolicy RetryPolicy
 
 	DialTimeout           time.Duration
 	ReadTimeout           time.Duration
 	WriteTimeout          time.Duration
 	ContextTimeoutEnabled bool
 
-	PoolFIFO        bool
-	PoolSize        int // applies per cluster node and not for the whole cluster
-	PoolTimeout     time.Duration
-	MinIdleConns    int
-	MaxIdleConns    int
-	MaxActiveConns  int // applies per cluster node and not for the whole cluster
-	ConnMaxIdleTime time.Duration
-	ConnMaxLifetime time.Duration
+	MaxBulkLen      int
+	MaxAggregateLen int
+	MaxReplyDepth   int
+
+	PoolFIFO            bool
+	PoolSize            int // applies per cluster node and not for the whole cluster
+	PoolTimeout         time.Duration
+	MinIdleConns        int
+	MaxIdleConns        int
+	MaxActiveConns      int // applies per cluster node and not for the whole cluster
+	ConnMaxIdleTime     time.Duration
+	ConnMaxLifetime     time.Duration
+	IdleCheckFrequency  time.Duration
+	HealthCheckInterval time.Duration
 
 	TLSConfig        *tls.Config
 	DisableIndentity bool // Disable set-lib on connect. Default is false.
 
 	IdentitySuffix string // Add suffix to client name. Default is empty.
+
+	// ClientSideCache enables server-assisted client-side caching.
+	// Every node keeps its own cache and tracking connection in the broadcasting mode,
+	// so MaxEntries applies per cluster node and not for the whole cluster.
+	ClientSideCache *CacheOptions
+
+	// AutoPipeline enables automatic pipelining on the connections
+	// of every cluster node. See AutoPipelineOptions.
+	AutoPipeline *AutoPipelineOptions
+
+	// ConnBudget limits the connections of all cluster nodes together.
+	// See ConnBudgetOptions. Default is nil, which only limits them per node.
+	ConnBudget *ConnBudgetOptions
+
+	// Hedge enables hedged reads across the nodes serving a slot.
+	// It requires ReadOnly. See HedgeOptions.
+	Hedge *HedgeOptions
 }
 
 func (opt *ClusterOptions) init() {
@@ -97,7 +129,7 @@ func (opt *ClusterOptions) init() {
 		opt.MaxRedirects = 3
 	}
 
-	if opt.RouteByLatency || opt.RouteRandomly {
+	if opt.RouteByLatency || opt.RouteRandomly || opt.ReadRouter != nil {
 		opt.ReadOnly = true
 	}
 
@@ -134,6 +166,10 @@ func (opt *ClusterOptions) init() {
 		opt.MaxRetryBackoff = 512 * time.Millisecond
 	}
 
+	if opt.Hedge != nil {
+		opt.Hedge.init()
+	}
+
 	if opt.NewClient == nil {
 		opt.NewClient = NewClient
 	}
@@ -241,6 +277,11 @@ func setupClusterQueryParams(u *url.URL, o *ClusterOptions) (*ClusterOptions, er
 	o.PoolTimeout = q.duration("pool_timeout")
 	o.ConnMaxLifetime = q.duration("conn_max_lifetime")
 	o.ConnMaxIdleTime = q.duration("conn_max_idle_time")
+	o.IdleCheckFrequency = q.duration("idle_check_frequency")
+	o.HealthCheckInterval = q.duration("health_check_interval")
+	o.MaxBulkLen = q.int("max_bulk_len")
+	o.MaxAggregateLen = q.int("max_aggregate_len")
+	o.MaxReplyDepth = q.int("max_reply_depth")
 
 	if q.err != nil {
 		return nil, q.err
@@ -270,6 +311,7 @@ func (opt *ClusterOptions) clientOptions() *Options {
 		ClientName: opt.ClientName,
 		Dialer:     opt.Dialer,
 		OnConnect:  opt.OnConnect,
+		OnPush:     opt.OnPush,
 
 		Protocol: opt.Protocol,
 		Username: opt.Username,
@@ -283,18 +325,25 @@ func (opt *ClusterOptions) clientOptions() *Options {
 		ReadTimeout:           opt.ReadTimeout,
 		WriteTimeout:          opt.WriteTimeout,
 		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
-
-		PoolFIFO:         opt.PoolFIFO,
-		PoolSize:         opt.PoolSize,
-		PoolTimeout:      opt.PoolTimeout,
-		MinIdleConns:     opt.MinIdleConns,
-		MaxIdleConns:     opt.MaxIdleConns,
-		MaxActiveConns:   opt.MaxActiveConns,
-		ConnMaxIdleTime:  opt.ConnMaxIdleTime,
-		ConnMaxLifetime:  opt.ConnMaxLifetime,
-		DisableIndentity: opt.DisableIndentity,
-		IdentitySuffix:   opt.IdentitySuffix,
-		TLSConfig:        opt.TLSConfig,
+		MaxBulkLen:            opt.MaxBulkLen,
+		MaxAggregateLen:       opt.MaxAggregateLen,
+		MaxReplyDepth:         opt.MaxReplyDepth,
+
+		PoolFIFO:            opt.PoolFIFO,
+		PoolSize:            opt.PoolSize,
+		PoolTimeout:         opt.PoolTimeout,
+		MinIdleConns:        opt.MinIdleConns,
+		MaxIdleConns:        opt.MaxIdleConns,
+		MaxActiveConns:      opt.MaxActiveConns,
+		ConnMaxIdleTime:     opt.ConnMaxIdleTime,
+		ConnMaxLifetime:     opt.ConnMaxLifetime,
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheckInterval: opt.HealthCheckInterval,
+		DisableIndentity:    opt.DisableIndentity,
+		IdentitySuffix:      opt.IdentitySuffix,
+		TLSConfig:           opt.TLSConfig,
+		ClientSideCache:     opt.cacheOptions(),
+		AutoPipeline:        opt.AutoPipeline.clone(),
 		// If ClusterSlots is populated, then we probably have an artificial
 		// cluster whose nodes are not in clustering mode (otherwise there isn't
 		// much use for ClusterSlots config).  This means we cannot execute the
@@ -304,22 +353,48 @@ func (opt *ClusterOptions) clientOptions() *Options {
 	}
 }
 
+func (opt *ClusterOptions) cacheOptions() *CacheOptions {
+	if opt.ClientSideCache == nil {
+		return nil
+	}
+	// Only the broadcasting mode allows to track the keys of a node
+	// with a single connection that survives topology changes.
+	cacheOpt := opt.ClientSideCache.clone()
+	cacheOpt.Broadcast = true
+	return cacheOpt
+}
+
 //------------------------------------------------------------------------------
 
 type clusterNode struct {
 	Client *Client
 
-	latency    uint32 // atomic
-	generation uint32 // atomic
-	failing    uint32 // atomic
+	latency     uint32 // atomic
+	generation  uint32 // atomic
+	failing     uint32 // atomic
+	outstanding int32  // atomic
+
+	labels atomic.Value // map[string]string
+
+	// latencies of the read-only commands, kept for hedged reads.
+	latencies *latencyWindow
 }
 
-func newClusterNode(clOpt *ClusterOptions, addr string) *clusterNode {
+func newClusterNode(clOpt *ClusterOptions, addr string, limits *PoolLimits, budget *pool.Budget) *clusterNode {
 	opt := clOpt.clientOptions()
 	opt.Addr = addr
+	opt.connBudget = budget
+	if limits != nil {
+		opt.PoolSize = limits.PoolSize
+		opt.MinIdleConns = limits.MinIdleConns
+		opt.MaxActiveConns = limits.MaxActiveConns
+	}
 	node := clusterNode{
 		Client: clOpt.NewClient(opt),
 	}
+	if clOpt.Hedge != nil && clOpt.Hedge.Percentile > 0 {
+		node.latencies = newLatencyWindow(clOpt.Hedge.Percentile)
+	}
 
 	node.latency = math.MaxUint32
 	if clOpt.RouteByLatency {
@@ -369,6 +444,54 @@ func (n *clusterNode) Latency() time.Duration {
 	return time.Duration(latency) * time.Microsecond
 }
 
+// process runs cmd on the node, counting it as outstanding.
+func (n *clusterNode) process(ctx context.Context, cmd Cmder) error {
+	atomic.AddInt32(&n.outstanding, 1)
+	defer atomic.AddInt32(&n.outstanding, -1)
+	return n.Client.Process(ctx, cmd)
+}
+
+// processAttempt processes an attempt of a hedged read without the hooks.
+func (n *clusterNode) processAttempt(ctx context.Context, cmd *hedgeCmd) error {
+	atomic.AddInt32(&n.outstanding, 1)
+	defer atomic.AddInt32(&n.outstanding, -1)
+	return n.Client.baseClient.process(ctx, cmd)
+}
+
+// Labels returns the networking metadata of the node.
+func (n *clusterNode) Labels() map[string]string {
+	labels, _ := n.labels.Load().(map[string]string)
+	return labels
+}
+
+func (n *clusterNode) SetLabels(labels map[string]string) {
+	if labels == nil && n.Labels() == nil {
+		return
+	}
+	n.labels.Store(labels)
+}
+
+// readNode describes the node to a ReadRouter.
+func (n *clusterNode) readNode(master bool) ReadNode {
+	node := ReadNode{
+		Addr:        n.Client.opt.Addr,
+		Master:      master,
+		Failing:     n.Failing(),
+		Outstanding: int(atomic.LoadInt32(&n.outstanding)),
+		Labels:      n.Labels(),
+	}
+	if atomic.LoadUint32(&n.latency) != math.MaxUint32 {
+		node.Latency = n.Latency()
+	}
+	return node
+}
+
+func (n *clusterNode) addLatency(d time.Duration) {
+	if n.latencies != nil {
+		n.latencies.add(d)
+	}
+}
+
 func (n *clusterNode) MarkAsFailing() {
 	atomic.StoreUint32(&n.failing, uint32(time.Now().Unix()))
 }
@@ -411,6 +534,8 @@ type clusterNodes struct {
 	activeAddrs []string
 	closed      bool
 	onNewNode   []func(rdb *Client)
+	poolLimits  *PoolLimits // set by ResizePool
+	budget      *pool.Budget
 
 	_generation uint32 // atomic
 }
@@ -419,8 +544,9 @@ func newClusterNodes(opt *ClusterOptions) *clusterNodes {
 	return &clusterNodes{
 		opt: opt,
 
-		addrs: opt.Addrs,
-		nodes: make(map[string]*clusterNode),
+		addrs:  opt.Addrs,
+		nodes:  make(map[string]*clusterNode),
+		budget: newConnBudget(opt.ConnBudget, opt.clientOptions()),
 	}
 }
 
@@ -528,7 +654,7 @@ func (c *clusterNodes) GetOrCreate(addr string) (*clusterNode, error) {
 		return node, nil
 	}
 
-	node = newClusterNode(c.opt, addr)
+	node = newClusterNode(c.opt, addr, c.poolLimits, c.budget)
 	for _, fn := range c.onNewNode {
 		fn(node.Client)
 	}
@@ -552,6 +678,17 @@ func (c *clusterNodes) get(addr string) (*clusterNode, error) {
 	return node, err
 }
 
+// ResizePools resizes the pools of the nodes and the nodes created later.
+func (c *clusterNodes) ResizePools(limits PoolLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.poolLimits = &limits
+	for _, node := range c.nodes {
+		node.Client.ResizePool(limits)
+	}
+}
+
 func (c *clusterNodes) All() ([]*clusterNode, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -638,6 +775,7 @@ func newClusterState(
 			}
 
 			node.SetGeneration(c.generation)
+			node.SetLabels(slotNode.NetworkingMetadata)
 			nodes = append(nodes, node)
 
 			if i == 0 {
@@ -765,6 +903,21 @@ func (c *clusterState) slotRandomNode(slot int) (*clusterNode, error) {
 	return nodes[randomNodes[0]], nil
 }
 
+func (c *clusterState) slotRoutedNode(
+	ctx context.Context, router ReadRouter, slot int,
+) (*clusterNode, error) {
+	nodes := c.slotNodes(slot)
+	if len(nodes) == 0 {
+		return c.nodes.Random()
+	}
+
+	readNodes := make([]ReadNode, len(nodes))
+	for i, node := range nodes {
+		readNodes[i] = node.readNode(i == 0)
+	}
+	return nodes[routeRead(ctx, router, readNodes)], nil
+}
+
 func (c *clusterState) slotNodes(slot int) []*clusterNode {
 	i := sort.Search(len(c.slots), func(i int) bool {
 		return c.slots[i].end >= slot
@@ -779,6 +932,18 @@ func (c *clusterState) slotNodes(slot int) []*clusterNode {
 	return nil
 }
 
+// nodeByAddr returns the master or replica with the address addr, or nil.
+func (c *clusterState) nodeByAddr(addr string) *clusterNode {
+	for _, nodes := range [][]*clusterNode{c.Masters, c.Slaves} {
+		for _, node := range nodes {
+			if node.Client.Options().Addr == addr {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 type clusterStateHolder struct {
@@ -849,6 +1014,8 @@ type ClusterClient struct {
 	nodes         *clusterNodes
 	state         *clusterStateHolder
 	cmdsInfoCache *cmdsInfoCache
+	retryPolicy   RetryPolicy
+	hedger        *hedger
 	cmdable
 	hooksMixin
 }
@@ -865,6 +1032,12 @@ func NewClusterClient(opt *ClusterOptions) *ClusterClient {
 
 	c.state = newClusterStateHolder(c.loadState)
 	c.cmdsInfoCache = newCmdsInfoCache(c.cmdsInfo)
+	c.retryPolicy = newRetryPolicy(
+		opt.RetryPolicy, opt.MaxRedirects, opt.MinRetryBackoff, opt.MaxRetryBackoff, c.cmdsInfoCache.Get,
+	)
+	if opt.ReadOnly {
+		c.hedger = newHedger(opt.Hedge)
+	}
 	c.cmdable = c.Process
 
 	c.initHooks(hooks{
@@ -903,6 +1076,13 @@ func (c *ClusterClient) Do(ctx context.Context, args ...interface{}) *Cmd {
 	return cmd
 }
 
+// DoValue creates a ValueCmd from the args and processes the cmd.
+func (c *ClusterClient) DoValue(ctx context.Context, args ...interface{}) *ValueCmd {
+	cmd := NewValueCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
 func (c *ClusterClient) Process(ctx context.Context, cmd Cmder) error {
 	err := c.processHook(ctx, cmd)
 	cmd.SetErr(err)
@@ -912,18 +1092,18 @@ func (c *ClusterClient) Process(ctx context.Context, cmd Cmder) error {
 func (c *ClusterClient) process(ctx context.Context, cmd Cmder) error {
 	slot := c.cmdSlot(ctx, cmd)
 	var node *clusterNode
-	var ask bool
+	var ask, readOnly bool
 	var lastErr error
 	for attempt := 0; attempt <= c.opt.MaxRedirects; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, c.retry(ctx).Backoff(attempt)); err != nil {
 				return err
 			}
 		}
 
 		if node == nil {
 			var err error
-			node, err = c.cmdNode(ctx, cmd.Name(), slot)
+			node, readOnly, err = c.cmdNode(ctx, cmd.Name(), slot)
 			if err != nil {
 				return err
 			}
@@ -936,8 +1116,10 @@ func (c *ClusterClient) process(ctx context.Context, cmd Cmder) error {
 			_ = pipe.Process(ctx, NewCmd(ctx, "asking"))
 			_ = pipe.Process(ctx, cmd)
 			_, lastErr = pipe.Exec(ctx)
+		} else if c.hedger != nil && attempt == 0 && readOnly && hedgeable(cmd) {
+			lastErr = c.processHedged(ctx, cmd, slot, node)
 		} else {
-			lastErr = node.Client.Process(ctx, cmd)
+			lastErr = node.process(ctx, cmd)
 		}
 
 		// If there is no error - we are done.
@@ -973,7 +1155,7 @@ func (c *ClusterClient) process(ctx context.Context, cmd Cmder) error {
 			continue
 		}
 
-		if shouldRetry(lastErr, cmd.readTimeout() == nil) {
+		if c.retry(ctx).ShouldRetry(ctx, []Cmder{cmd}, attempt, lastErr) {
 			// First retry the same node.
 			if attempt == 0 {
 				continue
@@ -990,6 +1172,10 @@ func (c *ClusterClient) process(ctx context.Context, cmd Cmder) error {
 	return lastErr
 }
 
+func (c *ClusterClient) retry(ctx context.Context) RetryPolicy {
+	return callRetryPolicy(ctx, c.retryPolicy)
+}
+
 func (c *ClusterClient) OnNewNode(fn func(rdb *Client)) {
 	c.nodes.OnNewNode(fn)
 }
@@ -1114,6 +1300,47 @@ func (c *ClusterClient) ForEachShard(
 	}
 }
 
+// ResizePool changes the limits of the connection pools of all cluster nodes,
+// including the nodes discovered later. Like PoolSize, the limits apply per
+// node. See Client.ResizePool.
+func (c *ClusterClient) ResizePool(limits PoolLimits) {
+	limits.init()
+	c.nodes.ResizePools(limits)
+}
+
+// Warmup dials and initializes up to n connections to every cluster node.
+// See Client.Warmup.
+func (c *ClusterClient) Warmup(ctx context.Context, n int) error {
+	state, err := c.state.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]*clusterNode, 0, len(state.Masters)+len(state.Slaves))
+	nodes = append(nodes, state.Masters...)
+	nodes = append(nodes, state.Slaves...)
+
+	errCh := make(chan error, len(nodes))
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *clusterNode) {
+			defer wg.Done()
+			if err := node.Client.Warmup(ctx, n); err != nil {
+				errCh <- err
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
 // PoolStats returns accumulated connection pool stats.
 func (c *ClusterClient) PoolStats() *PoolStats {
 	var acc PoolStats
@@ -1124,25 +1351,44 @@ func (c *ClusterClient) PoolStats() *PoolStats {
 	}
 
 	for _, node := range state.Masters {
-		s := node.Client.connPool.Stats()
-		acc.Hits += s.Hits
-		acc.Misses += s.Misses
-		acc.Timeouts += s.Timeouts
-
-		acc.TotalConns += s.TotalConns
-		acc.IdleConns += s.IdleConns
-		acc.StaleConns += s.StaleConns
+		acc.add(node.Client.connPool.Stats())
 	}
 
 	for _, node := range state.Slaves {
-		s := node.Client.connPool.Stats()
+		acc.add(node.Client.connPool.Stats())
+	}
+
+	return &acc
+}
+
+// HedgeStats returns the accumulated stats of hedged reads.
+// They are zero when Hedge or ReadOnly isn't set.
+func (c *ClusterClient) HedgeStats() *HedgeStats {
+	if c.hedger == nil {
+		return new(HedgeStats)
+	}
+	return c.hedger.Stats()
+}
+
+// ConnBudgetStats returns the stats of the connection budget shared by
+// all nodes. They are zero when ConnBudget isn't set.
+func (c *ClusterClient) ConnBudgetStats() *ConnBudgetStats {
+	return connBudgetStats(c.nodes.budget)
+}
+
+// CacheStats returns accumulated client-side cache stats of all nodes.
+func (c *ClusterClient) CacheStats() *CacheStats {
+	var acc CacheStats
+
+	nodes, _ := c.nodes.All()
+	for _, node := range nodes {
+		s := node.Client.CacheStats()
 		acc.Hits += s.Hits
 		acc.Misses += s.Misses
-		acc.Timeouts += s.Timeouts
+		acc.Invalidations += s.Invalidations
+		acc.Evictions += s.Evictions
 
-		acc.TotalConns += s.TotalConns
-		acc.IdleConns += s.IdleConns
-		acc.StaleConns += s.StaleConns
+		acc.Entries += s.Entries
 	}
 
 	return &acc
@@ -1154,7 +1400,7 @@ func (c *ClusterClient) loadState(ctx context.Context) (*clusterState, error) {
 		if err != nil {
 			return nil, err
 		}
-		return newClusterState(c.nodes, slots, "")
+		return c.newClusterState(slots, "")
 	}
 
 	addrs, err := c.nodes.Addrs()
@@ -1183,7 +1429,7 @@ func (c *ClusterClient) loadState(ctx context.Context) (*clusterState, error) {
 			continue
 		}
 
-		return newClusterState(c.nodes, slots, node.Client.opt.Addr)
+		return c.newClusterState(slots, node.Client.opt.Addr)
 	}
 
 	/*
@@ -1199,6 +1445,58 @@ func (c *ClusterClient) loadState(ctx context.Context) (*clusterState, error) {
 	return nil, firstErr
 }
 
+func (c *ClusterClient) newClusterState(slots []ClusterSlot, origin string) (*clusterState, error) {
+	state, err := newClusterState(c.nodes, slots, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.opt.ClientSideCache != nil {
+		if prev, _ := c.state.state.Load().(*clusterState); prev != nil {
+			flushMovedSlots(prev, state)
+		}
+	}
+
+	return state, nil
+}
+
+// flushMovedSlots removes the cached replies of the slots
+// that are no longer served by the same master node.
+func flushMovedSlots(prev, state *clusterState) {
+	moved := make(map[*clusterNode]map[int]struct{})
+	for _, slot := range prev.slots {
+		if len(slot.nodes) == 0 {
+			continue
+		}
+
+		master := slot.nodes[0]
+		for i := slot.start; i <= slot.end; i++ {
+			if nodes := state.slotNodes(i); len(nodes) > 0 && nodes[0] == master {
+				continue
+			}
+
+			for _, node := range slot.nodes {
+				slots, ok := moved[node]
+				if !ok {
+					slots = make(map[int]struct{})
+					moved[node] = slots
+				}
+				slots[i] = struct{}{}
+			}
+		}
+	}
+
+	for node, slots := range moved {
+		if node.Client.cache == nil {
+			continue
+		}
+		node.Client.cache.flushFunc(func(keys []string) bool {
+			_, ok := slots[hashtag.Slot(keys[0])]
+			return ok
+		})
+	}
+}
+
 func (c *ClusterClient) Pipeline() Pipeliner {
 	pipe := Pipeline{
 		exec: pipelineExecer(c.processPipelineHook),
@@ -1221,7 +1519,7 @@ func (c *ClusterClient) processPipeline(ctx context.Context, cmds []Cmder) error
 
 	for attempt := 0; attempt <= c.opt.MaxRedirects; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, c.retry(ctx).Backoff(attempt)); err != nil {
 				setCmdsErr(cmds, err)
 				return err
 			}
@@ -1234,7 +1532,7 @@ func (c *ClusterClient) processPipeline(ctx context.Context, cmds []Cmder) error
 			wg.Add(1)
 			go func(node *clusterNode, cmds []Cmder) {
 				defer wg.Done()
-				c.processPipelineNode(ctx, node, cmds, failedCmds)
+				c.processPipelineNode(ctx, node, cmds, failedCmds, attempt)
 			}(node, cmds)
 		}
 
@@ -1249,15 +1547,25 @@ func (c *ClusterClient) processPipeline(ctx context.Context, cmds []Cmder) error
 }
 
 func (c *ClusterClient) mapCmdsByNode(ctx context.Context, cmdsMap *cmdsMap, cmds []Cmder) error {
+	if node, err := c.callNode(ctx); node != nil || err != nil {
+		if err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			cmdsMap.Add(node, cmd)
+		}
+		return nil
+	}
+
 	state, err := c.state.Get(ctx)
 	if err != nil {
 		return err
 	}
 
-	if c.opt.ReadOnly && c.cmdsAreReadOnly(ctx, cmds) {
+	if c.readOnly(ctx) && c.cmdsAreReadOnly(ctx, cmds) {
 		for _, cmd := range cmds {
 			slot := c.cmdSlot(ctx, cmd)
-			node, err := c.slotReadOnlyNode(state, slot)
+			node, err := c.slotReadOnlyNode(ctx, state, slot)
 			if err != nil {
 				return err
 			}
@@ -1288,8 +1596,11 @@ func (c *ClusterClient) cmdsAreReadOnly(ctx context.Context, cmds []Cmder) bool
 }
 
 func (c *ClusterClient) processPipelineNode(
-	ctx context.Context, node *clusterNode, cmds []Cmder, failedCmds *cmdsMap,
+	ctx context.Context, node *clusterNode, cmds []Cmder, failedCmds *cmdsMap, attempt int,
 ) {
+	atomic.AddInt32(&node.outstanding, 1)
+	defer atomic.AddInt32(&node.outstanding, -1)
+
 	_ = node.Client.withProcessPipelineHook(ctx, cmds, func(ctx context.Context, cmds []Cmder) error {
 		cn, err := node.Client.getConn(ctx)
 		if err != nil {
@@ -1302,27 +1613,27 @@ func (c *ClusterClient) processPipelineNode(
 		defer func() {
 			node.Client.releaseConn(ctx, cn, processErr)
 		}()
-		processErr = c.processPipelineNodeConn(ctx, node, cn, cmds, failedCmds)
+		processErr = c.processPipelineNodeConn(ctx, node, cn, cmds, failedCmds, attempt)
 
 		return processErr
 	})
 }
 
 func (c *ClusterClient) processPipelineNodeConn(
-	ctx context.Context, node *clusterNode, cn *pool.Conn, cmds []Cmder, failedCmds *cmdsMap,
+	ctx context.Context, node *clusterNode, cn *pool.Conn, cmds []Cmder, failedCmds *cmdsMap, attempt int,
 ) error {
 	if err := cn.WithWriter(c.context(ctx), c.opt.WriteTimeout, func(wr *proto.Writer) error {
 		return writeCmds(wr, cmds)
 	}); err != nil {
-		if shouldRetry(err, true) {
+		if c.retry(ctx).ShouldRetry(ctx, cmds, attempt, err) {
 			_ = c.mapCmdsByNode(ctx, failedCmds, cmds)
 		}
 		setCmdsErr(cmds, err)
 		return err
 	}
 
-	return cn.WithReader(c.context(ctx), c.opt.ReadTimeout, func(rd *proto.Reader) error {
-		return c.pipelineReadCmds(ctx, node, rd, cmds, failedCmds)
+	return cn.WithReader(c.context(ctx), callReadTimeout(ctx, c.opt.ReadTimeout), func(rd *proto.Reader) error {
+		return c.pipelineReadCmds(ctx, node, rd, cmds, failedCmds, attempt)
 	})
 }
 
@@ -1332,9 +1643,10 @@ func (c *ClusterClient) pipelineReadCmds(
 	rd *proto.Reader,
 	cmds []Cmder,
 	failedCmds *cmdsMap,
+	attempt int,
 ) error {
 	for i, cmd := range cmds {
-		err := cmd.readReply(rd)
+		err := node.Client.readReply(ctx, rd, cmd)
 		cmd.SetErr(err)
 
 		if err == nil {
@@ -1350,7 +1662,7 @@ func (c *ClusterClient) pipelineReadCmds(
 		}
 
 		if !isRedisError(err) {
-			if shouldRetry(err, true) {
+			if c.retry(ctx).ShouldRetry(ctx, cmds, attempt, err) {
 				_ = c.mapCmdsByNode(ctx, failedCmds, cmds)
 			}
 			setCmdsErr(cmds[i+1:], err)
@@ -1358,7 +1670,7 @@ func (c *ClusterClient) pipelineReadCmds(
 		}
 	}
 
-	if err := cmds[0].Err(); err != nil && shouldRetry(err, true) {
+	if err := cmds[0].Err(); err != nil && c.retry(ctx).ShouldRetry(ctx, cmds, attempt, err) {
 		_ = c.mapCmdsByNode(ctx, failedCmds, cmds)
 		return err
 	}
@@ -1421,7 +1733,10 @@ func (c *ClusterClient) processTxPipeline(ctx context.Context, cmds []Cmder) err
 
 	cmdsMap := c.mapCmdsBySlot(ctx, cmds)
 	for slot, cmds := range cmdsMap {
-		node, err := state.slotMasterNode(slot)
+		node, err := c.callNode(ctx)
+		if node == nil && err == nil {
+			node, err = state.slotMasterNode(slot)
+		}
 		if err != nil {
 			setCmdsErr(cmds, err)
 			continue
@@ -1430,7 +1745,7 @@ func (c *ClusterClient) processTxPipeline(ctx context.Context, cmds []Cmder) err
 		cmdsMap := map[*clusterNode][]Cmder{node: cmds}
 		for attempt := 0; attempt <= c.opt.MaxRedirects; attempt++ {
 			if attempt > 0 {
-				if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+				if err := internal.Sleep(ctx, c.retry(ctx).Backoff(attempt)); err != nil {
 					setCmdsErr(cmds, err)
 					return err
 				}
@@ -1443,7 +1758,7 @@ func (c *ClusterClient) processTxPipeline(ctx context.Context, cmds []Cmder) err
 				wg.Add(1)
 				go func(node *clusterNode, cmds []Cmder) {
 					defer wg.Done()
-					c.processTxPipelineNode(ctx, node, cmds, failedCmds)
+					c.processTxPipelineNode(ctx, node, cmds, failedCmds, attempt)
 				}(node, cmds)
 			}
 
@@ -1468,8 +1783,11 @@ func (c *ClusterClient) mapCmdsBySlot(ctx context.Context, cmds []Cmder) map[int
 }
 
 func (c *ClusterClient) processTxPipelineNode(
-	ctx context.Context, node *clusterNode, cmds []Cmder, failedCmds *cmdsMap,
+	ctx context.Context, node *clusterNode, cmds []Cmder, failedCmds *cmdsMap, attempt int,
 ) {
+	atomic.AddInt32(&node.outstanding, 1)
+	defer atomic.AddInt32(&node.outstanding, -1)
+
 	cmds = wrapMultiExec(ctx, cmds)
 	_ = node.Client.withProcessPipelineHook(ctx, cmds, func(ctx context.Context, cmds []Cmder) error {
 		cn, err := node.Client.getConn(ctx)
@@ -1483,32 +1801,32 @@ func (c *ClusterClient) processTxPipelineNode(
 		defer func() {
 			node.Client.releaseConn(ctx, cn, processErr)
 		}()
-		processErr = c.processTxPipelineNodeConn(ctx, node, cn, cmds, failedCmds)
+		processErr = c.processTxPipelineNodeConn(ctx, node, cn, cmds, failedCmds, attempt)
 
 		return processErr
 	})
 }
 
 func (c *ClusterClient) processTxPipelineNodeConn(
-	ctx context.Context, node *clusterNode, cn *pool.Conn, cmds []Cmder, failedCmds *cmdsMap,
+	ctx context.Context, node *clusterNode, cn *pool.Conn, cmds []Cmder, failedCmds *cmdsMap, attempt int,
 ) error {
 	if err := cn.WithWriter(c.context(ctx), c.opt.WriteTimeout, func(wr *proto.Writer) error {
 		return writeCmds(wr, cmds)
 	}); err != nil {
-		if shouldRetry(err, true) {
+		if c.retry(ctx).ShouldRetry(ctx, cmds, attempt, err) {
 			_ = c.mapCmdsByNode(ctx, failedCmds, cmds)
 		}
 		setCmdsErr(cmds, err)
 		return err
 	}
 
-	return cn.WithReader(c.context(ctx), c.opt.ReadTimeout, func(rd *proto.Reader) error {
+	return cn.WithReader(c.context(ctx), callReadTimeout(ctx, c.opt.ReadTimeout), func(rd *proto.Reader) error {
 		statusCmd := cmds[0].(*StatusCmd)
 		// Trim multi and exec.
 		trimmedCmds := cmds[1 : len(cmds)-1]
 
 		if err := c.txPipelineReadQueued(
-			ctx, rd, statusCmd, trimmedCmds, failedCmds,
+			ctx, node, rd, statusCmd, trimmedCmds, failedCmds,
 		); err != nil {
 			setCmdsErr(cmds, err)
 
@@ -1520,23 +1838,30 @@ func (c *ClusterClient) processTxPipelineNodeConn(
 			return err
 		}
 
-		return pipelineReadCmds(rd, trimmedCmds)
+		return node.Client.pipelineReadCmds(ctx, rd, trimmedCmds)
 	})
 }
 
 func (c *ClusterClient) txPipelineReadQueued(
 	ctx context.Context,
+	node *clusterNode,
 	rd *proto.Reader,
 	statusCmd *StatusCmd,
 	cmds []Cmder,
 	failedCmds *cmdsMap,
 ) error {
 	// Parse queued replies.
+	if err := node.Client.readPushes(ctx, rd); err != nil {
+		return err
+	}
 	if err := statusCmd.readReply(rd); err != nil {
 		return err
 	}
 
 	for _, cmd := range cmds {
+		if err := node.Client.readPushes(ctx, rd); err != nil {
+			return err
+		}
 		err := statusCmd.readReply(rd)
 		if err == nil || c.checkMovedErr(ctx, cmd, err, failedCmds) || isRedisError(err) {
 			continue
@@ -1545,18 +1870,16 @@ func (c *ClusterClient) txPipelineReadQueued(
 	}
 
 	// Parse number of replies.
-	line, err := rd.ReadLine()
-	if err != nil {
+	if err := node.Client.readPushes(ctx, rd); err != nil {
+		return err
+	}
+	if _, err := rd.ReadStrictArrayLen(); err != nil {
 		if err == Nil {
 			err = TxFailedErr
 		}
 		return err
 	}
 
-	if line[0] != proto.RespArray {
-		return fmt.Errorf("redis: expected '*', but got line %q", line)
-	}
-
 	return nil
 }
 
@@ -1602,19 +1925,23 @@ func (c *ClusterClient) Watch(ctx context.Context, fn func(*Tx) error, keys ...s
 		}
 	}
 
-	node, err := c.slotMasterNode(ctx, slot)
+	node, err := c.callNode(ctx)
+	if node == nil && err == nil {
+		node, err = c.slotMasterNode(ctx, slot)
+	}
 	if err != nil {
 		return err
 	}
 
 	for attempt := 0; attempt <= c.opt.MaxRedirects; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, c.retry(ctx).Backoff(attempt)); err != nil {
 				return err
 			}
 		}
 
-		err = node.Client.Watch(ctx, fn, keys...)
+		var cmds []Cmder
+		cmds, err = node.Client.watch(ctx, fn, keys...)
 		if err == nil {
 			break
 		}
@@ -1639,7 +1966,8 @@ func (c *ClusterClient) Watch(ctx context.Context, fn func(*Tx) error, keys ...s
 			continue
 		}
 
-		if shouldRetry(err, true) {
+		// fn runs again, so its commands must be safe to send twice.
+		if c.retry(ctx).ShouldRetry(ctx, cmds, attempt, err) {
 			continue
 		}
 
@@ -1719,10 +2047,6 @@ func (c *ClusterClient) SSubscribe(ctx context.Context, channels ...string) *Pub
 	return pubsub
 }
 
-func (c *ClusterClient) retryBackoff(attempt int) time.Duration {
-	return internal.RetryBackoff(attempt, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff)
-}
-
 func (c *ClusterClient) cmdsInfo(ctx context.Context) (map[string]*CommandInfo, error) {
 	// Try 3 random nodes.
 	const nodeLimit = 3
@@ -1796,26 +2120,65 @@ func cmdSlot(cmd Cmder, pos int) int {
 	return hashtag.Slot(firstKey)
 }
 
+// cmdNode returns the node to send the command to and whether it is
+// a read-only command that was routed by slotReadOnlyNode.
 func (c *ClusterClient) cmdNode(
 	ctx context.Context,
 	cmdName string,
 	slot int,
-) (*clusterNode, error) {
+) (*clusterNode, bool, error) {
+	if node, err := c.callNode(ctx); node != nil || err != nil {
+		return node, false, err
+	}
+
 	state, err := c.state.Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	if c.opt.ReadOnly {
+	if c.readOnly(ctx) {
 		cmdInfo := c.cmdInfo(ctx, cmdName)
 		if cmdInfo != nil && cmdInfo.ReadOnly {
-			return c.slotReadOnlyNode(state, slot)
+			node, err := c.slotReadOnlyNode(ctx, state, slot)
+			return node, true, err
 		}
 	}
-	return state.slotMasterNode(slot)
+	node, err := state.slotMasterNode(slot)
+	return node, false, err
 }
 
-func (c *ClusterClient) slotReadOnlyNode(state *clusterState, slot int) (*clusterNode, error) {
+// readOnly reports whether read-only commands may be sent to replicas.
+func (c *ClusterClient) readOnly(ctx context.Context) bool {
+	if !c.opt.ReadOnly {
+		return false
+	}
+	opt := CallOptionsFromContext(ctx)
+	return opt == nil || !opt.Master
+}
+
+// callNode returns the node the call options of ctx pin the commands to,
+// or nil when they don't.
+func (c *ClusterClient) callNode(ctx context.Context) (*clusterNode, error) {
+	opt := CallOptionsFromContext(ctx)
+	if opt == nil || opt.Node == "" {
+		return nil, nil
+	}
+	state, err := c.state.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if node := state.nodeByAddr(opt.Node); node != nil {
+		return node, nil
+	}
+	return nil, fmt.Errorf("redis: cluster node %q not found", opt.Node)
+}
+
+func (c *ClusterClient) slotReadOnlyNode(
+	ctx context.Context, state *clusterState, slot int,
+) (*clusterNode, error) {
+	if c.opt.ReadRouter != nil {
+		return state.slotRoutedNode(ctx, c.opt.ReadRouter, slot)
+	}
 	if c.opt.RouteByLatency {
 		return state.slotClosestNode(slot)
 	}
@@ -1845,7 +2208,7 @@ func (c *ClusterClient) SlaveForKey(ctx context.Context, key string) (*Client, e
 		return nil, err
 	}
 	slot := hashtag.Slot(key)
-	node, err := c.slotReadOnlyNode(state, slot)
+	node, err := c.slotReadOnlyNode(ctx, state, slot)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/redis/go-redis/v9/internal/hashtag"
+	"github.com/redis/go-redis/v9/redisfault"
 )
 
 type clusterScenario struct {
@@ -637,6 +638,198 @@ var _ = Describe("ClusterClient", func() {
 			Expect(stats).To(BeAssignableToTypeOf(&redis.PoolStats{}))
 		})
 
+		It("warms up and resizes the pools of all nodes", func() {
+			client.ResizePool(redis.PoolLimits{PoolSize: 3})
+			Expect(client.Warmup(ctx, 3)).NotTo(HaveOccurred())
+			total := client.PoolStats().TotalConns
+			Expect(total).To(BeNumerically(">=", 3*3))
+
+			client.ResizePool(redis.PoolLimits{PoolSize: 1})
+			Expect(client.PoolStats().TotalConns).To(BeNumerically("<=", total/3))
+			Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		})
+
+		It("limits the connections of all nodes with a budget", func() {
+			opt := redisClusterOptions()
+			opt.ConnBudget = &redis.ConnBudgetOptions{MaxConns: 4}
+			client := cluster.newClusterClient(ctx, opt)
+			defer client.Close()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					err := client.Set(ctx, strconv.Itoa(i), "value", 0).Err()
+					Expect(err).NotTo(HaveOccurred())
+				}(i)
+			}
+			wg.Wait()
+
+			stats := client.ConnBudgetStats()
+			Expect(stats.Conns).To(BeNumerically("<=", 4))
+			Expect(client.PoolStats().TotalConns).To(BeNumerically("<=", 4))
+		})
+
+		It("doesn't retry Watch when EXEC may have been applied", func() {
+			in := redisfault.New()
+			opt := redisClusterOptions()
+			opt.Dialer = in.Dialer
+			client := cluster.newClusterClient(ctx, opt)
+			defer client.Close()
+			Expect(client.Del(ctx, "key").Err()).NotTo(HaveOccurred())
+
+			var runs int
+			in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "exec", Times: 1})
+			err := client.Watch(ctx, func(tx *redis.Tx) error {
+				runs++
+				_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					pipe.Incr(ctx, "key")
+					return nil
+				})
+				return err
+			}, "key")
+			Expect(err).To(HaveOccurred())
+			Expect(runs).To(Equal(1))
+			Expect(client.Get(ctx, "key").Val()).To(Equal("1"))
+		})
+
+		It("hedges slow reads to another node", func() {
+			in := redisfault.New()
+			opt := redisClusterOptions()
+			opt.Dialer = in.Dialer
+			opt.ReadOnly = true
+			opt.Hedge = &redis.HedgeOptions{Delay: 20 * time.Millisecond}
+			client := cluster.newClusterClient(ctx, opt)
+			defer client.Close()
+
+			Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+			Eventually(func() string {
+				return client.Get(ctx, "key").Val()
+			}, 30*time.Second).Should(Equal("value"))
+
+			in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: time.Second, Times: 1})
+			start := time.Now()
+			Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			stats := client.HedgeStats()
+			Expect(stats.Hedges).To(BeNumerically(">=", 1))
+			Expect(stats.Wins).To(BeNumerically(">=", 1))
+		})
+
+		It("runs the node hooks with the hedged command", func() {
+			var mu sync.Mutex
+			var gets []redis.Cmder
+
+			in := redisfault.New()
+			opt := redisClusterOptions()
+			opt.Dialer = in.Dialer
+			opt.ReadOnly = true
+			opt.Hedge = &redis.HedgeOptions{Delay: 20 * time.Millisecond}
+			opt.NewClient = func(opt *redis.Options) *redis.Client {
+				node := redis.NewClient(opt)
+				node.AddHook(&hook{
+					processHook: func(hook redis.ProcessHook) redis.ProcessHook {
+						return func(ctx context.Context, cmd redis.Cmder) error {
+							if cmd.Name() == "get" {
+								mu.Lock()
+								gets = append(gets, cmd)
+								mu.Unlock()
+							}
+							return hook(ctx, cmd)
+						}
+					},
+				})
+				return node
+			}
+			client := cluster.newClusterClient(ctx, opt)
+			defer client.Close()
+
+			Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+			Eventually(func() string {
+				return client.Get(ctx, "key").Val()
+			}, 30*time.Second).Should(Equal("value"))
+
+			in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: time.Second, Times: 1})
+			mu.Lock()
+			gets = nil
+			mu.Unlock()
+			cmd := client.Get(ctx, "key")
+			Expect(cmd.Val()).To(Equal("value"))
+			Expect(client.HedgeStats().Hedges).To(BeNumerically(">=", 1))
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(gets).To(Equal([]redis.Cmder{cmd}))
+		})
+
+		It("sends commands to the node in the call options", func() {
+			var mu sync.Mutex
+			gets := make(map[string]int)
+			watches := make(map[string]int)
+
+			opt := redisClusterOptions()
+			opt.ReadOnly = true
+			opt.NewClient = func(opt *redis.Options) *redis.Client {
+				node := redis.NewClient(opt)
+				node.AddHook(&hook{
+					processHook: func(hook redis.ProcessHook) redis.ProcessHook {
+						return func(ctx context.Context, cmd redis.Cmder) error {
+							mu.Lock()
+							switch cmd.Name() {
+							case "get":
+								gets[opt.Addr]++
+							case "watch":
+								watches[opt.Addr]++
+							}
+							mu.Unlock()
+							return hook(ctx, cmd)
+						}
+					},
+				})
+				return node
+			}
+			client := cluster.newClusterClient(ctx, opt)
+			defer client.Close()
+
+			Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+			master, err := client.MasterForKey(ctx, "key")
+			Expect(err).NotTo(HaveOccurred())
+
+			callCtx := redis.WithCallOptions(ctx, &redis.CallOptions{Master: true})
+			Expect(client.Get(callCtx, "key").Val()).To(Equal("value"))
+			Expect(gets).To(Equal(map[string]int{master.Options().Addr: 1}))
+
+			var other string
+			err = client.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if addr := node.Options().Addr; addr != master.Options().Addr {
+					other = addr
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// The node replies with MOVED, which is followed.
+			callCtx = redis.WithCallOptions(ctx, &redis.CallOptions{Node: other})
+			Expect(client.Get(callCtx, "key").Val()).To(Equal("value"))
+			Expect(gets[other]).To(Equal(1))
+
+			// So does WATCH.
+			err = client.Watch(callCtx, func(tx *redis.Tx) error {
+				return tx.Get(ctx, "key").Err()
+			}, "key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(watches).To(Equal(map[string]int{other: 1, master.Options().Addr: 1}))
+
+			callCtx = redis.WithCallOptions(ctx, &redis.CallOptions{Node: "127.0.0.1:1"})
+			err = client.Get(callCtx, "key").Err()
+			Expect(err).To(MatchError(`redis: cluster node "127.0.0.1:1" not found`))
+		})
+
 		It("returns an error when there are no attempts left", func() {
 			opt := redisClusterOptions()
 			opt.MaxRedirects = -1
@@ -30,6 +30,9 @@ type Pipeliner interface {
 	// If a certain Redis command is not yet supported, you can use Do to execute it.
 	Do(ctx context.Context, args ...interface{}) *Cmd
 
+	// DoValue is like Do, but keeps the RESP types of the reply.
+	DoValue(ctx context.Context, args ...interface{}) *ValueCmd
+
 	// Process is to put the commands to be executed into the pipeline buffer.
 	Process(ctx context.Context, cmd Cmder) error
 
@@ -74,6 +77,17 @@ func (c *Pipeline) Do(ctx context.Context, args ...interface{}) *Cmd {
 	return cmd
 }
 
+// DoValue queues the custom command for later execution.
+func (c *Pipeline) DoValue(ctx context.Context, args ...interface{}) *ValueCmd {
+	cmd := NewValueCmd(ctx, args...)
+	if len(args) == 0 {
+		cmd.SetErr(errors.New("redis: please enter the command to be executed"))
+		return cmd
+	}
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
 // Process queues the cmd for later execution.
 func (c *Pipeline) Process(ctx context.Context, cmd Cmder) error {
 	c.cmds = append(c.cmds, cmd)
@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+// Push is an out-of-band RESP3 push message, e.g. a client-side caching
+// invalidation, received in between the replies of regular commands.
+type Push struct {
+	// Kind is the first element of the push message, e.g. "invalidate".
+	Kind string
+	// Payload holds the remaining elements of the push message.
+	Payload []interface{}
+}
+
+func (p *Push) String() string {
+	return fmt.Sprintf("Push<%s: %v>", p.Kind, p.Payload)
+}
+
+// pushReplyCmds lists the commands whose reply is a push message in RESP3.
+var pushReplyCmds = map[string]struct{}{
+	"subscribe":    {},
+	"ssubscribe":   {},
+	"psubscribe":   {},
+	"unsubscribe":  {},
+	"sunsubscribe": {},
+	"punsubscribe": {},
+}
+
+// readPushes reads the push messages preceding the next reply
+// and passes them to the OnPush hook. Push messages are discarded
+// when no hook is configured so they don't corrupt the replies.
+func (c *baseClient) readPushes(ctx context.Context, rd *proto.Reader) error {
+	_, err := c.readCmdPushes(ctx, rd, nil)
+	return err
+}
+
+// readCmdPushes reads the push messages preceding the reply of cmd like
+// readPushes. When the reply of cmd is a push message itself, e.g. for
+// SUBSCRIBE, it stops at the push message of the command and returns it raw.
+func (c *baseClient) readCmdPushes(ctx context.Context, rd *proto.Reader, cmd Cmder) ([]byte, error) {
+	var kind string
+	if cmd != nil {
+		if _, ok := pushReplyCmds[cmd.Name()]; ok {
+			kind = cmd.Name()
+		}
+	}
+
+	for {
+		b, err := rd.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != proto.RespPush {
+			return nil, nil
+		}
+
+		var raw []byte
+		var reply interface{}
+		if kind != "" {
+			if raw, err = rd.ReadRawReply(); err == nil {
+				reply, err = proto.NewReaderSize(bytes.NewReader(raw), len(raw)).ReadReply()
+			}
+		} else {
+			reply, err = rd.ReadReply()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if kind != "" {
+			if elems, ok := reply.([]interface{}); ok && len(elems) > 0 {
+				if s, ok := elems[0].(string); ok && strings.EqualFold(s, kind) {
+					return raw, nil
+				}
+			}
+		}
+
+		if c.opt.OnPush == nil {
+			continue
+		}
+
+		push, err := newPush(reply)
+		if err != nil {
+			return nil, err
+		}
+		c.opt.OnPush(ctx, push)
+	}
+}
+
+func newPush(reply interface{}) (*Push, error) {
+	elems, ok := reply.([]interface{})
+	if !ok || len(elems) == 0 {
+		return nil, fmt.Errorf("redis: unsupported push message: %#v", reply)
+	}
+	kind, ok := elems[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("redis: unsupported push message kind: %#v", elems[0])
+	}
+	return &Push{
+		Kind:    kind,
+		Payload: elems[1:],
+	}, nil
+}
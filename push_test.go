@@ -0,0 +1,92 @@
+package redis_test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ = Describe("RESP3 push messages", func() {
+	var client *redis.Client
+	var mu sync.Mutex
+	var pushes []*redis.Push
+
+	BeforeEach(func() {
+		pushes = nil
+
+		opt := redisOptions()
+		opt.OnPush = func(ctx context.Context, push *redis.Push) {
+			mu.Lock()
+			pushes = append(pushes, push)
+			mu.Unlock()
+		}
+		client = redis.NewClient(opt)
+		Expect(client.FlushDB(ctx).Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	kinds := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var kinds []string
+		for _, push := range pushes {
+			kinds = append(kinds, push.Kind)
+		}
+		return kinds
+	}
+
+	It("dispatches pushes received in between replies", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		Expect(conn.Process(ctx, redis.NewStatusCmd(ctx, "client", "tracking", "on"))).NotTo(HaveOccurred())
+		Expect(conn.Get(ctx, "key").Err()).To(Equal(redis.Nil))
+		Expect(client.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+
+		Eventually(func() []string {
+			Expect(conn.Ping(ctx).Val()).To(Equal("PONG"))
+			return kinds()
+		}).Should(ContainElement("invalidate"))
+	})
+
+	It("reads the replies of SUBSCRIBE sent with Do", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		cmd := redis.NewCmd(ctx, "subscribe", "ch")
+		Expect(conn.Process(ctx, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal([]interface{}{"subscribe", "ch", int64(1)}))
+
+		cmd = redis.NewCmd(ctx, "unsubscribe", "ch")
+		Expect(conn.Process(ctx, cmd)).NotTo(HaveOccurred())
+		Expect(cmd.Val()).To(Equal([]interface{}{"unsubscribe", "ch", int64(0)}))
+	})
+
+	It("dispatches pushes received in between pipelined replies", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		Expect(conn.Process(ctx, redis.NewStatusCmd(ctx, "client", "tracking", "on"))).NotTo(HaveOccurred())
+		Expect(conn.Get(ctx, "key").Err()).To(Equal(redis.Nil))
+		Expect(client.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+
+		Eventually(func() []string {
+			cmds, err := conn.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Ping(ctx)
+				pipe.Ping(ctx)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmds).To(HaveLen(2))
+			return kinds()
+		}).Should(ContainElement("invalidate"))
+	})
+})
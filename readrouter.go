@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// ReadNode is a node that can serve read-only commands, as passed to ReadRouter.
+type ReadNode struct {
+	Addr string
+	// Master reports whether the node is the master rather than a replica.
+	Master bool
+	// Failing reports whether the node failed recently, e.g. while loading.
+	Failing bool
+
+	// Latency of the node, measured when RouteByLatency is set as well.
+	// It is 0 otherwise.
+	Latency time.Duration
+	// Outstanding is the number of commands and pipelines the client
+	// is running on the node.
+	Outstanding int
+
+	// Labels are the metadata of the node. They are the networking metadata
+	// of CLUSTER SLOTS or ClusterOptions.ClusterSlots for cluster nodes and
+	// the fields of SENTINEL REPLICAS for the replicas of a failover client.
+	Labels map[string]string
+}
+
+// ReadRouter picks the node that serves read-only commands, e.g. to keep reads
+// in the availability zone of the client. See package redisroute for
+// the built-in routers.
+//
+// A ClusterClient routes the read-only commands of a slot with it, choosing
+// between the master and the replicas of the slot. A failover client with
+// ReplicaOnly routes its connections with it, choosing between the replicas.
+type ReadRouter interface {
+	// Route returns the index in nodes of the node to use. nodes isn't empty
+	// and must not be modified. An index out of range uses the first node.
+	Route(ctx context.Context, nodes []ReadNode) int
+}
+
+// routeRead returns the index of the node the router picks.
+func routeRead(ctx context.Context, router ReadRouter, nodes []ReadNode) int {
+	i := router.Route(ctx, nodes)
+	if i < 0 || i >= len(nodes) {
+		return 0
+	}
+	return i
+}
@@ -1,12 +1,13 @@
 package redis
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"net"
+	"runtime"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9/internal"
@@ -199,6 +200,13 @@ type baseClient struct {
 	opt      *Options
 	connPool pool.Pooler
 
+	cache   *clientCache
+	tracker *cacheTracker
+
+	autoPipeline *autoPipeliner
+
+	retryPolicy RetryPolicy // nil for the connections of the handshake and the cache
+
 	onClose func() error // hook called when client is closed
 }
 
@@ -214,6 +222,8 @@ func (c *baseClient) withTimeout(timeout time.Duration) *baseClient {
 
 	clone := c.clone()
 	clone.opt = opt
+	// The shared connections use the timeouts of the client.
+	clone.autoPipeline = nil
 
 	return clone
 }
@@ -262,12 +272,21 @@ func (c *baseClient) _getConn(ctx context.Context) (*pool.Conn, error) {
 		return nil, err
 	}
 
+	if cn.Inited && c.tracker != nil && !c.tracker.tracks(cn) {
+		// The invalidations of the connection went to a lost tracking connection.
+		c.connPool.Remove(ctx, cn, nil)
+		return c._getConn(ctx)
+	}
+
 	if cn.Inited {
 		return cn, nil
 	}
 
 	if err := c.initConn(ctx, cn); err != nil {
-		c.connPool.Remove(ctx, cn, err)
+		c.connPool.Remove(ctx, cn, &pool.InitError{Err: err})
+		if _, ok := err.(*HandshakeError); ok {
+			return nil, err
+		}
 		if err := errors.Unwrap(err); err != nil {
 			return nil, err
 		}
@@ -291,18 +310,48 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 	connPool := pool.NewSingleConnPool(c.connPool, cn)
 	conn := newConn(c.opt, connPool)
 
-	var auth bool
 	protocol := c.opt.Protocol
 	// By default, use RESP3 in current version.
 	if protocol < 2 {
 		protocol = 3
 	}
 
-	// for redis-server versions that do not support the HELLO command,
-	// RESP2 will continue to be used.
-	if err := conn.Hello(ctx, protocol, username, password, "").Err(); err == nil {
-		auth = true
-	} else if !isRedisError(err) {
+	var trackingID int64
+	if c.tracker != nil {
+		id, err := c.tracker.ClientID(ctx)
+		if err != nil {
+			return err
+		}
+		if !c.tracker.broadcast() {
+			trackingID = id
+		}
+	}
+	cn.TrackingID = trackingID
+
+	// The whole handshake is pipelined in a single round trip:
+	// HELLO authenticates and names the connection.
+	var hello *MapStringInterfaceCmd
+	var cmds []Cmder
+	if _, err := conn.Pipelined(ctx, func(pipe Pipeliner) error {
+		hello = pipe.Hello(ctx, protocol, username, password, c.opt.ClientName)
+		if !c.opt.DisableIndentity {
+			libName := c.opt.IdentitySuffix
+			libVer := Version()
+			pipe.ClientSetInfo(ctx, LibraryInfo{LibName: &libName})
+			pipe.ClientSetInfo(ctx, LibraryInfo{LibVer: &libVer})
+		}
+		cmds = c.initConnState(ctx, pipe, trackingID)
+		return nil
+	}); err != nil && !isRedisError(err) {
+		return err
+	}
+
+	switch err := hello.Err(); {
+	case err == nil:
+	case HasErrorPrefix(err, "WRONGPASS"):
+		// The server supports HELLO, but rejected the credentials.
+		return &HandshakeError{Command: "hello", Err: err}
+	default:
 		// When the server responds with the RESP protocol and the result is not a normal
 		// execution result of the HELLO command, we consider it to be an indication that
 		// the server does not support the HELLO command.
@@ -310,52 +359,90 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 		// or it could be DragonflyDB or a third-party redis-proxy. They all respond
 		// with different error string results for unsupported commands, making it
 		// difficult to rely on error strings to determine all results.
-		return err
-	}
-	if !c.opt.DisableIndentity {
-		libName := ""
-		libVer := Version()
-		if c.opt.IdentitySuffix != "" {
-			libName = c.opt.IdentitySuffix
-		}
-		libInfo := LibraryInfo{LibName: &libName}
-		conn.ClientSetInfo(ctx, libInfo)
-		libInfo = LibraryInfo{LibVer: &libVer}
-		conn.ClientSetInfo(ctx, libInfo)
-	}
-	_, err := conn.Pipelined(ctx, func(pipe Pipeliner) error {
-		if !auth && password != "" {
-			if username != "" {
-				pipe.AuthACL(ctx, username, password)
-			} else {
-				pipe.Auth(ctx, password)
+		// RESP2 continues to be used, and the connection is authenticated and named
+		// in a second round trip, which repeats the commands that failed without AUTH.
+		retry := handshakeErr(cmds) != nil
+		cmds = nil
+		if _, err := conn.Pipelined(ctx, func(pipe Pipeliner) error {
+			if password != "" {
+				if username != "" {
+					cmds = append(cmds, pipe.AuthACL(ctx, username, password))
+				} else {
+					cmds = append(cmds, pipe.Auth(ctx, password))
+				}
 			}
+			if c.opt.ClientName != "" {
+				cmds = append(cmds, pipe.ClientSetName(ctx, c.opt.ClientName))
+			}
+			if retry {
+				cmds = append(cmds, c.initConnState(ctx, pipe, trackingID)...)
+			}
+			return nil
+		}); err != nil && !isRedisError(err) {
+			return err
 		}
+	}
+	if err := handshakeErr(cmds); err != nil {
+		return err
+	}
 
-		if c.opt.DB > 0 {
-			pipe.Select(ctx, c.opt.DB)
-		}
+	if c.opt.OnConnect != nil {
+		return c.opt.OnConnect(ctx, conn)
+	}
+	return nil
+}
 
-		if c.opt.readOnly {
-			pipe.ReadOnly(ctx)
-		}
+// initConnState queues the commands that set the state of a new connection.
+func (c *baseClient) initConnState(ctx context.Context, pipe Pipeliner, trackingID int64) []Cmder {
+	var cmds []Cmder
+	if c.opt.DB > 0 {
+		cmds = append(cmds, pipe.Select(ctx, c.opt.DB))
+	}
 
-		if c.opt.ClientName != "" {
-			pipe.ClientSetName(ctx, c.opt.ClientName)
-		}
+	if c.opt.readOnly {
+		cmds = append(cmds, pipe.ReadOnly(ctx))
+	}
 
-		return nil
-	})
-	if err != nil {
-		return err
+	if trackingID != 0 {
+		cmd := NewStatusCmd(ctx, "client", "tracking", "on", "redirect", trackingID)
+		_ = pipe.Process(ctx, cmd)
+		cmds = append(cmds, cmd)
 	}
+	return cmds
+}
 
-	if c.opt.OnConnect != nil {
-		return c.opt.OnConnect(ctx, conn)
+// handshakeErr returns the first error of the handshake commands.
+func handshakeErr(cmds []Cmder) error {
+	for _, cmd := range cmds {
+		err := cmd.Err()
+		if err == nil {
+			continue
+		}
+		if !isRedisError(err) {
+			return err
+		}
+		name := cmd.Name()
+		if args := cmd.Args(); name == "client" && len(args) > 1 {
+			name += " " + fmt.Sprint(args[1])
+		}
+		return &HandshakeError{Command: name, Err: err}
 	}
 	return nil
 }
 
+// healthCheck checks an idle connection with PING.
+func (c *baseClient) healthCheck(ctx context.Context, cn *pool.Conn) error {
+	cmd := NewStatusCmd(ctx, "ping")
+	if err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+		return writeCmd(wr, cmd)
+	}); err != nil {
+		return err
+	}
+	return cn.WithReader(ctx, c.opt.ReadTimeout, func(rd *proto.Reader) error {
+		return c.readReply(ctx, rd, cmd)
+	})
+}
+
 func (c *baseClient) releaseConn(ctx context.Context, cn *pool.Conn, err error) {
 	if c.opt.Limiter != nil {
 		c.opt.Limiter.ReportResult(err)
@@ -391,59 +478,87 @@ func (c *baseClient) dial(ctx context.Context, network, addr string) (net.Conn,
 }
 
 func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
-	var lastErr error
-	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
-		attempt := attempt
+	if c.cache != nil {
+		if keys := cacheKeys(cmd); keys != nil {
+			if opt := CallOptionsFromContext(ctx); opt != nil && opt.SkipCache {
+				return c.processRetry(ctx, cmd)
+			}
+			return c.cache.process(ctx, cmd, keys, c.processRetry)
+		}
 
-		retry, err := c._process(ctx, cmd, attempt)
-		if err == nil || !retry {
-			return err
+		err := c.processRetry(ctx, cmd)
+		// Don't wait for the server to invalidate the keys of a write
+		// to be able to read them back from the cache.
+		if keys := cacheWriteKeys(cmd); keys != nil {
+			c.cache.forget(keys)
+		}
+		return err
+	}
+	return c.processRetry(ctx, cmd)
+}
+
+func (c *baseClient) processRetry(ctx context.Context, cmd Cmder) error {
+	policy := c.retry(ctx)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := internal.Sleep(ctx, policy.Backoff(attempt)); err != nil {
+				return err
+			}
 		}
 
-		lastErr = err
+		err := c._process(ctx, cmd)
+		if err == nil || !policy.ShouldRetry(ctx, []Cmder{cmd}, attempt, err) {
+			return err
+		}
 	}
-	return lastErr
 }
 
-func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool, error) {
-	if attempt > 0 {
-		if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
-			return false, err
+func (c *baseClient) _process(ctx context.Context, cmd Cmder) error {
+	if c.autoPipeline != nil && autoPipelined(cmd) {
+		// Auto pipelined commands share the read timeout of their batch.
+		if opt := CallOptionsFromContext(ctx); opt == nil || opt.ReadTimeout == 0 {
+			return c.autoPipeline.process(ctx, cmd)
 		}
 	}
 
-	retryTimeout := uint32(0)
-	if err := c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
+	return c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
 		if err := cn.WithWriter(c.context(ctx), c.opt.WriteTimeout, func(wr *proto.Writer) error {
 			return writeCmd(wr, cmd)
 		}); err != nil {
-			atomic.StoreUint32(&retryTimeout, 1)
 			return err
 		}
 
-		if err := cn.WithReader(c.context(ctx), c.cmdTimeout(cmd), cmd.readReply); err != nil {
-			if cmd.readTimeout() == nil {
-				atomic.StoreUint32(&retryTimeout, 1)
-			} else {
-				atomic.StoreUint32(&retryTimeout, 0)
-			}
-			return err
-		}
+		return cn.WithReader(c.context(ctx), c.cmdTimeout(ctx, cmd), func(rd *proto.Reader) error {
+			return c.readReply(ctx, rd, cmd)
+		})
+	})
+}
 
-		return nil
-	}); err != nil {
-		retry := shouldRetry(err, atomic.LoadUint32(&retryTimeout) == 1)
-		return retry, err
+func (c *baseClient) retry(ctx context.Context) RetryPolicy {
+	if c.retryPolicy == nil {
+		return callRetryPolicy(ctx, c.newRetryPolicy(nil))
 	}
+	return callRetryPolicy(ctx, c.retryPolicy)
+}
 
-	return false, nil
+func (c *baseClient) initRetryPolicy() {
+	cmdsInfo := newCmdsInfoCache(func(ctx context.Context) (map[string]*CommandInfo, error) {
+		cmd := NewCommandsInfoCmd(ctx, "command")
+		_ = c.process(ctx, cmd)
+		return cmd.Result()
+	})
+	c.retryPolicy = c.newRetryPolicy(cmdsInfo.Get)
 }
 
-func (c *baseClient) retryBackoff(attempt int) time.Duration {
-	return internal.RetryBackoff(attempt, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff)
+func (c *baseClient) newRetryPolicy(
+	cmdsInfo func(ctx context.Context) (map[string]*CommandInfo, error),
+) RetryPolicy {
+	return newRetryPolicy(
+		c.opt.RetryPolicy, c.opt.MaxRetries, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff, cmdsInfo,
+	)
 }
 
-func (c *baseClient) cmdTimeout(cmd Cmder) time.Duration {
+func (c *baseClient) cmdTimeout(ctx context.Context, cmd Cmder) time.Duration {
 	if timeout := cmd.readTimeout(); timeout != nil {
 		t := *timeout
 		if t == 0 {
@@ -451,7 +566,7 @@ func (c *baseClient) cmdTimeout(cmd Cmder) time.Duration {
 		}
 		return t + 10*time.Second
 	}
-	return c.opt.ReadTimeout
+	return callReadTimeout(ctx, c.opt.ReadTimeout)
 }
 
 // Close closes the client, releasing any open resources.
@@ -494,10 +609,10 @@ type pipelineProcessor func(context.Context, *pool.Conn, []Cmder) (bool, error)
 func (c *baseClient) generalProcessPipeline(
 	ctx context.Context, cmds []Cmder, p pipelineProcessor,
 ) error {
-	var lastErr error
-	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
+	policy := c.retry(ctx)
+	for attempt := 0; ; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, policy.Backoff(attempt)); err != nil {
 				setCmdsErr(cmds, err)
 				return err
 			}
@@ -505,16 +620,15 @@ func (c *baseClient) generalProcessPipeline(
 
 		// Enable retries by default to retry dial errors returned by withConn.
 		canRetry := true
-		lastErr = c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
+		err := c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
 			var err error
 			canRetry, err = p(ctx, cn, cmds)
 			return err
 		})
-		if lastErr == nil || !canRetry || !shouldRetry(lastErr, true) {
-			return lastErr
+		if err == nil || !canRetry || !policy.ShouldRetry(ctx, cmds, attempt, err) {
+			return err
 		}
 	}
-	return lastErr
 }
 
 func (c *baseClient) pipelineProcessCmds(
@@ -527,8 +641,8 @@ func (c *baseClient) pipelineProcessCmds(
 		return true, err
 	}
 
-	if err := cn.WithReader(c.context(ctx), c.opt.ReadTimeout, func(rd *proto.Reader) error {
-		return pipelineReadCmds(rd, cmds)
+	if err := cn.WithReader(c.context(ctx), callReadTimeout(ctx, c.opt.ReadTimeout), func(rd *proto.Reader) error {
+		return c.pipelineReadCmds(ctx, rd, cmds)
 	}); err != nil {
 		return true, err
 	}
@@ -536,9 +650,9 @@ func (c *baseClient) pipelineProcessCmds(
 	return false, nil
 }
 
-func pipelineReadCmds(rd *proto.Reader, cmds []Cmder) error {
+func (c *baseClient) pipelineReadCmds(ctx context.Context, rd *proto.Reader, cmds []Cmder) error {
 	for i, cmd := range cmds {
-		err := cmd.readReply(rd)
+		err := c.readReply(ctx, rd, cmd)
 		cmd.SetErr(err)
 		if err != nil && !isRedisError(err) {
 			setCmdsErr(cmds[i+1:], err)
@@ -559,17 +673,17 @@ func (c *baseClient) txPipelineProcessCmds(
 		return true, err
 	}
 
-	if err := cn.WithReader(c.context(ctx), c.opt.ReadTimeout, func(rd *proto.Reader) error {
+	if err := cn.WithReader(c.context(ctx), callReadTimeout(ctx, c.opt.ReadTimeout), func(rd *proto.Reader) error {
 		statusCmd := cmds[0].(*StatusCmd)
 		// Trim multi and exec.
 		trimmedCmds := cmds[1 : len(cmds)-1]
 
-		if err := txPipelineReadQueued(rd, statusCmd, trimmedCmds); err != nil {
+		if err := c.txPipelineReadQueued(ctx, rd, statusCmd, trimmedCmds); err != nil {
 			setCmdsErr(cmds, err)
 			return err
 		}
 
-		return pipelineReadCmds(rd, trimmedCmds)
+		return c.pipelineReadCmds(ctx, rd, trimmedCmds)
 	}); err != nil {
 		return false, err
 	}
@@ -577,32 +691,71 @@ func (c *baseClient) txPipelineProcessCmds(
 	return false, nil
 }
 
-func txPipelineReadQueued(rd *proto.Reader, statusCmd *StatusCmd, cmds []Cmder) error {
+// readReply reads the reply of the cmd along with the push messages
+// and the attributes that precede it.
+func (c *baseClient) readReply(ctx context.Context, rd *proto.Reader, cmd Cmder) error {
+	var attrs []proto.ValuePair
+	for {
+		raw, err := c.readCmdPushes(ctx, rd, cmd)
+		if err != nil {
+			return err
+		}
+		if raw != nil {
+			cmd.setAttributes(attrs)
+			return cmd.readReply(proto.NewReaderSize(bytes.NewReader(raw), len(raw)))
+		}
+
+		b, err := rd.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] != proto.RespAttr {
+			break
+		}
+
+		pairs, err := rd.ReadAttributes()
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, pairs...)
+	}
+
+	cmd.setAttributes(attrs)
+	return cmd.readReply(rd)
+}
+
+func (c *baseClient) txPipelineReadQueued(
+	ctx context.Context, rd *proto.Reader, statusCmd *StatusCmd, cmds []Cmder,
+) error {
 	// Parse +OK.
+	if err := c.readPushes(ctx, rd); err != nil {
+		return err
+	}
 	if err := statusCmd.readReply(rd); err != nil {
 		return err
 	}
 
 	// Parse +QUEUED.
 	for range cmds {
+		if err := c.readPushes(ctx, rd); err != nil {
+			return err
+		}
 		if err := statusCmd.readReply(rd); err != nil && !isRedisError(err) {
 			return err
 		}
 	}
 
 	// Parse number of replies.
-	line, err := rd.ReadLine()
-	if err != nil {
+	if err := c.readPushes(ctx, rd); err != nil {
+		return err
+	}
+	if _, err := rd.ReadStrictArrayLen(); err != nil {
 		if err == Nil {
 			err = TxFailedErr
 		}
 		return err
 	}
 
-	if line[0] != proto.RespArray {
-		return fmt.Errorf("redis: expected '*', but got line %q", line)
-	}
-
 	return nil
 }
 
@@ -636,11 +789,38 @@ func NewClient(opt *Options) *Client {
 		},
 	}
 	c.init()
-	c.connPool = newConnPool(opt, c.dialHook)
+	c.initRetryPolicy()
+	connPool := newConnPool(opt, c.dialHook, c.healthCheck)
+	c.connPool = connPool
+
+	if opt.ClientSideCache != nil {
+		c.cache = newClientCache(opt.ClientSideCache)
+		c.tracker = newCacheTracker(opt, connPool, c.cache)
+		c.onClose = c.tracker.Close
+	}
+
+	if opt.AutoPipeline != nil {
+		c.autoPipeline = newAutoPipeliner(c.baseClient, opt.AutoPipeline)
+		c.onClose = chainOnClose(c.autoPipeline.Close, c.onClose)
+	}
 
 	return &c
 }
 
+// chainOnClose returns an onClose hook that calls first and then next.
+func chainOnClose(first, next func() error) func() error {
+	if next == nil {
+		return first
+	}
+	return func() error {
+		err := first()
+		if nextErr := next(); err == nil {
+			err = nextErr
+		}
+		return err
+	}
+}
+
 func (c *Client) init() {
 	c.cmdable = c.Process
 	c.initHooks(hooks{
@@ -658,8 +838,21 @@ func (c *Client) WithTimeout(timeout time.Duration) *Client {
 	return &clone
 }
 
+// WithoutCache returns a client that shares the connection pool with c,
+// but always sends commands to the server bypassing the client-side cache.
+func (c *Client) WithoutCache() *Client {
+	clone := *c
+	clone.baseClient = c.baseClient.clone()
+	clone.cache = nil
+	clone.init()
+	return &clone
+}
+
 func (c *Client) Conn() *Conn {
-	return newConn(c.opt, pool.NewStickyConnPool(c.connPool))
+	conn := newConn(c.opt, pool.NewStickyConnPool(c.connPool))
+	conn.tracker = c.tracker
+	conn.retryPolicy = c.retryPolicy
+	return conn
 }
 
 // Do create a Cmd from the args and processes the cmd.
@@ -669,6 +862,13 @@ func (c *Client) Do(ctx context.Context, args ...interface{}) *Cmd {
 	return cmd
 }
 
+// DoValue creates a ValueCmd from the args and processes the cmd.
+func (c *Client) DoValue(ctx context.Context, args ...interface{}) *ValueCmd {
+	cmd := NewValueCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
 func (c *Client) Process(ctx context.Context, cmd Cmder) error {
 	err := c.processHook(ctx, cmd)
 	cmd.SetErr(err)
@@ -688,6 +888,120 @@ func (c *Client) PoolStats() *PoolStats {
 	return (*PoolStats)(stats)
 }
 
+// add accumulates the stats s of another pool.
+func (acc *PoolStats) add(s *pool.Stats) {
+	acc.Hits += s.Hits
+	acc.Misses += s.Misses
+	acc.Timeouts += s.Timeouts
+
+	acc.WaitCount += s.WaitCount
+	acc.WaitDuration += s.WaitDuration
+
+	acc.TotalConns += s.TotalConns
+	acc.IdleConns += s.IdleConns
+	acc.StaleConns += s.StaleConns
+	acc.InUseConns += s.InUseConns
+
+	acc.DialCount += s.DialCount
+	acc.DialErrors += s.DialErrors
+	acc.DialDuration += s.DialDuration
+
+	acc.IdleClosed += s.IdleClosed
+	acc.LifetimeClosed += s.LifetimeClosed
+	acc.BadConnClosed += s.BadConnClosed
+	acc.InitFailedClosed += s.InitFailedClosed
+
+	acc.MaintenanceRuns += s.MaintenanceRuns
+	acc.HealthChecks += s.HealthChecks
+	acc.HealthCheckErrors += s.HealthCheckErrors
+}
+
+// PoolLimits are the limits of a connection pool that can change at runtime.
+// The fields have the meaning of the Options with the same names.
+// PoolSize is capped at MaxActiveConns and MinIdleConns at PoolSize.
+type PoolLimits struct {
+	PoolSize       int
+	MinIdleConns   int
+	MaxActiveConns int
+}
+
+func (limits *PoolLimits) init() {
+	if limits.PoolSize <= 0 {
+		limits.PoolSize = 10 * runtime.GOMAXPROCS(0)
+	}
+	if limits.MaxActiveConns < 0 {
+		limits.MaxActiveConns = 0
+	}
+	if limits.MaxActiveConns > 0 && limits.PoolSize > limits.MaxActiveConns {
+		limits.PoolSize = limits.MaxActiveConns
+	}
+	if limits.MinIdleConns < 0 {
+		limits.MinIdleConns = 0
+	}
+	if limits.MinIdleConns > limits.PoolSize {
+		limits.MinIdleConns = limits.PoolSize
+	}
+}
+
+// ResizePool changes the limits of the connection pool without closing
+// the connections in use. When the pool shrinks, the idle connections above
+// the new size are closed right away and the connections in use when the
+// commands using them finish. Options still reports the initial limits.
+func (c *Client) ResizePool(limits PoolLimits) {
+	limits.init()
+	if connPool, ok := c.connPool.(*pool.ConnPool); ok {
+		connPool.Resize(limits.PoolSize, limits.MinIdleConns, limits.MaxActiveConns)
+	}
+}
+
+// Warmup dials and initializes up to n connections at once, so that the
+// first commands don't pay for it, e.g. before an instance reports ready.
+// n is capped at the pool size. The connections are put back into the pool
+// as idle connections, where MaxIdleConns and ConnMaxIdleTime apply to them.
+func (c *Client) Warmup(ctx context.Context, n int) error {
+	connPool, ok := c.connPool.(*pool.ConnPool)
+	if !ok {
+		return nil
+	}
+	if size := connPool.Size(); n > size {
+		n = size
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	cns := make([]*pool.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cns[i], errs[i] = c._getConn(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i, cn := range cns {
+		if cn != nil {
+			connPool.Put(ctx, cn)
+		} else if firstErr == nil {
+			firstErr = errs[i]
+		}
+	}
+	return firstErr
+}
+
+// CacheStats returns client-side cache stats.
+// It returns zero stats when ClientSideCache is not enabled.
+func (c *Client) CacheStats() *CacheStats {
+	if c.cache == nil {
+		return &CacheStats{}
+	}
+	return c.cache.Stats()
+}
+
 func (c *Client) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
 	return c.Pipeline().Pipelined(ctx, fn)
 }
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	. "github.com/bsm/gomega"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redisfault"
 )
 
 type redisHookError struct{}
@@ -97,6 +101,55 @@ var _ = Describe("Client", func() {
 		Expect(val).To(Equal("PONG"))
 	})
 
+	It("should DoValue", func() {
+		Expect(client.HSet(ctx, "hash", "b", "2", "a", "1").Err()).NotTo(HaveOccurred())
+		Expect(client.SAdd(ctx, "set", "member").Err()).NotTo(HaveOccurred())
+
+		val, err := client.DoValue(ctx, "hgetall", "hash").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(val.Type).To(Equal(byte('%')))
+		m, err := val.AsMap()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(HaveLen(2))
+		Expect(m["a"].AsInt64()).To(Equal(int64(1)))
+
+		val, err = client.DoValue(ctx, "smembers", "set").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(val.Type).To(Equal(byte('~')))
+		Expect(val.Elems).To(HaveLen(1))
+		Expect(val.Elems[0].AsString()).To(Equal("member"))
+
+		val, err = client.DoValue(ctx, "get", "missing").Result()
+		Expect(err).To(Equal(redis.Nil))
+		Expect(val.IsNil()).To(BeTrue())
+
+		err = client.DoValue(ctx, "incr", "hash").Err()
+		Expect(err).To(MatchError(ContainSubstring("WRONGTYPE")))
+	})
+
+	It("should Decode", func() {
+		type hash struct {
+			A int64   `redis:"a"`
+			B *string `redis:"b"`
+			C *string `redis:"c"`
+		}
+
+		Expect(client.HSet(ctx, "hash", "b", "2", "a", "1").Err()).NotTo(HaveOccurred())
+
+		var h hash
+		Expect(client.Do(ctx, "hgetall", "hash").Decode(&h)).NotTo(HaveOccurred())
+		Expect(h.A).To(Equal(int64(1)))
+		Expect(*h.B).To(Equal("2"))
+		Expect(h.C).To(BeNil())
+
+		m, err := redis.ScanInto[map[string]int](client.DoValue(ctx, "hgetall", "hash"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(Equal(map[string]int{"a": 1, "b": 2}))
+
+		_, err = redis.ScanInto[hash](client.Do(ctx, "get", "missing"))
+		Expect(err).To(Equal(redis.Nil))
+	})
+
 	It("should return pool stats", func() {
 		Expect(client.PoolStats()).To(BeAssignableToTypeOf(&redis.PoolStats{}))
 	})
@@ -469,6 +522,382 @@ var _ = Describe("Client OnConnect", func() {
 	})
 })
 
+var _ = Describe("Client handshake", func() {
+	var writes int32
+	var noHello bool
+	var opt *redis.Options
+
+	BeforeEach(func() {
+		writes, noHello = 0, false
+		opt = redisOptions()
+		opt.ClientName = "handshake"
+		opt.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &handshakeConn{Conn: conn, writes: &writes, noHello: noHello}, nil
+		}
+	})
+
+	It("initializes connections in a single round trip", func() {
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&writes)).To(Equal(int32(2)))
+		Expect(client.ClientGetName(ctx).Val()).To(Equal("handshake"))
+	})
+
+	It("falls back for servers without HELLO", func() {
+		noHello = true
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		Expect(client.ClientGetName(ctx).Val()).To(Equal("handshake"))
+		Expect(client.Do(ctx, "client", "info").Text()).To(ContainSubstring("resp=2"))
+	})
+
+	It("reports the failed command", func() {
+		opt.DB = 100000
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		err := client.Ping(ctx).Err()
+		var herr *redis.HandshakeError
+		Expect(errors.As(err, &herr)).To(BeTrue())
+		Expect(herr.Command).To(Equal("select"))
+		Expect(err).To(MatchError(
+			"redis: SELECT failed during the connection handshake: ERR DB index is out of range"))
+	})
+})
+
+var _ = Describe("Client pool maintenance", func() {
+	var client *redis.Client
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.MinIdleConns = 2
+		opt.IdleCheckFrequency = 10 * time.Millisecond
+		opt.HealthCheckInterval = time.Nanosecond
+		client = redis.NewClient(opt)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("checks idle connections with PING", func() {
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+
+		Eventually(func() uint32 {
+			return client.PoolStats().HealthChecks
+		}).Should(BeNumerically(">=", 2))
+
+		stats := client.PoolStats()
+		Expect(stats.MaintenanceRuns).To(BeNumerically(">", 0))
+		Expect(stats.HealthCheckErrors).To(Equal(uint32(0)))
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Client pool resizing", func() {
+	var client *redis.Client
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.PoolSize = 4
+		client = redis.NewClient(opt)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("warms up connections", func() {
+		Expect(client.Warmup(ctx, 10)).NotTo(HaveOccurred())
+
+		stats := client.PoolStats()
+		Expect(stats.TotalConns).To(Equal(uint32(4)))
+		Expect(stats.IdleConns).To(Equal(uint32(4)))
+
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		Expect(client.PoolStats().Misses).To(Equal(uint32(4)))
+	})
+
+	It("resizes the pool", func() {
+		Expect(client.Warmup(ctx, 4)).NotTo(HaveOccurred())
+
+		client.ResizePool(redis.PoolLimits{PoolSize: 2})
+		Expect(client.PoolStats().TotalConns).To(Equal(uint32(2)))
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+
+		client.ResizePool(redis.PoolLimits{PoolSize: 8, MinIdleConns: 6})
+		Eventually(func() uint32 {
+			return client.PoolStats().IdleConns
+		}).Should(Equal(uint32(6)))
+	})
+
+	It("caps the limits", func() {
+		Expect(client.Warmup(ctx, -1)).NotTo(HaveOccurred())
+		Expect(client.PoolStats().TotalConns).To(Equal(uint32(0)))
+
+		client.ResizePool(redis.PoolLimits{PoolSize: 8, MinIdleConns: 10, MaxActiveConns: 3})
+		Eventually(func() uint32 {
+			return client.PoolStats().IdleConns
+		}).Should(Equal(uint32(3)))
+		Consistently(func() uint32 {
+			return client.PoolStats().TotalConns
+		}, 100*time.Millisecond).Should(Equal(uint32(3)))
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Client retry policy", func() {
+	var client *redis.Client
+	var in *redisfault.Injector
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.MaxRetries = 1
+		client = redis.NewClient(opt)
+		Expect(client.FlushDB(ctx).Err()).NotTo(HaveOccurred())
+
+		in = redisfault.New()
+		client.AddHook(in)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("retries read-only commands that may have been applied", func() {
+		Expect(client.Set(ctx, "key", "1", 0).Err()).NotTo(HaveOccurred())
+
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "get", Times: 1})
+		Expect(client.Get(ctx, "key").Val()).To(Equal("1"))
+	})
+
+	It("doesn't retry writes that may have been applied", func() {
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "incr", Times: 1})
+		Expect(client.Incr(ctx, "key").Err()).To(HaveOccurred())
+
+		// The server applied INCR once.
+		Expect(client.Get(ctx, "key").Val()).To(Equal("1"))
+	})
+
+	It("retries SET unless its reply depends on the first attempt", func() {
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "set", Times: 1})
+		Expect(client.Set(ctx, "key", "1", 0).Err()).NotTo(HaveOccurred())
+
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "set", Times: 1})
+		Expect(client.SetArgs(ctx, "other", "1", redis.SetArgs{Mode: "NX"}).Err()).To(HaveOccurred())
+		Expect(client.Get(ctx, "other").Val()).To(Equal("1"))
+
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "del", Times: 1})
+		Expect(client.Del(ctx, "key").Err()).To(HaveOccurred())
+		Expect(client.Exists(ctx, "key").Val()).To(Equal(int64(0)))
+	})
+
+	It("doesn't retry with NoRetry in the call options", func() {
+		Expect(client.Set(ctx, "key", "1", 0).Err()).NotTo(HaveOccurred())
+
+		callCtx := redis.WithCallOptions(ctx, &redis.CallOptions{NoRetry: true})
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "get", Times: 1})
+		Expect(client.Get(callCtx, "key").Err()).To(HaveOccurred())
+		Expect(client.Get(callCtx, "key").Val()).To(Equal("1"))
+	})
+
+	It("uses the read timeout of the call options", func() {
+		callCtx := redis.WithCallOptions(ctx, &redis.CallOptions{
+			NoRetry:     true,
+			ReadTimeout: 50 * time.Millisecond,
+		})
+		in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: time.Second, Times: 1})
+		err := client.Get(callCtx, "key").Err()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(net.Error).Timeout()).To(BeTrue())
+
+		in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: 100 * time.Millisecond, Times: 1})
+		callCtx = redis.WithCallOptions(ctx, &redis.CallOptions{ReadTimeout: -1})
+		Expect(client.Get(callCtx, "key").Err()).To(Equal(redis.Nil))
+	})
+
+	It("asks the custom policy", func() {
+		policy := new(retryOncePolicy)
+		opt := redisOptions()
+		opt.RetryPolicy = policy
+		client := redis.NewClient(opt)
+		defer client.Close()
+		client.AddHook(in)
+
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "incr", Times: 1})
+		Expect(client.Incr(ctx, "key").Val()).To(Equal(int64(2)))
+		Expect(policy.cmds).To(Equal([]string{"incr"}))
+	})
+})
+
+// retryOncePolicy retries every command once without a backoff.
+type retryOncePolicy struct {
+	cmds []string
+}
+
+func (p *retryOncePolicy) ShouldRetry(ctx context.Context, cmds []redis.Cmder, attempt int, err error) bool {
+	for _, cmd := range cmds {
+		p.cmds = append(p.cmds, cmd.Name())
+	}
+	return attempt == 0
+}
+
+func (p *retryOncePolicy) Backoff(retry int) time.Duration {
+	return 0
+}
+
+// handshakeConn counts the writes of a connection and can make
+// the server reject HELLO like servers without RESP3 support do.
+type handshakeConn struct {
+	net.Conn
+	writes  *int32
+	noHello bool
+}
+
+func (c *handshakeConn) Write(b []byte) (int, error) {
+	atomic.AddInt32(c.writes, 1)
+	if c.noHello {
+		b = bytes.Replace(b, []byte("\r\nhello\r\n"), []byte("\r\nhellx\r\n"), 1)
+	}
+	return c.Conn.Write(b)
+}
+
+var _ = Describe("Client reply limits", func() {
+	var client *redis.Client
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.MaxBulkLen = 1000
+		opt.MaxAggregateLen = 100
+		client = redis.NewClient(opt)
+		Expect(client.FlushDB(ctx).Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("rejects replies exceeding the limits and closes the connection", func() {
+		Expect(client.Set(ctx, "key", strings.Repeat("x", 1001), 0).Err()).NotTo(HaveOccurred())
+		for i := 0; i < 101; i++ {
+			Expect(client.RPush(ctx, "list", i).Err()).NotTo(HaveOccurred())
+		}
+
+		var protoErr *redis.ProtocolError
+		err := client.Get(ctx, "key").Err()
+		Expect(errors.As(err, &protoErr)).To(BeTrue())
+
+		err = client.LRange(ctx, "list", 0, -1).Err()
+		Expect(errors.As(err, &protoErr)).To(BeTrue())
+
+		Expect(client.LRange(ctx, "list", 0, 1).Val()).To(Equal([]string{"0", "1"}))
+		Expect(client.PoolStats().TotalConns).To(Equal(uint32(1)))
+	})
+})
+
+var _ = Describe("Client auto pipelining", func() {
+	var client *redis.Client
+
+	BeforeEach(func() {
+		opt := redisOptions()
+		opt.AutoPipeline = &redis.AutoPipelineOptions{Conns: 2}
+		client = redis.NewClient(opt)
+		Expect(client.FlushDB(ctx).Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("shares connections between goroutines", func() {
+		const n = 1000
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				key := fmt.Sprintf("key%d", i)
+				Expect(client.Set(ctx, key, i, 0).Err()).NotTo(HaveOccurred())
+				Expect(client.Get(ctx, key).Val()).To(Equal(fmt.Sprint(i)))
+				Expect(client.Get(ctx, "missing").Err()).To(Equal(redis.Nil))
+			}(i)
+		}
+		wg.Wait()
+
+		Expect(client.DBSize(ctx).Val()).To(Equal(int64(n)))
+		Expect(client.PoolStats().TotalConns).To(BeNumerically("<=", 2))
+	})
+
+	It("uses dedicated connections for blocking commands and transactions", func() {
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			Expect(client.RPush(ctx, "list", "value").Err()).NotTo(HaveOccurred())
+		}()
+		Expect(client.BLPop(ctx, time.Second, "list").Val()).To(Equal([]string{"list", "value"}))
+
+		cmds, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "counter")
+			pipe.Incr(ctx, "counter")
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmds[1].(*redis.IntCmd).Val()).To(Equal(int64(2)))
+	})
+
+	It("uses dedicated connections for blocking commands sent with Do", func() {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			val, err := client.Do(ctx, "blpop", "list", 1).Result()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(val).To(Equal([]interface{}{"list", "value"}))
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		start := time.Now()
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+
+		Expect(client.RPush(ctx, "list", "value").Err()).NotTo(HaveOccurred())
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("uses the timeouts of WithTimeout", func() {
+		in := redisfault.New()
+		opt := redisOptions()
+		opt.Dialer = in.Dialer
+		opt.MaxRetries = -1
+		opt.ReadTimeout = 50 * time.Millisecond
+		opt.AutoPipeline = &redis.AutoPipelineOptions{}
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: 200 * time.Millisecond, Times: 1})
+		Expect(client.WithTimeout(time.Second).Get(ctx, "key").Err()).To(Equal(redis.Nil))
+	})
+
+	It("returns when the context is canceled", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(client.Ping(ctx).Err()).To(Equal(context.Canceled))
+		Expect(client.Ping(context.Background()).Err()).NotTo(HaveOccurred())
+	})
+})
+
 var _ = Describe("Client context cancelation", func() {
 	var opt *redis.Options
 	var client *redis.Client
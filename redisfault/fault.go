@@ -0,0 +1,344 @@
+// Package redisfault injects network faults into the connections of clients
+// to test how applications handle them.
+//
+// An Injector dials connections, either as redis.Options.Dialer or as
+// a redis.Hook wrapping the dialer of a client, and applies the faults of
+// the rules that match the address of a connection and the commands it
+// sends:
+//
+//	in := redisfault.New()
+//	rdb := redis.NewClient(&redis.Options{Addr: addr, Dialer: in.Dialer})
+//
+//	// The next two replies to GET take a second.
+//	in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: time.Second, Times: 2})
+//	// Connections to addr fail from now on.
+//	in.Add(redisfault.Rule{Fault: redisfault.DialError, Addr: addr})
+package redisfault
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+// Fault is a kind of network failure.
+type Fault int
+
+const (
+	// DialError makes dialing fail with Rule.Err.
+	DialError Fault = iota + 1
+	// Latency delays the reply to a command by Rule.Delay.
+	Latency
+	// DropReply closes the connection after a part of the reply was read.
+	DropReply
+	// TruncateWrite writes a part of a command and closes the connection.
+	TruncateWrite
+	// StallRead blocks reading the reply until the read deadline
+	// expires or the connection is closed.
+	StallRead
+)
+
+// Rule selects the connections and commands that a fault applies to.
+type Rule struct {
+	Fault Fault
+
+	// Addr is the address of the connections or empty for all of them.
+	Addr string
+	// Command is the lower case name of the commands, e.g. "get", or empty
+	// for all of them. It doesn't apply to DialError. Pipelines match
+	// when one of their commands does.
+	Command string
+
+	// Delay is the latency that Latency adds.
+	Delay time.Duration
+	// Err is the error of DialError. It defaults to a refused connection.
+	Err error
+
+	// Times is how often the fault applies before the rule is removed.
+	// Zero applies it until Reset.
+	Times int
+}
+
+// Injector dials connections with faults. It is safe for concurrent use.
+type Injector struct {
+	mu    sync.Mutex
+	rules []*Rule
+}
+
+var _ redis.Hook = (*Injector)(nil)
+
+// New returns an injector without rules.
+func New() *Injector {
+	return new(Injector)
+}
+
+// Add adds a rule.
+func (in *Injector) Add(rule Rule) {
+	in.mu.Lock()
+	in.rules = append(in.rules, &rule)
+	in.mu.Unlock()
+}
+
+// Reset removes all rules.
+func (in *Injector) Reset() {
+	in.mu.Lock()
+	in.rules = nil
+	in.mu.Unlock()
+}
+
+// match returns the faults of the rules that match, removing
+// the rules that have been applied often enough.
+func (in *Injector) match(addr string, cmds []string, faults ...Fault) []*Rule {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var matched []*Rule
+	rules := in.rules[:0]
+	for _, rule := range in.rules {
+		if rule.matches(addr, cmds, faults) {
+			matched = append(matched, rule)
+			if rule.Times > 0 {
+				rule.Times--
+				if rule.Times == 0 {
+					continue
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	for i := len(rules); i < len(in.rules); i++ {
+		in.rules[i] = nil
+	}
+	in.rules = rules
+	return matched
+}
+
+func (r *Rule) matches(addr string, cmds []string, faults []Fault) bool {
+	if r.Addr != "" && r.Addr != addr {
+		return false
+	}
+	var ok bool
+	for _, fault := range faults {
+		if r.Fault == fault {
+			ok = true
+		}
+	}
+	if !ok {
+		return false
+	}
+	if r.Command == "" || r.Fault == DialError {
+		return true
+	}
+	for _, cmd := range cmds {
+		if cmd == r.Command {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// Dialer dials a TCP connection with faults. It can be used as
+// redis.Options.Dialer.
+func (in *Injector) Dialer(ctx context.Context, network, addr string) (net.Conn, error) {
+	netDialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 5 * time.Minute,
+	}
+	return in.dial(ctx, network, addr, netDialer.DialContext)
+}
+
+func (in *Injector) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return in.dial(ctx, network, addr, next)
+	}
+}
+
+func (in *Injector) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (in *Injector) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (in *Injector) dial(
+	ctx context.Context, network, addr string, dial func(context.Context, string, string) (net.Conn, error),
+) (net.Conn, error) {
+	if rules := in.match(addr, nil, DialError); len(rules) > 0 {
+		err := rules[0].Err
+		if err == nil {
+			err = syscall.ECONNREFUSED
+		}
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+
+	conn, err := dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &faultConn{
+		Conn:   conn,
+		in:     in,
+		addr:   addr,
+		closed: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+type faultConn struct {
+	net.Conn
+	in   *Injector
+	addr string
+
+	mu        sync.Mutex
+	deadline  time.Time
+	delay     time.Duration // of the next read
+	drop      bool          // after the next read
+	stall     bool
+	dropped   bool
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (c *faultConn) Write(b []byte) (int, error) {
+	cmds := commandNames(b)
+	rules := c.in.match(c.addr, cmds, Latency, DropReply, TruncateWrite, StallRead)
+
+	c.mu.Lock()
+	dropped := c.dropped
+	for _, rule := range rules {
+		switch rule.Fault {
+		case Latency:
+			c.delay += rule.Delay
+		case DropReply:
+			c.drop = true
+		case StallRead:
+			c.stall = true
+		case TruncateWrite:
+			dropped = true
+		}
+	}
+	c.mu.Unlock()
+
+	if dropped {
+		n, _ := c.Conn.Write(b[:len(b)/2])
+		c.dropConn()
+		return n, &net.OpError{Op: "write", Net: "tcp", Err: syscall.ECONNRESET}
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *faultConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	delay, drop, stall, dropped, deadline := c.delay, c.drop, c.stall, c.dropped, c.deadline
+	c.delay, c.drop, c.stall = 0, false, false
+	c.mu.Unlock()
+
+	if dropped {
+		return 0, io.EOF
+	}
+	if stall {
+		delay = -1
+	}
+	if delay != 0 {
+		if err := c.sleep(delay, deadline); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := c.Conn.Read(b)
+	if drop && err == nil {
+		c.dropConn()
+		if n /= 2; n == 0 {
+			return 0, io.EOF
+		}
+	}
+	return n, err
+}
+
+// sleep waits for d or until the connection is closed. A negative d
+// waits for the deadline, which returns a timeout error like reads do.
+func (c *faultConn) sleep(d time.Duration, deadline time.Time) error {
+	var wait <-chan time.Time
+	if d >= 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		wait = timer.C
+	}
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-wait:
+		return nil
+	case <-expired:
+		return &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	case <-c.closed:
+		return net.ErrClosed
+	}
+}
+
+func (c *faultConn) dropConn() {
+	c.mu.Lock()
+	c.dropped = true
+	c.mu.Unlock()
+	_ = c.Conn.Close()
+}
+
+func (c *faultConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
+
+func (c *faultConn) SetDeadline(t time.Time) error {
+	c.setReadDeadline(t)
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *faultConn) SetReadDeadline(t time.Time) error {
+	c.setReadDeadline(t)
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *faultConn) setReadDeadline(t time.Time) {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+}
+
+// commandNames returns the lower case names of the commands in b.
+func commandNames(b []byte) []string {
+	var names []string
+	rd := proto.NewReader(bytes.NewReader(b))
+	for {
+		args, err := rd.ReadReply()
+		if err != nil {
+			// io.EOF or a command that continues in the next write.
+			return names
+		}
+		if args, ok := args.([]interface{}); ok && len(args) > 0 {
+			if name, ok := args[0].(string); ok {
+				names = append(names, strings.ToLower(name))
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package redisfault_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redisfault"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redisfault")
+}
+
+var ctx = context.Background()
+
+var _ = Describe("Injector", func() {
+	var srv *redistest.Server
+	var in *redisfault.Injector
+	var opt *redis.Options
+
+	BeforeEach(func() {
+		var err error
+		srv, err = redistest.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+
+		in = redisfault.New()
+		opt = srv.Options()
+		opt.Dialer = in.Dialer
+		opt.ReadTimeout = 100 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		Expect(srv.Close()).NotTo(HaveOccurred())
+	})
+
+	It("fails dials", func() {
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		in.Add(redisfault.Rule{Fault: redisfault.DialError, Addr: srv.Addr()})
+		err := client.Ping(ctx).Err()
+		var opErr *net.OpError
+		Expect(errors.As(err, &opErr)).To(BeTrue())
+		Expect(opErr.Op).To(Equal("dial"))
+
+		in.Reset()
+		Eventually(func() error {
+			return client.Ping(ctx).Err()
+		}).ShouldNot(HaveOccurred())
+	})
+
+	It("adds latency", func() {
+		client := redis.NewClient(opt)
+		defer client.Close()
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+
+		in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: 50 * time.Millisecond})
+		start := time.Now()
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+
+		in.Reset()
+		in.Add(redisfault.Rule{Fault: redisfault.Latency, Command: "get", Delay: time.Second, Times: 1})
+		opt.MaxRetries = -1
+		noRetries := redis.NewClient(opt)
+		defer noRetries.Close()
+		err := noRetries.Get(ctx, "key").Err()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(net.Error).Timeout()).To(BeTrue())
+		Expect(noRetries.Get(ctx, "key").Val()).To(Equal("value"))
+	})
+
+	It("drops connections mid-reply", func() {
+		client := redis.NewClient(opt)
+		defer client.Close()
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+
+		// The client retries the first failure.
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "get", Times: 1})
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+
+		opt.MaxRetries = -1
+		noRetries := redis.NewClient(opt)
+		defer noRetries.Close()
+		Expect(noRetries.Ping(ctx).Err()).NotTo(HaveOccurred())
+		in.Add(redisfault.Rule{Fault: redisfault.DropReply, Command: "get", Times: 1})
+		Expect(noRetries.Get(ctx, "key").Err()).To(Equal(io.ErrUnexpectedEOF))
+	})
+
+	It("truncates writes", func() {
+		opt.MaxRetries = -1
+		client := redis.NewClient(opt)
+		defer client.Close()
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+
+		in.Add(redisfault.Rule{Fault: redisfault.TruncateWrite, Command: "set", Times: 1})
+		err := client.Set(ctx, "key", "value", 0).Err()
+		Expect(err).To(MatchError(ContainSubstring("connection reset by peer")))
+		Expect(client.Exists(ctx, "key").Val()).To(Equal(int64(0)))
+	})
+
+	It("stalls reads", func() {
+		opt.ContextTimeoutEnabled = true
+		opt.ReadTimeout = time.Minute
+		opt.MaxRetries = -1
+		client := redis.NewClient(opt)
+		defer client.Close()
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+
+		in.Add(redisfault.Rule{Fault: redisfault.StallRead, Addr: srv.Addr(), Times: 1})
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		err := client.Ping(ctx).Err()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(net.Error).Timeout()).To(BeTrue())
+	})
+
+	It("works as a hook", func() {
+		client := redis.NewClient(srv.Options())
+		defer client.Close()
+		client.AddHook(in)
+
+		in.Add(redisfault.Rule{Fault: redisfault.DialError, Err: errors.New("no route")})
+		Expect(client.Ping(ctx).Err()).To(MatchError("dial tcp: no route"))
+	})
+})
@@ -0,0 +1,306 @@
+package redislimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed allows all operations.
+	Closed State = iota
+	// Open rejects all operations until BreakerOptions.OpenTimeout passes.
+	Open
+	// HalfOpen allows BreakerOptions.HalfOpenRequests operations to probe
+	// whether the node recovered.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOptions configures a circuit breaker.
+type BreakerOptions struct {
+	// Name is passed to OnStateChange, e.g. the address of the node.
+	Name string
+
+	// Window is the period over which the error and slow call rates
+	// are computed. Default is 10 seconds.
+	Window time.Duration
+	// MinRequests is the number of results in the window that are
+	// needed to open the breaker. Default is 20.
+	MinRequests int
+	// ErrorRate is the rate of failed operations in the window, between
+	// 0 and 1, that opens the breaker. Default is 0.5.
+	ErrorRate float64
+	// SlowCallDuration is the latency above which operations count as slow.
+	// Default is 0, which doesn't count slow operations.
+	SlowCallDuration time.Duration
+	// SlowCallRate is the rate of slow operations in the window, between
+	// 0 and 1, that opens the breaker. Default is 0.5.
+	SlowCallRate float64
+
+	// OpenTimeout is how long the breaker stays open before it lets
+	// operations probe the node. Default is 10 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probes that have to succeed
+	// to close the breaker again. Default is 1.
+	HalfOpenRequests int
+
+	// IsFailure reports whether a result counts as a failure. Default
+	// counts all errors except redis.Nil, the errors replied by the server
+	// and canceled contexts. The rejections of other limiters, ErrOpen and
+	// ErrRateLimited, count as neither successes nor failures.
+	IsFailure func(err error) bool
+	// OnStateChange is called when the state of the breaker changes.
+	OnStateChange func(name string, from, to State)
+}
+
+func (opt *BreakerOptions) init() {
+	if opt.Window <= 0 {
+		opt.Window = 10 * time.Second
+	}
+	if opt.MinRequests <= 0 {
+		opt.MinRequests = 20
+	}
+	if opt.ErrorRate <= 0 {
+		opt.ErrorRate = 0.5
+	}
+	if opt.SlowCallRate <= 0 {
+		opt.SlowCallRate = 0.5
+	}
+	if opt.OpenTimeout <= 0 {
+		opt.OpenTimeout = 10 * time.Second
+	}
+	if opt.HalfOpenRequests <= 0 {
+		opt.HalfOpenRequests = 1
+	}
+	if opt.IsFailure == nil {
+		opt.IsFailure = isFailure
+	}
+}
+
+func isFailure(err error) bool {
+	if err == nil || err == redis.Nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+// isRejection reports whether err is the rejection of a limiter chained
+// after the breaker, which never reached the node.
+func isRejection(err error) bool {
+	return errors.Is(err, ErrOpen) || errors.Is(err, ErrRateLimited)
+}
+
+const numBuckets = 10
+
+// counts are the results of a part of the window.
+type counts struct {
+	start    int64 // of the part in units of its length
+	total    int
+	failures int
+	slow     int
+}
+
+// Breaker is a circuit breaker that rejects operations with ErrOpen after
+// too many of them failed or were slow, until a few probes succeed again.
+// It implements redis.Limiter and is safe for concurrent use.
+//
+// Since redis.Limiter doesn't tie a result to the Allow call before it,
+// the latency of a result is measured from the oldest operation in flight.
+// That counts every slow operation, but may also count fast operations that
+// overlap with slow ones.
+type Breaker struct {
+	opt BreakerOptions
+
+	mu        sync.Mutex
+	state     State
+	openedAt  time.Time
+	probes    int // operations allowed while half-open
+	successes int // probes that succeeded
+	buckets   [numBuckets]counts
+
+	// Start times of the operations in flight, oldest first from head.
+	starts []time.Time
+	head   int
+}
+
+var _ redis.Limiter = (*Breaker)(nil)
+
+// NewBreaker returns a closed circuit breaker.
+func NewBreaker(opt *BreakerOptions) *Breaker {
+	b := &Breaker{opt: *opt}
+	b.opt.init()
+	return b
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) Allow() error {
+	now := time.Now()
+	var notify func()
+
+	b.mu.Lock()
+	if b.state == Open {
+		if now.Sub(b.openedAt) < b.opt.OpenTimeout {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		notify = b.setState(HalfOpen, now)
+	}
+	err := b.allow(now)
+	b.mu.Unlock()
+
+	if notify != nil {
+		notify()
+	}
+	return err
+}
+
+func (b *Breaker) allow(now time.Time) error {
+	if b.state == HalfOpen {
+		if b.probes >= b.opt.HalfOpenRequests {
+			return ErrOpen
+		}
+		b.probes++
+	}
+	if b.opt.SlowCallDuration > 0 {
+		b.starts = append(b.starts, now)
+	}
+	return nil
+}
+
+func (b *Breaker) ReportResult(result error) {
+	if isRejection(result) {
+		b.mu.Lock()
+		b.slow(time.Now())
+		if b.state == HalfOpen && b.probes > b.successes {
+			// Give the probe to the next operation.
+			b.probes--
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	failed := b.opt.IsFailure(result)
+	var notify func()
+
+	b.mu.Lock()
+	slow := b.slow(now)
+	switch b.state {
+	case Closed:
+		if b.record(now, failed, slow) {
+			notify = b.setState(Open, now)
+		}
+	case HalfOpen:
+		if failed || slow {
+			notify = b.setState(Open, now)
+		} else if b.successes++; b.successes >= b.opt.HalfOpenRequests {
+			notify = b.setState(Closed, now)
+		}
+	}
+	b.mu.Unlock()
+
+	if notify != nil {
+		notify()
+	}
+}
+
+// slow reports whether the oldest operation in flight was slow.
+func (b *Breaker) slow(now time.Time) bool {
+	if b.head == len(b.starts) {
+		return false
+	}
+	start := b.starts[b.head]
+	if b.head++; b.head == len(b.starts) {
+		b.starts, b.head = b.starts[:0], 0
+	}
+	return now.Sub(start) >= b.opt.SlowCallDuration
+}
+
+// record counts a result and reports whether the breaker has to open.
+func (b *Breaker) record(now time.Time, failed, slow bool) bool {
+	length := int64(b.opt.Window / numBuckets)
+	if length <= 0 {
+		length = 1
+	}
+	start := now.UnixNano() / length
+
+	c := &b.buckets[start%numBuckets]
+	if c.start != start {
+		*c = counts{start: start}
+	}
+	c.total++
+	if failed {
+		c.failures++
+	}
+	if slow {
+		c.slow++
+	}
+
+	var total, failures, slows int
+	for _, c := range b.buckets {
+		if start-c.start < numBuckets {
+			total += c.total
+			failures += c.failures
+			slows += c.slow
+		}
+	}
+	if total < b.opt.MinRequests {
+		return false
+	}
+	if float64(failures) >= b.opt.ErrorRate*float64(total) {
+		return true
+	}
+	return b.opt.SlowCallDuration > 0 && float64(slows) >= b.opt.SlowCallRate*float64(total)
+}
+
+// setState changes the state and returns the callback to call
+// after unlocking the breaker.
+func (b *Breaker) setState(state State, now time.Time) func() {
+	from := b.state
+	b.state = state
+	switch state {
+	case Open:
+		b.openedAt = now
+	case HalfOpen:
+		b.probes, b.successes = 0, 0
+	case Closed:
+		b.buckets = [numBuckets]counts{}
+	}
+
+	fn := b.opt.OnStateChange
+	if fn == nil {
+		return nil
+	}
+	name := b.opt.Name
+	return func() {
+		fn(name, from, state)
+	}
+}
@@ -0,0 +1,67 @@
+// Package redislimit implements redis.Limiter with a circuit breaker and
+// a token bucket rate limiter.
+//
+// A limiter applies to all the connections of a client. PerNode gives
+// every node of a ClusterClient and every shard of a Ring its own limiter,
+// so that the breaker of one failing node doesn't stop the others:
+//
+//	opt.NewClient = redislimit.PerNode(func(addr string) redis.Limiter {
+//		return redislimit.NewBreaker(&redislimit.BreakerOptions{
+//			Name: addr,
+//			OnStateChange: func(name string, from, to redislimit.State) {
+//				log.Printf("circuit breaker of %s is %s", name, to)
+//			},
+//		})
+//	})
+package redislimit
+
+import (
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrOpen is returned by Breaker.Allow while the breaker rejects operations.
+	ErrOpen = errors.New("redislimit: circuit breaker is open")
+	// ErrRateLimited is returned by RateLimiter.Allow when the bucket is empty.
+	ErrRateLimited = errors.New("redislimit: rate limit exceeded")
+)
+
+// PerNode returns a function for ClusterOptions.NewClient and
+// RingOptions.NewClient that gives the client of every node its own
+// limiter, created by newLimiter with the address of the node.
+func PerNode(newLimiter func(addr string) redis.Limiter) func(opt *redis.Options) *redis.Client {
+	return func(opt *redis.Options) *redis.Client {
+		opt.Limiter = newLimiter(opt.Addr)
+		return redis.NewClient(opt)
+	}
+}
+
+// Chain returns a limiter that allows an operation when all limiters allow
+// it, asking them in order. When a limiter rejects the operation, the ones
+// before it get the rejection as the result, which a Breaker neither counts
+// nor takes as a probe.
+func Chain(limiters ...redis.Limiter) redis.Limiter {
+	return chain(limiters)
+}
+
+type chain []redis.Limiter
+
+func (c chain) Allow() error {
+	for i, l := range c {
+		if err := l.Allow(); err != nil {
+			for _, allowed := range c[:i] {
+				allowed.ReportResult(err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c chain) ReportResult(result error) {
+	for _, l := range c {
+		l.ReportResult(result)
+	}
+}
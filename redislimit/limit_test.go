@@ -0,0 +1,257 @@
+package redislimit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redislimit"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redislimit")
+}
+
+var ctx = context.Background()
+
+var errNetwork = errors.New("network error")
+
+type stateChange struct {
+	name     string
+	from, to redislimit.State
+}
+
+var _ = Describe("Breaker", func() {
+	var mu sync.Mutex
+	var changes []stateChange
+	var opt *redislimit.BreakerOptions
+
+	BeforeEach(func() {
+		changes = nil
+		opt = &redislimit.BreakerOptions{
+			Name:        "node",
+			MinRequests: 4,
+			ErrorRate:   0.5,
+			OpenTimeout: 50 * time.Millisecond,
+			OnStateChange: func(name string, from, to redislimit.State) {
+				mu.Lock()
+				changes = append(changes, stateChange{name, from, to})
+				mu.Unlock()
+			},
+		}
+	})
+
+	report := func(b *redislimit.Breaker, results ...error) {
+		for _, err := range results {
+			Expect(b.Allow()).To(Succeed())
+			b.ReportResult(err)
+		}
+	}
+
+	It("opens when the error rate is reached", func() {
+		b := redislimit.NewBreaker(opt)
+		report(b, nil, errNetwork, nil)
+		Expect(b.State()).To(Equal(redislimit.Closed))
+
+		report(b, errNetwork)
+		Expect(b.State()).To(Equal(redislimit.Open))
+		Expect(b.Allow()).To(Equal(redislimit.ErrOpen))
+		Expect(changes).To(Equal([]stateChange{{"node", redislimit.Closed, redislimit.Open}}))
+	})
+
+	It("doesn't count errors replied by the server", func() {
+		b := redislimit.NewBreaker(opt)
+		report(b, redis.Nil, serverError("WRONGTYPE"), serverError("ERR"), context.Canceled, nil)
+		Expect(b.State()).To(Equal(redislimit.Closed))
+	})
+
+	It("closes when the probes succeed", func() {
+		opt.HalfOpenRequests = 2
+		b := redislimit.NewBreaker(opt)
+		report(b, errNetwork, errNetwork, errNetwork, errNetwork)
+		Expect(b.State()).To(Equal(redislimit.Open))
+
+		time.Sleep(opt.OpenTimeout)
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.Allow()).To(Equal(redislimit.ErrOpen))
+		Expect(b.State()).To(Equal(redislimit.HalfOpen))
+
+		b.ReportResult(nil)
+		Expect(b.State()).To(Equal(redislimit.HalfOpen))
+		b.ReportResult(nil)
+		Expect(b.State()).To(Equal(redislimit.Closed))
+		Expect(changes).To(Equal([]stateChange{
+			{"node", redislimit.Closed, redislimit.Open},
+			{"node", redislimit.Open, redislimit.HalfOpen},
+			{"node", redislimit.HalfOpen, redislimit.Closed},
+		}))
+	})
+
+	It("opens again when a probe fails", func() {
+		b := redislimit.NewBreaker(opt)
+		report(b, errNetwork, errNetwork, errNetwork, errNetwork)
+
+		time.Sleep(opt.OpenTimeout)
+		report(b, errNetwork)
+		Expect(b.State()).To(Equal(redislimit.Open))
+		Expect(b.Allow()).To(Equal(redislimit.ErrOpen))
+	})
+
+	It("opens when the slow call rate is reached", func() {
+		opt.SlowCallDuration = 10 * time.Millisecond
+		b := redislimit.NewBreaker(opt)
+		report(b, nil, nil)
+
+		for i := 0; i < 2; i++ {
+			Expect(b.Allow()).To(Succeed())
+			time.Sleep(opt.SlowCallDuration)
+			b.ReportResult(nil)
+		}
+		Expect(b.State()).To(Equal(redislimit.Open))
+	})
+})
+
+var _ = Describe("RateLimiter", func() {
+	It("allows bursts and refills at the rate", func() {
+		l := redislimit.NewRateLimiter(100, 2)
+		Expect(l.Allow()).To(Succeed())
+		Expect(l.Allow()).To(Succeed())
+		Expect(l.Allow()).To(Equal(redislimit.ErrRateLimited))
+
+		Eventually(l.Allow).Should(Succeed())
+	})
+})
+
+var _ = Describe("Chain", func() {
+	It("reports the rejection to the limiters that allowed the operation", func() {
+		b := redislimit.NewBreaker(&redislimit.BreakerOptions{MinRequests: 1})
+		l := redislimit.Chain(b, redislimit.NewRateLimiter(1, 1))
+
+		Expect(l.Allow()).To(Succeed())
+		l.ReportResult(nil)
+		Expect(l.Allow()).To(Equal(redislimit.ErrRateLimited))
+		Expect(b.State()).To(Equal(redislimit.Closed))
+	})
+
+	It("doesn't close a half-open breaker with the rejections of later limiters", func() {
+		b := redislimit.NewBreaker(&redislimit.BreakerOptions{
+			MinRequests: 1,
+			OpenTimeout: 10 * time.Millisecond,
+		})
+		l := redislimit.Chain(b, redislimit.NewRateLimiter(10, 1))
+
+		Expect(l.Allow()).To(Succeed())
+		l.ReportResult(io.EOF)
+		Expect(b.State()).To(Equal(redislimit.Open))
+		time.Sleep(20 * time.Millisecond)
+
+		// The rate limiter rejects the probe, which is given back.
+		Expect(l.Allow()).To(Equal(redislimit.ErrRateLimited))
+		Expect(b.State()).To(Equal(redislimit.HalfOpen))
+		time.Sleep(100 * time.Millisecond)
+
+		Expect(l.Allow()).To(Succeed())
+		l.ReportResult(nil)
+		Expect(b.State()).To(Equal(redislimit.Closed))
+	})
+
+	It("doesn't count the rejections of later limiters in the error rate", func() {
+		b := redislimit.NewBreaker(&redislimit.BreakerOptions{MinRequests: 2})
+		l := redislimit.Chain(b, redislimit.NewRateLimiter(1, 1))
+
+		Expect(l.Allow()).To(Succeed())
+		l.ReportResult(io.EOF)
+		for i := 0; i < 3; i++ {
+			Expect(l.Allow()).To(Equal(redislimit.ErrRateLimited))
+		}
+		Expect(b.State()).To(Equal(redislimit.Closed))
+
+		// One failure out of two operations.
+		Expect(b.Allow()).To(Succeed())
+		b.ReportResult(nil)
+		Expect(b.State()).To(Equal(redislimit.Open))
+	})
+})
+
+var _ = Describe("PerNode", func() {
+	var servers []*redistest.Server
+	var ring *redis.Ring
+	var mu sync.Mutex
+	var opened []string
+
+	BeforeEach(func() {
+		addrs := make(map[string]string)
+		servers = nil
+		for _, name := range []string{"a", "b"} {
+			srv, err := redistest.NewServer()
+			Expect(err).NotTo(HaveOccurred())
+			servers = append(servers, srv)
+			addrs[name] = srv.Addr()
+		}
+
+		opened = nil
+		ring = redis.NewRing(&redis.RingOptions{
+			Addrs:              addrs,
+			HeartbeatFrequency: time.Hour,
+			MaxRetries:         -1,
+			NewClient: redislimit.PerNode(func(addr string) redis.Limiter {
+				return redislimit.NewBreaker(&redislimit.BreakerOptions{
+					Name:        addr,
+					MinRequests: 2,
+					OnStateChange: func(name string, from, to redislimit.State) {
+						if to == redislimit.Open {
+							mu.Lock()
+							opened = append(opened, name)
+							mu.Unlock()
+						}
+					},
+				})
+			}),
+		})
+	})
+
+	AfterEach(func() {
+		Expect(ring.Close()).To(Succeed())
+		for _, srv := range servers {
+			_ = srv.Close()
+		}
+	})
+
+	It("opens the breaker of the failing shard only", func() {
+		failing := servers[0].Addr()
+		Expect(servers[0].Close()).To(Succeed())
+
+		var failed, rejected, succeeded int
+		for i := 0; i < 100; i++ {
+			key := string(rune('a' + i%26))
+			switch err := ring.Get(ctx, key).Err(); {
+			case err == nil || err == redis.Nil:
+				succeeded++
+			case err == redislimit.ErrOpen:
+				rejected++
+			default:
+				failed++
+			}
+		}
+
+		Expect(failed).To(Equal(2))
+		Expect(rejected).To(BeNumerically(">", 0))
+		Expect(succeeded).To(BeNumerically(">", 0))
+		Expect(opened).To(Equal([]string{failing}))
+	})
+})
+
+type serverError string
+
+func (e serverError) Error() string { return string(e) }
+func (serverError) RedisError()     {}
@@ -0,0 +1,59 @@
+package redislimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter is a token bucket that allows rate operations per second
+// on average and bursts of up to burst operations. It rejects the others
+// with ErrRateLimited instead of waiting. It implements redis.Limiter and
+// is safe for concurrent use.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var _ redis.Limiter = (*RateLimiter)(nil)
+
+// NewRateLimiter returns a rate limiter with a full bucket.
+// A burst below 1 is 1.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (l *RateLimiter) Allow() error {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return ErrRateLimited
+	}
+	l.tokens--
+	return nil
+}
+
+// ReportResult does nothing, the rate doesn't depend on the results.
+func (l *RateLimiter) ReportResult(result error) {}
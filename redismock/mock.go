@@ -0,0 +1,305 @@
+// Package redismock fakes the replies of Redis commands for unit tests.
+//
+// A Mock is a redis.Hook that answers commands with the replies of the
+// expectations declared by the test instead of sending them to a server,
+// so code under test can use the methods of redis.Client as usual:
+//
+//	rdb, mock := redismock.NewClient()
+//	mock.Expect("get", "key").SetVal("value")
+//	mock.Expect("set", "key", "other").SetErr(errors.New("FAIL"))
+//
+//	// run the code under test with rdb
+//
+//	if err := mock.ExpectationsWereMet(); err != nil {
+//		t.Error(err)
+//	}
+//
+// The hook can also be added to clients created by the test, e.g.
+// cluster clients, with AddHook. Commands that aren't expected fail
+// with an error and never reach the network.
+package redismock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Mock is a redis.Hook that replies to commands with expectations.
+// It is safe for concurrent use.
+type Mock struct {
+	mu       sync.Mutex
+	expected []*Expectation
+	ordered  bool
+}
+
+var _ redis.Hook = (*Mock)(nil)
+
+// New returns a mock that matches the expectations in order.
+func New() *Mock {
+	return &Mock{ordered: true}
+}
+
+// NewClient returns a client whose commands are answered by a new mock.
+// The client doesn't connect to a server.
+func NewClient() (*redis.Client, *Mock) {
+	m := New()
+	rdb := redis.NewClient(&redis.Options{
+		Addr:       "redismock:6379",
+		MaxRetries: -1,
+	})
+	rdb.AddHook(m)
+	return rdb, m
+}
+
+// MatchExpectationsInOrder sets whether commands must run in the order
+// of the expectations, which is the default. Otherwise a command matches
+// the first unmet expectation with the same arguments.
+func (m *Mock) MatchExpectationsInOrder(ordered bool) {
+	m.mu.Lock()
+	m.ordered = ordered
+	m.mu.Unlock()
+}
+
+// Expect adds the expectation of a command with the arguments args,
+// e.g. "set", "key", "value", "ex", 60 like Cmd.Args returns them.
+// It replies with a zero value and no error unless the test sets them.
+func (m *Mock) Expect(args ...interface{}) *Expectation {
+	e := &Expectation{args: args}
+	m.mu.Lock()
+	m.expected = append(m.expected, e)
+	m.mu.Unlock()
+	return e
+}
+
+// ExpectTxPipeline expects the MULTI that starts a transaction pipeline.
+// The commands of the transaction and ExpectTxPipelineExec must follow it.
+func (m *Mock) ExpectTxPipeline() *Expectation {
+	return m.Expect("multi").SetVal("OK")
+}
+
+// ExpectTxPipelineExec expects the EXEC that ends a transaction pipeline.
+func (m *Mock) ExpectTxPipelineExec() *Expectation {
+	return m.Expect("exec")
+}
+
+// ExpectationsWereMet returns an error that lists the expectations
+// that no command has matched yet.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var unmet []string
+	for _, e := range m.expected {
+		if !e.triggered {
+			unmet = append(unmet, e.String())
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	return fmt.Errorf("redismock: there are unmet expectations: %s", strings.Join(unmet, ", "))
+}
+
+// ClearExpect removes all expectations.
+func (m *Mock) ClearExpect() {
+	m.mu.Lock()
+	m.expected = nil
+	m.mu.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Mock) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("redismock: the client must not dial with a mock")
+	}
+}
+
+func (m *Mock) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		m.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (m *Mock) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			m.process(cmd)
+		}
+		for _, cmd := range cmds {
+			if err := cmd.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (m *Mock) process(cmd redis.Cmder) {
+	e, err := m.match(cmd.Args())
+	if err != nil {
+		cmd.SetErr(err)
+		return
+	}
+	if err := e.apply(cmd); err != nil {
+		cmd.SetErr(err)
+	}
+}
+
+// match finds and triggers the expectation for the command args.
+func (m *Mock) match(args []interface{}) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expected {
+		if e.triggered {
+			continue
+		}
+		if e.matches(args) {
+			e.triggered = true
+			return e, nil
+		}
+		if m.ordered {
+			return nil, fmt.Errorf("redismock: command %s doesn't match the next expectation %s",
+				formatArgs(args), e)
+		}
+	}
+	return nil, fmt.Errorf("redismock: command %s was not expected", formatArgs(args))
+}
+
+//------------------------------------------------------------------------------
+
+// Expectation is an expected command and its reply.
+type Expectation struct {
+	args  []interface{}
+	match func(expected, actual []interface{}) error
+
+	val    interface{}
+	hasVal bool
+	err    error
+
+	// Protected by Mock.mu.
+	triggered bool
+}
+
+// SetVal sets the value of the reply. It must have the type of the value
+// of the command, e.g. string for Get or []interface{} for MGet,
+// or a number that converts to it.
+func (e *Expectation) SetVal(val interface{}) *Expectation {
+	e.val, e.hasVal = val, true
+	return e
+}
+
+// SetErr makes the command fail with err.
+func (e *Expectation) SetErr(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// RedisNil makes the command fail with redis.Nil like missing keys do.
+func (e *Expectation) RedisNil() *Expectation {
+	return e.SetErr(redis.Nil)
+}
+
+// CustomMatch replaces the comparison of the expected with the actual
+// arguments, e.g. to ignore arguments that change on every run.
+// A non-nil error fails the match.
+func (e *Expectation) CustomMatch(fn func(expected, actual []interface{}) error) *Expectation {
+	e.match = fn
+	return e
+}
+
+func (e *Expectation) String() string {
+	return formatArgs(e.args)
+}
+
+func (e *Expectation) matches(args []interface{}) bool {
+	if e.match != nil {
+		return e.match(e.args, args) == nil
+	}
+	if len(args) != len(e.args) {
+		return false
+	}
+	for i := range args {
+		actual, expected := argString(args[i]), argString(e.args[i])
+		if i == 0 {
+			actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+		}
+		if actual != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) apply(cmd redis.Cmder) error {
+	if e.hasVal {
+		if err := setVal(cmd, e.val); err != nil {
+			return err
+		}
+	}
+	if e.err != nil {
+		cmd.SetErr(e.err)
+	}
+	return nil
+}
+
+// setVal calls the SetVal method that all commands implement
+// with an argument of their own value type.
+func setVal(cmd redis.Cmder, val interface{}) error {
+	method := reflect.ValueOf(cmd).MethodByName("SetVal")
+	if !method.IsValid() || method.Type().NumIn() != 1 {
+		return fmt.Errorf("redismock: %T doesn't support SetVal", cmd)
+	}
+	typ := method.Type().In(0)
+
+	v := reflect.ValueOf(val)
+	switch {
+	case val == nil:
+		v = reflect.Zero(typ)
+	case v.Type().AssignableTo(typ):
+	case isNumber(v.Kind()) && isNumber(typ.Kind()):
+		v = v.Convert(typ)
+	default:
+		return fmt.Errorf("redismock: can't use %T as the value of %T, which wants %s", val, cmd, typ)
+	}
+	method.Call([]reflect.Value{v})
+	return nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func argString(arg interface{}) string {
+	switch arg := arg.(type) {
+	case string:
+		return arg
+	case []byte:
+		return string(arg)
+	default:
+		return fmt.Sprint(arg)
+	}
+}
+
+func formatArgs(args []interface{}) string {
+	ss := make([]string, len(args))
+	for i, arg := range args {
+		ss[i] = argString(arg)
+	}
+	return "[" + strings.Join(ss, " ") + "]"
+}
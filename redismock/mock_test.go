@@ -0,0 +1,129 @@
+package redismock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redismock"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redismock")
+}
+
+var ctx = context.Background()
+
+var _ = Describe("Mock", func() {
+	var client *redis.Client
+	var mock *redismock.Mock
+
+	BeforeEach(func() {
+		client, mock = redismock.NewClient()
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("replies to expected commands", func() {
+		mock.Expect("get", "key").SetVal("value")
+		mock.Expect("get", "missing").RedisNil()
+		mock.Expect("set", "key", "value", "ex", 60).SetVal("OK")
+		mock.Expect("incr", "counter").SetVal(2)
+		mock.Expect("mget", "a", "b").SetVal([]interface{}{"1", nil})
+		mock.Expect("del", "key").SetErr(errors.New("ERR fail"))
+
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		Expect(client.Get(ctx, "missing").Err()).To(Equal(redis.Nil))
+		Expect(client.Set(ctx, "key", "value", time.Minute).Val()).To(Equal("OK"))
+		Expect(client.Incr(ctx, "counter").Val()).To(Equal(int64(2)))
+		Expect(client.MGet(ctx, "a", "b").Val()).To(Equal([]interface{}{"1", nil}))
+		Expect(client.Del(ctx, "key").Err()).To(MatchError("ERR fail"))
+
+		Expect(mock.ExpectationsWereMet()).NotTo(HaveOccurred())
+	})
+
+	It("asserts the order", func() {
+		mock.Expect("get", "a").SetVal("1")
+		mock.Expect("get", "b").SetVal("2")
+
+		err := client.Get(ctx, "b").Err()
+		Expect(err).To(MatchError("redismock: command [get b] doesn't match the next expectation [get a]"))
+		Expect(mock.ExpectationsWereMet()).To(MatchError(
+			"redismock: there are unmet expectations: [get a], [get b]"))
+
+		mock.ClearExpect()
+		mock.MatchExpectationsInOrder(false)
+		mock.Expect("get", "a").SetVal("1")
+		mock.Expect("get", "b").SetVal("2")
+		Expect(client.Get(ctx, "b").Val()).To(Equal("2"))
+		Expect(client.Get(ctx, "a").Val()).To(Equal("1"))
+		Expect(mock.ExpectationsWereMet()).NotTo(HaveOccurred())
+	})
+
+	It("rejects unexpected commands", func() {
+		Expect(client.Ping(ctx).Err()).To(MatchError("redismock: command [ping] was not expected"))
+
+		mock.Expect("get", "key").SetVal(1)
+		Expect(client.Get(ctx, "key").Err()).To(MatchError(
+			"redismock: can't use int as the value of *redis.StringCmd, which wants string"))
+	})
+
+	It("matches custom arguments", func() {
+		mock.Expect("set", "key").CustomMatch(func(expected, actual []interface{}) error {
+			if len(actual) < 2 || actual[1] != expected[1] {
+				return errors.New("other key")
+			}
+			return nil
+		}).SetVal("OK")
+
+		Expect(client.Set(ctx, "key", time.Now().String(), 0).Val()).To(Equal("OK"))
+		Expect(mock.ExpectationsWereMet()).NotTo(HaveOccurred())
+	})
+
+	It("supports pipelines", func() {
+		mock.Expect("incr", "a").SetVal(1)
+		mock.Expect("get", "b").RedisNil()
+
+		cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "a")
+			pipe.Get(ctx, "b")
+			return nil
+		})
+		Expect(err).To(Equal(redis.Nil))
+		Expect(cmds[0].(*redis.IntCmd).Val()).To(Equal(int64(1)))
+		Expect(cmds[1].Err()).To(Equal(redis.Nil))
+		Expect(mock.ExpectationsWereMet()).NotTo(HaveOccurred())
+	})
+
+	It("supports transaction pipelines", func() {
+		mock.ExpectTxPipeline()
+		mock.Expect("incr", "a").SetVal(1)
+		mock.Expect("expire", "a", 10).SetVal(true)
+		mock.ExpectTxPipelineExec()
+
+		cmds, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "a")
+			pipe.Expire(ctx, "a", 10*time.Second)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmds).To(HaveLen(2))
+		Expect(cmds[1].(*redis.BoolCmd).Val()).To(BeTrue())
+		Expect(mock.ExpectationsWereMet()).NotTo(HaveOccurred())
+
+		mock.Expect("incr", "a").SetVal(1)
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "a")
+			return nil
+		})
+		Expect(err).To(MatchError("redismock: command [multi] doesn't match the next expectation [incr a]"))
+	})
+})
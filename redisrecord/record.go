@@ -0,0 +1,440 @@
+// Package redisrecord records the RESP traffic of clients and replays it.
+//
+// A Recorder is a redis.Hook that wraps the connections a client dials
+// and writes the bytes they send and receive with timestamps to a file:
+//
+//	rec := redisrecord.NewRecorder(f)
+//	rdb.AddHook(rec)
+//
+// A Replayer reads such a file and dials connections that check the
+// commands a client sends against the recording and answer with the
+// recorded replies, so a protocol exchange can be reproduced without
+// a server:
+//
+//	rp, err := redisrecord.NewReplayer(f)
+//	rdb := redis.NewClient(&redis.Options{Dialer: rp.Dialer})
+//
+// The format is a header line followed by an event per read, write,
+// dial and close of a connection. Every event is a line
+// "<conn> <kind> <time> <length>" with the time in RFC 3339 format,
+// followed by length bytes of data and a newline:
+//
+//	redisrecord 1
+//	1 dial 2024-01-02T15:04:05.123456789Z 20
+//	tcp 127.0.0.1:6379
+//	1 send 2024-01-02T15:04:05.123500000Z 14
+//	*1\r\n$4\r\nPING\r\n
+//	1 recv 2024-01-02T15:04:05.123700000Z 7
+//	+PONG\r\n
+package redisrecord
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const header = "redisrecord 1\n"
+
+// Kinds of events.
+const (
+	Dial  = "dial"  // the client dialed the connection; data is "<network> <addr>"
+	Send  = "send"  // the client wrote data
+	Recv  = "recv"  // the client read data
+	Close = "close" // the server closed the connection
+)
+
+// Event is a recorded event of a connection.
+type Event struct {
+	Conn int
+	Kind string
+	Time time.Time
+	Data []byte
+}
+
+//------------------------------------------------------------------------------
+
+// Recorder is a redis.Hook that records the traffic of the connections
+// dialed by the clients it is added to. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	started bool
+	lastID  int
+	err     error
+}
+
+var _ redis.Hook = (*Recorder)(nil)
+
+// NewRecorder returns a recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Err returns the first error writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.lastID++
+		id := r.lastID
+		r.mu.Unlock()
+
+		r.record(id, Dial, []byte(network+" "+addr))
+		return &recordConn{Conn: conn, rec: r, id: id}, nil
+	}
+}
+
+func (r *Recorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (r *Recorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (r *Recorder) record(id int, kind string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	var b []byte
+	if !r.started {
+		r.started = true
+		b = append(b, header...)
+	}
+	b = strconv.AppendInt(b, int64(id), 10)
+	b = append(b, ' ')
+	b = append(b, kind...)
+	b = append(b, ' ')
+	b = time.Now().UTC().AppendFormat(b, time.RFC3339Nano)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(len(data)), 10)
+	b = append(b, '\n')
+	b = append(b, data...)
+	b = append(b, '\n')
+	_, r.err = r.w.Write(b)
+}
+
+type recordConn struct {
+	net.Conn
+	rec *Recorder
+	id  int
+}
+
+func (c *recordConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.rec.record(c.id, Recv, b[:n])
+	}
+	if err == io.EOF {
+		c.rec.record(c.id, Close, nil)
+	}
+	return n, err
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.rec.record(c.id, Send, b[:n])
+	}
+	return n, err
+}
+
+//------------------------------------------------------------------------------
+
+// ReadEvents reads the events of a recording.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	rd := bufio.NewReader(r)
+	line, err := rd.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if line == "" {
+		return nil, nil
+	}
+	if line != header {
+		return nil, fmt.Errorf("redisrecord: invalid header %q", line)
+	}
+
+	var events []Event
+	for {
+		line, err := rd.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redisrecord: truncated event %q", line)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		ev, n, err := parseEvent(line)
+		if err != nil {
+			return nil, err
+		}
+		ev.Data = make([]byte, n+1)
+		if _, err := io.ReadFull(rd, ev.Data); err != nil {
+			return nil, fmt.Errorf("redisrecord: truncated data of event %q", line)
+		}
+		if ev.Data[n] != '\n' {
+			return nil, fmt.Errorf("redisrecord: data of event %q doesn't end with a newline", line)
+		}
+		ev.Data = ev.Data[:n]
+		events = append(events, ev)
+	}
+}
+
+func parseEvent(line string) (Event, int, error) {
+	fields := strings.Split(line, " ")
+	if len(fields) != 4 {
+		return Event{}, 0, fmt.Errorf("redisrecord: invalid event %q", line)
+	}
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Event{}, 0, fmt.Errorf("redisrecord: invalid connection of event %q", line)
+	}
+	switch fields[1] {
+	case Dial, Send, Recv, Close:
+	default:
+		return Event{}, 0, fmt.Errorf("redisrecord: invalid kind of event %q", line)
+	}
+	tm, err := time.Parse(time.RFC3339Nano, fields[2])
+	if err != nil {
+		return Event{}, 0, fmt.Errorf("redisrecord: invalid time of event %q", line)
+	}
+	n, err := strconv.Atoi(fields[3])
+	if err != nil || n < 0 {
+		return Event{}, 0, fmt.Errorf("redisrecord: invalid length of event %q", line)
+	}
+	return Event{Conn: id, Kind: fields[1], Time: tm}, n, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Replayer dials connections that replay a recording. The connections
+// are replayed in the order the recording dialed them. It is safe
+// for concurrent use.
+type Replayer struct {
+	mu    sync.Mutex
+	conns [][]Event
+	next  int
+}
+
+// NewReplayer reads a recording for replay.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	events, err := ReadEvents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Replayer)
+	index := make(map[int]int)
+	for _, ev := range events {
+		i, ok := index[ev.Conn]
+		if !ok {
+			i = len(p.conns)
+			index[ev.Conn] = i
+			p.conns = append(p.conns, nil)
+		}
+		if ev.Kind != Dial {
+			p.conns[i] = append(p.conns[i], ev)
+		}
+	}
+	return p, nil
+}
+
+// Dialer returns the next recorded connection. It can be used as
+// redis.Options.Dialer.
+func (p *Replayer) Dialer(ctx context.Context, network, addr string) (net.Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.next >= len(p.conns) {
+		return nil, errors.New("redisrecord: no more recorded connections")
+	}
+	c := newReplayConn(p.conns[p.next])
+	p.next++
+	return c, nil
+}
+
+// replayConn checks that the client writes the recorded data and makes the
+// recorded replies that follow it available for reading.
+type replayConn struct {
+	mu       sync.Mutex
+	events   []Event
+	written  []byte // written data not yet matched
+	readable []byte
+	eof      bool
+	closed   bool
+	deadline time.Time
+	notify   chan struct{}
+}
+
+func newReplayConn(events []Event) *replayConn {
+	c := &replayConn{
+		events: append([]Event(nil), events...),
+		notify: make(chan struct{}),
+	}
+	c.advance()
+	return c
+}
+
+// advance queues the replies up to the next recorded write.
+func (c *replayConn) advance() {
+	for len(c.events) > 0 {
+		switch ev := c.events[0]; ev.Kind {
+		case Recv:
+			c.readable = append(c.readable, ev.Data...)
+		case Close:
+			c.eof = true
+		default:
+			return
+		}
+		c.events = c.events[1:]
+	}
+}
+
+func (c *replayConn) wakeup() {
+	close(c.notify)
+	c.notify = make(chan struct{})
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case c.closed:
+			return 0, net.ErrClosed
+		case len(c.readable) > 0:
+			n := copy(b, c.readable)
+			c.readable = c.readable[n:]
+			return n, nil
+		case c.eof:
+			return 0, io.EOF
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !c.deadline.IsZero() {
+			d := time.Until(c.deadline)
+			if d <= 0 {
+				return 0, errTimeout
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+
+		notify := c.notify
+		c.mu.Unlock()
+		select {
+		case <-notify:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		c.mu.Lock()
+	}
+}
+
+func (c *replayConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	c.written = append(c.written, b...)
+	for len(c.written) > 0 {
+		if len(c.events) == 0 || c.events[0].Kind != Send {
+			return 0, fmt.Errorf("redisrecord: unexpected write %q", c.written)
+		}
+		want := c.events[0].Data
+		n := len(want)
+		if len(c.written) < n {
+			n = len(c.written)
+		}
+		if string(c.written[:n]) != string(want[:n]) {
+			return 0, fmt.Errorf("redisrecord: wrote %q, recorded %q", c.written, want)
+		}
+		if n < len(want) {
+			// The client writes the recorded data in smaller chunks.
+			c.events[0].Data = want[n:]
+			c.written = c.written[:0]
+			break
+		}
+		c.written = c.written[n:]
+		c.events = c.events[1:]
+		c.advance()
+	}
+	c.wakeup()
+	return len(b), nil
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.wakeup()
+	}
+	return nil
+}
+
+func (c *replayConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *replayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.wakeup()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *replayConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *replayConn) LocalAddr() net.Addr {
+	return replayAddr{}
+}
+
+func (c *replayConn) RemoteAddr() net.Addr {
+	return replayAddr{}
+}
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "redisrecord" }
+func (replayAddr) String() string  { return "replay" }
+
+var errTimeout error = timeoutError{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "redisrecord: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
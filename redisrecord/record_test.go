@@ -0,0 +1,113 @@
+package redisrecord_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redisrecord"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redisrecord")
+}
+
+var ctx = context.Background()
+
+var _ = Describe("Recorder", func() {
+	var srv *redistest.Server
+	var recording bytes.Buffer
+
+	BeforeEach(func() {
+		var err error
+		srv, err = redistest.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+
+		recording.Reset()
+		rec := redisrecord.NewRecorder(&recording)
+		client := redis.NewClient(srv.Options())
+		client.AddHook(rec)
+
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "counter")
+			pipe.Get(ctx, "missing")
+			return nil
+		})
+		Expect(err).To(Equal(redis.Nil))
+		Expect(client.Close()).NotTo(HaveOccurred())
+		Expect(rec.Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(srv.Close()).NotTo(HaveOccurred())
+	})
+
+	It("records the traffic", func() {
+		events, err := redisrecord.ReadEvents(bytes.NewReader(recording.Bytes()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events[0].Kind).To(Equal(redisrecord.Dial))
+		Expect(string(events[0].Data)).To(Equal("tcp " + srv.Addr()))
+
+		var sent, received []byte
+		for _, ev := range events {
+			Expect(ev.Conn).To(Equal(1))
+			Expect(ev.Time).NotTo(BeZero())
+			switch ev.Kind {
+			case redisrecord.Send:
+				sent = append(sent, ev.Data...)
+			case redisrecord.Recv:
+				received = append(received, ev.Data...)
+			}
+		}
+		Expect(string(sent)).To(ContainSubstring("*2\r\n$3\r\nget\r\n$3\r\nkey\r\n"))
+		Expect(string(received)).To(ContainSubstring("$5\r\nvalue\r\n"))
+	})
+
+	It("replays the traffic", func() {
+		Expect(srv.Close()).NotTo(HaveOccurred())
+
+		rp, err := redisrecord.NewReplayer(bytes.NewReader(recording.Bytes()))
+		Expect(err).NotTo(HaveOccurred())
+		opt := srv.Options()
+		opt.Dialer = rp.Dialer
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, "counter")
+			pipe.Get(ctx, "missing")
+			return nil
+		})
+		Expect(err).To(Equal(redis.Nil))
+		Expect(cmds[0].(*redis.IntCmd).Val()).To(Equal(int64(1)))
+	})
+
+	It("detects other commands", func() {
+		rp, err := redisrecord.NewReplayer(bytes.NewReader(recording.Bytes()))
+		Expect(err).NotTo(HaveOccurred())
+		opt := srv.Options()
+		opt.Dialer = rp.Dialer
+		opt.MaxRetries = -1
+		client := redis.NewClient(opt)
+		defer client.Close()
+
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		err = client.Get(ctx, "other").Err()
+		Expect(err).To(MatchError(ContainSubstring("redisrecord: wrote")))
+	})
+
+	It("rejects invalid recordings", func() {
+		_, err := redisrecord.NewReplayer(bytes.NewBufferString("redisrecord 1\n1 send now 3\nabc\n"))
+		Expect(err).To(MatchError(`redisrecord: invalid time of event "1 send now 3"`))
+	})
+})
@@ -0,0 +1,188 @@
+// Package redisroute implements redis.ReadRouter with availability zone
+// affinity, weighted round-robin and least outstanding requests routing.
+//
+// The routers combine, e.g. to keep reads in the zone of the client, where
+// they go to the node with the fewest outstanding requests:
+//
+//	opt.ReadRouter = redisroute.Zone("us-east-1a", redisroute.ZoneLabel("zone"),
+//		redisroute.LeastOutstanding())
+//
+// All routers avoid the failing nodes unless all nodes are failing.
+package redisroute
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Zone returns a router that routes to the nodes in zone, the availability
+// zone of the client, using next. zoneOf returns the zone of a node, see
+// ZoneLabel and ZoneByAddr. When none of the nodes in zone is healthy,
+// it routes to all nodes using next. A nil next is LeastOutstanding.
+func Zone(zone string, zoneOf func(node *redis.ReadNode) string, next redis.ReadRouter) redis.ReadRouter {
+	if next == nil {
+		next = LeastOutstanding()
+	}
+	return &zoneRouter{
+		zone:   zone,
+		zoneOf: zoneOf,
+		next:   next,
+	}
+}
+
+type zoneRouter struct {
+	zone   string
+	zoneOf func(node *redis.ReadNode) string
+	next   redis.ReadRouter
+}
+
+func (r *zoneRouter) Route(ctx context.Context, nodes []redis.ReadNode) int {
+	var local []redis.ReadNode
+	var indexes []int
+	for i := range nodes {
+		node := &nodes[i]
+		if !node.Failing && r.zoneOf(node) == r.zone {
+			local = append(local, *node)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(local) == 0 {
+		return r.next.Route(ctx, nodes)
+	}
+
+	i := r.next.Route(ctx, local)
+	if i < 0 || i >= len(local) {
+		return indexes[0]
+	}
+	return indexes[i]
+}
+
+// ZoneLabel returns the zone of the nodes from the label key,
+// e.g. of the networking metadata of CLUSTER SLOTS.
+func ZoneLabel(key string) func(node *redis.ReadNode) string {
+	return func(node *redis.ReadNode) string {
+		return node.Labels[key]
+	}
+}
+
+// ZoneByAddr returns the zone of the nodes from their address. The keys
+// of zones are node addresses, hosts or subnets in CIDR notation, e.g.
+// "10.0.1.0/24" for the subnet of an availability zone. Nodes that don't
+// match any key have no zone.
+func ZoneByAddr(zones map[string]string) func(node *redis.ReadNode) string {
+	type subnet struct {
+		ipNet *net.IPNet
+		zone  string
+	}
+	var subnets []subnet
+	for key, zone := range zones {
+		if _, ipNet, err := net.ParseCIDR(key); err == nil {
+			subnets = append(subnets, subnet{ipNet, zone})
+		}
+	}
+
+	return func(node *redis.ReadNode) string {
+		if zone, ok := zones[node.Addr]; ok {
+			return zone
+		}
+		host, _, err := net.SplitHostPort(node.Addr)
+		if err != nil {
+			host = node.Addr
+		}
+		if zone, ok := zones[host]; ok {
+			return zone
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			for _, s := range subnets {
+				if s.ipNet.Contains(ip) {
+					return s.zone
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// WeightedRoundRobin returns a router that spreads the reads over the nodes
+// in proportion to their weight, interleaving them smoothly. Nodes with
+// a weight of 0 or less get no reads, unless none of the nodes is healthy
+// with a positive weight and a random node is used. A nil weight gives all
+// nodes the same weight.
+func WeightedRoundRobin(weight func(node *redis.ReadNode) int) redis.ReadRouter {
+	if weight == nil {
+		weight = func(*redis.ReadNode) int { return 1 }
+	}
+	return &roundRobin{
+		weight:  weight,
+		current: make(map[string]int),
+	}
+}
+
+type roundRobin struct {
+	weight func(node *redis.ReadNode) int
+
+	mu      sync.Mutex
+	current map[string]int // by address
+}
+
+func (r *roundRobin) Route(ctx context.Context, nodes []redis.ReadNode) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	best, total := -1, 0
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Failing {
+			continue
+		}
+		w := r.weight(node)
+		if w <= 0 {
+			continue
+		}
+		r.current[node.Addr] += w
+		total += w
+		if best == -1 || r.current[node.Addr] > r.current[nodes[best].Addr] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return rand.Intn(len(nodes))
+	}
+	r.current[nodes[best].Addr] -= total
+	return best
+}
+
+// LeastOutstanding returns a router that routes to the node with
+// the fewest commands in flight, breaking ties randomly.
+func LeastOutstanding() redis.ReadRouter {
+	return leastOutstanding{}
+}
+
+type leastOutstanding struct{}
+
+func (leastOutstanding) Route(ctx context.Context, nodes []redis.ReadNode) int {
+	best, ties := -1, 0
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Failing {
+			continue
+		}
+		switch {
+		case best == -1 || node.Outstanding < nodes[best].Outstanding:
+			best, ties = i, 1
+		case node.Outstanding == nodes[best].Outstanding:
+			// Pick each of the ties with the same probability.
+			if ties++; rand.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	if best == -1 {
+		return rand.Intn(len(nodes))
+	}
+	return best
+}
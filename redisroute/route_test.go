@@ -0,0 +1,167 @@
+package redisroute_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redisroute"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redisroute")
+}
+
+var ctx = context.Background()
+
+func route(r redis.ReadRouter, nodes []redis.ReadNode, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[nodes[r.Route(ctx, nodes)].Addr]++
+	}
+	return counts
+}
+
+var _ = Describe("Zone", func() {
+	nodes := []redis.ReadNode{
+		{Addr: "10.0.1.1:6379", Master: true, Labels: map[string]string{"zone": "a"}},
+		{Addr: "10.0.2.1:6379", Labels: map[string]string{"zone": "b"}},
+		{Addr: "10.0.2.2:6379", Labels: map[string]string{"zone": "b"}},
+	}
+
+	It("routes to the nodes in the zone", func() {
+		r := redisroute.Zone("b", redisroute.ZoneLabel("zone"), redisroute.WeightedRoundRobin(nil))
+		Expect(route(r, nodes, 4)).To(Equal(map[string]int{
+			"10.0.2.1:6379": 2,
+			"10.0.2.2:6379": 2,
+		}))
+	})
+
+	It("routes to all nodes when the zone has no healthy node", func() {
+		failing := append([]redis.ReadNode(nil), nodes...)
+		failing[0].Failing = true
+
+		r := redisroute.Zone("a", redisroute.ZoneLabel("zone"), nil)
+		Expect(route(r, failing, 10)).NotTo(HaveKey("10.0.1.1:6379"))
+	})
+
+	It("finds the zone by address", func() {
+		zoneOf := redisroute.ZoneByAddr(map[string]string{
+			"10.0.1.0/24":   "a",
+			"10.0.2.2":      "c",
+			"10.0.2.1:6379": "b",
+		})
+		Expect(zoneOf(&nodes[0])).To(Equal("a"))
+		Expect(zoneOf(&nodes[1])).To(Equal("b"))
+		Expect(zoneOf(&nodes[2])).To(Equal("c"))
+		Expect(zoneOf(&redis.ReadNode{Addr: "10.0.3.1:6379"})).To(Equal(""))
+	})
+})
+
+var _ = Describe("WeightedRoundRobin", func() {
+	It("routes in proportion to the weights", func() {
+		nodes := []redis.ReadNode{{Addr: "master", Master: true}, {Addr: "a"}, {Addr: "b"}}
+		r := redisroute.WeightedRoundRobin(func(node *redis.ReadNode) int {
+			switch node.Addr {
+			case "master":
+				return 0
+			case "a":
+				return 3
+			}
+			return 1
+		})
+
+		var addrs []string
+		for i := 0; i < 8; i++ {
+			addrs = append(addrs, nodes[r.Route(ctx, nodes)].Addr)
+		}
+		Expect(addrs).To(Equal([]string{"a", "a", "b", "a", "a", "a", "b", "a"}))
+	})
+
+	It("skips failing nodes", func() {
+		nodes := []redis.ReadNode{{Addr: "a", Failing: true}, {Addr: "b"}}
+		Expect(route(redisroute.WeightedRoundRobin(nil), nodes, 4)).To(Equal(map[string]int{"b": 4}))
+	})
+})
+
+var _ = Describe("LeastOutstanding", func() {
+	It("routes to the node with the fewest outstanding requests", func() {
+		nodes := []redis.ReadNode{
+			{Addr: "a", Outstanding: 3},
+			{Addr: "b", Outstanding: 1},
+			{Addr: "c", Outstanding: 0, Failing: true},
+			{Addr: "d", Outstanding: 1},
+		}
+		counts := route(redisroute.LeastOutstanding(), nodes, 100)
+		Expect(counts).To(HaveLen(2))
+		Expect(counts["b"]).To(BeNumerically(">", 0))
+		Expect(counts["d"]).To(BeNumerically(">", 0))
+	})
+})
+
+var _ = Describe("ClusterClient with ReadRouter", func() {
+	var cluster *redistest.Cluster
+	var client *redis.ClusterClient
+	var mu sync.Mutex
+	var gets map[string]int
+
+	BeforeEach(func() {
+		var err error
+		cluster, err = redistest.NewCluster(1, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		replica := cluster.Nodes()[2].Addr()
+		gets = make(map[string]int)
+
+		opt := cluster.Options()
+		opt.ReadRouter = redisroute.Zone("a", redisroute.ZoneByAddr(map[string]string{replica: "a"}), nil)
+		opt.NewClient = func(opt *redis.Options) *redis.Client {
+			node := redis.NewClient(opt)
+			node.AddHook(countHook(func(name string) {
+				if name == "get" {
+					mu.Lock()
+					gets[opt.Addr]++
+					mu.Unlock()
+				}
+			}))
+			return node
+		}
+		client = redis.NewClusterClient(opt)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).To(Succeed())
+		Expect(cluster.Close()).To(Succeed())
+	})
+
+	It("routes the reads to the replica in the zone", func() {
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		for i := 0; i < 10; i++ {
+			Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		}
+		Expect(gets).To(Equal(map[string]int{cluster.Nodes()[2].Addr(): 10}))
+	})
+})
+
+type countHook func(name string)
+
+func (h countHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h countHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h(cmd.Name())
+		return next(ctx, cmd)
+	}
+}
+
+func (h countHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
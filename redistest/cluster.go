@@ -0,0 +1,404 @@
+package redistest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/internal/hashtag"
+)
+
+// NumSlots is the number of hash slots of a Redis Cluster.
+const NumSlots = 16384
+
+// KeySlot returns the hash slot of key.
+func KeySlot(key string) int {
+	return hashtag.Slot(key)
+}
+
+// Cluster is a simulated Redis Cluster of in-memory servers.
+//
+// The nodes share their data, so moving slots between masters or promoting
+// replicas doesn't copy keys, but each node only serves the slots assigned
+// to it and replies with MOVED and ASK redirects like redis-server does.
+// Tests change the topology with MoveSlots, the migration methods, Failover
+// and by closing nodes, and inject errors with FailSlot and SetDown.
+type Cluster struct {
+	st    *state
+	nodes []*ClusterNode
+
+	// Protected by st.mu.
+	slots      [NumSlots]*ClusterNode
+	migrations map[int]*migration
+	faults     map[int]*fault
+	down       bool
+}
+
+// ClusterNode is a node of a Cluster. Closing its Server kills the node,
+// which stays part of the topology until a replica takes over its slots.
+type ClusterNode struct {
+	*Server
+
+	// ID is the 40 characters node ID.
+	ID string
+
+	cluster *Cluster
+
+	// master is the master of a replica or nil. Protected by cluster.st.mu.
+	master *ClusterNode
+}
+
+type migration struct {
+	to   *ClusterNode
+	keys map[string]struct{} // keys that already moved to the target
+}
+
+type fault struct {
+	msg string
+	n   int
+}
+
+// NewCluster starts a cluster of masters that split the hash slots evenly,
+// each with the given number of replicas.
+func NewCluster(masters, replicas int) (*Cluster, error) {
+	if masters < 1 || replicas < 0 {
+		return nil, errors.New("redistest: cluster needs at least one master")
+	}
+
+	cl := &Cluster{
+		st:         newState(),
+		migrations: make(map[int]*migration),
+		faults:     make(map[int]*fault),
+	}
+	for i := 0; i < masters*(1+replicas); i++ {
+		node := &ClusterNode{
+			ID:      fmt.Sprintf("%040x", i+1),
+			cluster: cl,
+		}
+		if i >= masters {
+			node.master = cl.nodes[(i-masters)%masters]
+		}
+		srv, err := startServer("127.0.0.1:0", cl.st, node)
+		if err != nil {
+			_ = cl.Close()
+			return nil, err
+		}
+		node.Server = srv
+		cl.nodes = append(cl.nodes, node)
+	}
+	for slot := range cl.slots {
+		cl.slots[slot] = cl.nodes[slot*masters/NumSlots]
+	}
+	return cl, nil
+}
+
+// RunCluster starts a cluster for the test tb and closes it when the test finishes.
+func RunCluster(tb testing.TB, masters, replicas int) *Cluster {
+	tb.Helper()
+	cl, err := NewCluster(masters, replicas)
+	if err != nil {
+		tb.Fatalf("redistest: %s", err)
+	}
+	tb.Cleanup(func() {
+		_ = cl.Close()
+	})
+	return cl
+}
+
+// Addrs returns the addresses of all nodes.
+func (cl *Cluster) Addrs() []string {
+	addrs := make([]string, len(cl.nodes))
+	for i, node := range cl.nodes {
+		addrs[i] = node.Addr()
+	}
+	return addrs
+}
+
+// Options returns client options that connect to the cluster.
+func (cl *Cluster) Options() *redis.ClusterOptions {
+	return &redis.ClusterOptions{
+		Addrs:    cl.Addrs(),
+		Password: cl.getPassword(),
+	}
+}
+
+// UniversalOptions returns options for redis.NewUniversalClient
+// that connect to the cluster. It needs more than one node
+// for the universal client to create a cluster client.
+func (cl *Cluster) UniversalOptions() *redis.UniversalOptions {
+	return &redis.UniversalOptions{
+		Addrs:    cl.Addrs(),
+		Password: cl.getPassword(),
+	}
+}
+
+// RequireAuth makes the nodes require the password, see Server.RequireAuth.
+func (cl *Cluster) RequireAuth(password string) {
+	cl.st.mu.Lock()
+	cl.st.password = password
+	cl.st.mu.Unlock()
+}
+
+func (cl *Cluster) getPassword() string {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	return cl.st.password
+}
+
+// FastForward advances the clock of all nodes by d.
+func (cl *Cluster) FastForward(d time.Duration) {
+	cl.st.mu.Lock()
+	cl.st.offset += d
+	cl.st.mu.Unlock()
+}
+
+// FlushAll removes all keys from the cluster.
+func (cl *Cluster) FlushAll() {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	for i, d := range cl.st.dbs {
+		for key := range d.keys {
+			cl.nodes[0].touch(i, key)
+			delete(d.keys, key)
+		}
+	}
+}
+
+// Close closes all nodes.
+func (cl *Cluster) Close() error {
+	var firstErr error
+	for _, node := range cl.nodes {
+		if err := node.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Nodes returns all nodes of the cluster.
+func (cl *Cluster) Nodes() []*ClusterNode {
+	return append([]*ClusterNode(nil), cl.nodes...)
+}
+
+// Masters returns the nodes that are masters.
+func (cl *Cluster) Masters() []*ClusterNode {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	var masters []*ClusterNode
+	for _, node := range cl.nodes {
+		if node.master == nil {
+			masters = append(masters, node)
+		}
+	}
+	return masters
+}
+
+// SlotMaster returns the master that owns the slot.
+func (cl *Cluster) SlotMaster(slot int) *ClusterNode {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	return cl.slots[slot]
+}
+
+// MoveSlots assigns the slots to the master to. Nodes redirect the
+// commands for the slots with MOVED errors from then on.
+func (cl *Cluster) MoveSlots(to *ClusterNode, slots ...int) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	cl.mustBeMaster(to)
+	for _, slot := range slots {
+		cl.slots[slot] = to
+		delete(cl.migrations, slot)
+	}
+}
+
+// StartMigration starts moving the slot to the master to. Until the
+// migration finishes, the owner of the slot replies with ASK redirects
+// for keys that were migrated by MigrateKeys or don't exist, and with
+// TRYAGAIN to commands that use both migrated and remaining keys.
+func (cl *Cluster) StartMigration(slot int, to *ClusterNode) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	cl.mustBeMaster(to)
+	cl.migrations[slot] = &migration{
+		to:   to,
+		keys: make(map[string]struct{}),
+	}
+}
+
+// MigrateKeys moves the keys of a slot that is being migrated to the target.
+func (cl *Cluster) MigrateKeys(slot int, keys ...string) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	m := cl.migrations[slot]
+	if m == nil {
+		panic(fmt.Sprintf("redistest: slot %d isn't being migrated", slot))
+	}
+	for _, key := range keys {
+		m.keys[key] = struct{}{}
+	}
+}
+
+// FinishMigration assigns a slot that is being migrated to the target.
+func (cl *Cluster) FinishMigration(slot int) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	if m := cl.migrations[slot]; m != nil {
+		cl.slots[slot] = m.to
+		delete(cl.migrations, slot)
+	}
+}
+
+// FailSlot makes the nodes reply with the error msg, e.g.
+// "TRYAGAIN Multiple keys request during rehashing of slot",
+// to the next n commands for keys of the slot.
+func (cl *Cluster) FailSlot(slot, n int, msg string) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	if n <= 0 {
+		delete(cl.faults, slot)
+		return
+	}
+	cl.faults[slot] = &fault{msg: msg, n: n}
+}
+
+// SetDown makes all nodes reply with CLUSTERDOWN errors to commands
+// for keys while down is true.
+func (cl *Cluster) SetDown(down bool) {
+	cl.st.mu.Lock()
+	cl.down = down
+	cl.st.mu.Unlock()
+}
+
+// Failover promotes the replica to the master of the slots of its master,
+// which becomes a replica of it. The old master may have been closed.
+func (cl *Cluster) Failover(replica *ClusterNode) {
+	cl.st.mu.Lock()
+	defer cl.st.mu.Unlock()
+	old := replica.master
+	if old == nil {
+		panic(fmt.Sprintf("redistest: node %s isn't a replica", replica.Addr()))
+	}
+	for _, node := range cl.nodes {
+		if node == old || node.master == old {
+			node.master = replica
+		}
+	}
+	replica.master = nil
+	for slot, owner := range cl.slots {
+		if owner == old {
+			cl.slots[slot] = replica
+		}
+	}
+	for _, m := range cl.migrations {
+		if m.to == old {
+			m.to = replica
+		}
+	}
+}
+
+func (cl *Cluster) mustBeMaster(node *ClusterNode) {
+	if node.cluster != cl || node.master != nil {
+		panic(fmt.Sprintf("redistest: node %s isn't a master of the cluster", node.Addr()))
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// IsMaster reports whether the node is a master.
+func (n *ClusterNode) IsMaster() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.master == nil
+}
+
+// Master returns the master of a replica or nil.
+func (n *ClusterNode) Master() *ClusterNode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.master
+}
+
+// primary returns the node itself for masters and the master for replicas.
+func (n *ClusterNode) primary() *ClusterNode {
+	if n.master != nil {
+		return n.master
+	}
+	return n
+}
+
+func (n *ClusterNode) servesSlot(slot int) bool {
+	return n.cluster.slots[slot] == n.primary()
+}
+
+// route checks that the node serves the keys of the command args. Otherwise
+// it writes the redirect or error that redis-server replies with instead.
+func (n *ClusterNode) route(c *conn, cmd *command, args []string) bool {
+	asking := c.asking
+	c.asking = false
+
+	keys := cmd.keysOf(args)
+	if len(keys) == 0 {
+		return true
+	}
+	cl := n.cluster
+	if cl.down {
+		c.writeError("CLUSTERDOWN The cluster is down")
+		return false
+	}
+
+	slot := KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if KeySlot(key) != slot {
+			c.writeError("CROSSSLOT Keys in request don't hash to the same slot")
+			return false
+		}
+	}
+
+	if f := cl.faults[slot]; f != nil {
+		f.n--
+		if f.n == 0 {
+			delete(cl.faults, slot)
+		}
+		c.writeError(f.msg)
+		return false
+	}
+
+	owner := cl.slots[slot]
+	m := cl.migrations[slot]
+	switch {
+	case n.master != nil:
+		if n.master == owner && c.readOnly && cmd.flags&flagReadOnly != 0 {
+			return true
+		}
+	case owner == n:
+		if m == nil {
+			return true
+		}
+		var moved int
+		for _, key := range keys {
+			if _, ok := m.keys[key]; ok || c.currentDB().get(key, n.now()) == nil {
+				moved++
+			}
+		}
+		switch moved {
+		case 0:
+			return true
+		case len(keys):
+			c.writeErrorf("ASK %d %s", slot, m.to.Addr())
+		default:
+			c.writeError("TRYAGAIN Multiple keys request during rehashing of slot")
+		}
+		return false
+	case m != nil && m.to == n && asking:
+		// The keys that the target of a migration serves belong to it.
+		for _, key := range keys {
+			m.keys[key] = struct{}{}
+		}
+		return true
+	}
+	c.writeErrorf("MOVED %d %s", slot, owner.Addr())
+	return false
+}
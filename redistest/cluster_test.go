@@ -0,0 +1,173 @@
+package redistest_test
+
+import (
+	"fmt"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+var _ = Describe("Cluster", func() {
+	var cl *redistest.Cluster
+	var client *redis.ClusterClient
+
+	BeforeEach(func() {
+		var err error
+		cl, err = redistest.NewCluster(3, 1)
+		Expect(err).NotTo(HaveOccurred())
+		client = redis.NewClusterClient(cl.Options())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+		Expect(cl.Close()).NotTo(HaveOccurred())
+	})
+
+	// nodeClient connects to a single node of the cluster.
+	nodeClient := func(node *redistest.ClusterNode) *redis.Client {
+		return redis.NewClient(node.Options())
+	}
+
+	It("reports the topology", func() {
+		slots, err := client.ClusterSlots(ctx).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slots).To(HaveLen(3))
+		Expect(slots[0].Start).To(Equal(0))
+		Expect(slots[2].End).To(Equal(redistest.NumSlots - 1))
+		for _, slot := range slots {
+			Expect(slot.Nodes).To(HaveLen(2))
+			Expect(slot.Nodes[0].Addr).To(Equal(cl.SlotMaster(slot.Start).Addr()))
+		}
+
+		shards, err := client.ClusterShards(ctx).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shards).To(HaveLen(3))
+		Expect(shards[0].Nodes[0].Role).To(Equal("master"))
+		Expect(shards[0].Nodes[1].Role).To(Equal("replica"))
+
+		Expect(client.ClusterKeySlot(ctx, "{user}:1").Val()).To(Equal(int64(redistest.KeySlot("user"))))
+	})
+
+	It("routes commands by slot", func() {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key%d", i)
+			Expect(client.Set(ctx, key, i, 0).Err()).NotTo(HaveOccurred())
+			Expect(client.Get(ctx, key).Val()).To(Equal(fmt.Sprint(i)))
+		}
+
+		var total int64
+		for _, master := range cl.Masters() {
+			node := nodeClient(master)
+			n, err := node.DBSize(ctx).Result()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(BeNumerically(">", 0))
+			total += n
+			Expect(node.Close()).NotTo(HaveOccurred())
+		}
+		Expect(total).To(Equal(int64(100)))
+
+		err := client.MSet(ctx, "{a}1", "x", "{b}1", "y").Err()
+		Expect(err).To(MatchError(ContainSubstring("CROSSSLOT")))
+	})
+
+	It("redirects moved slots", func() {
+		slot := redistest.KeySlot("key")
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+
+		from := cl.SlotMaster(slot)
+		to := cl.Masters()[(slot*3/redistest.NumSlots+1)%3]
+		cl.MoveSlots(to, slot)
+
+		node := nodeClient(from)
+		defer node.Close()
+		Expect(node.Get(ctx, "key").Err()).To(MatchError(fmt.Sprintf("MOVED %d %s", slot, to.Addr())))
+
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+		Expect(client.Incr(ctx, "{key}counter").Val()).To(Equal(int64(1)))
+	})
+
+	It("redirects migrated keys with ASK", func() {
+		slot := redistest.KeySlot("{tag}old")
+		Expect(client.Set(ctx, "{tag}old", "1", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Set(ctx, "{tag}moved", "2", 0).Err()).NotTo(HaveOccurred())
+
+		from := cl.SlotMaster(slot)
+		to := cl.Masters()[(slot*3/redistest.NumSlots+1)%3]
+		cl.StartMigration(slot, to)
+		cl.MigrateKeys(slot, "{tag}moved")
+
+		node := nodeClient(from)
+		defer node.Close()
+		Expect(node.Get(ctx, "{tag}moved").Err()).To(MatchError(fmt.Sprintf("ASK %d %s", slot, to.Addr())))
+		Expect(node.MGet(ctx, "{tag}old", "{tag}moved").Err()).To(MatchError(ContainSubstring("TRYAGAIN")))
+
+		Expect(client.Get(ctx, "{tag}old").Val()).To(Equal("1"))
+		Expect(client.Get(ctx, "{tag}moved").Val()).To(Equal("2"))
+		Expect(client.Set(ctx, "{tag}new", "3", 0).Err()).NotTo(HaveOccurred())
+
+		cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Get(ctx, "{tag}old")
+			pipe.Get(ctx, "{tag}new")
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmds[1].(*redis.StringCmd).Val()).To(Equal("3"))
+
+		cl.FinishMigration(slot)
+		Expect(cl.SlotMaster(slot)).To(Equal(to))
+		Expect(client.Get(ctx, "{tag}old").Val()).To(Equal("1"))
+	})
+
+	It("injects errors", func() {
+		slot := redistest.KeySlot("key")
+		cl.FailSlot(slot, 2, "TRYAGAIN Multiple keys request during rehashing of slot")
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+
+		cl.SetDown(true)
+		Expect(client.Get(ctx, "key").Err()).To(MatchError("CLUSTERDOWN The cluster is down"))
+		cl.SetDown(false)
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+	})
+
+	It("fails over to replicas", func() {
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+
+		master := cl.SlotMaster(redistest.KeySlot("key"))
+		var replica *redistest.ClusterNode
+		for _, node := range cl.Nodes() {
+			if node.Master() == master {
+				replica = node
+			}
+		}
+		Expect(replica).NotTo(BeNil())
+
+		node := nodeClient(replica)
+		defer node.Close()
+		Expect(node.Get(ctx, "key").Err()).To(MatchError(ContainSubstring("MOVED")))
+
+		Expect(master.Close()).NotTo(HaveOccurred())
+		cl.Failover(replica)
+		Expect(replica.IsMaster()).To(BeTrue())
+		client.ReloadState(ctx)
+
+		Eventually(func() (string, error) {
+			return client.Get(ctx, "key").Result()
+		}).Should(Equal("value"))
+
+		Expect(master.Restart()).NotTo(HaveOccurred())
+		Expect(master.Master()).To(Equal(replica))
+	})
+
+	It("serves reads from replicas", func() {
+		opt := cl.Options()
+		opt.ReadOnly = true
+		ro := redis.NewClusterClient(opt)
+		defer ro.Close()
+
+		Expect(ro.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		Expect(ro.Get(ctx, "key").Val()).To(Equal("value"))
+	})
+})
@@ -0,0 +1,270 @@
+package redistest
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+const errClusterDisabled = "ERR This instance has cluster support disabled"
+
+func cmdAsking(c *conn, args []string) {
+	if c.srv.node == nil {
+		c.writeError(errClusterDisabled)
+		return
+	}
+	c.asking = true
+	c.writeOK()
+}
+
+func cmdReadOnly(c *conn, args []string) {
+	if c.srv.node == nil {
+		c.writeError(errClusterDisabled)
+		return
+	}
+	c.readOnly = true
+	c.writeOK()
+}
+
+func cmdReadWrite(c *conn, args []string) {
+	if c.srv.node == nil {
+		c.writeError(errClusterDisabled)
+		return
+	}
+	c.readOnly = false
+	c.writeOK()
+}
+
+func cmdCluster(c *conn, args []string) {
+	node := c.srv.node
+	if node == nil {
+		c.writeError(errClusterDisabled)
+		return
+	}
+	cl := node.cluster
+
+	switch sub := strings.ToLower(args[0]); sub {
+	case "slots":
+		writeClusterSlots(c, cl)
+	case "shards":
+		writeClusterShards(c, cl)
+	case "nodes":
+		writeClusterNodes(c, cl, node)
+	case "info":
+		writeClusterInfo(c, cl)
+	case "myid":
+		c.writeBulk(node.ID)
+	case "keyslot":
+		if len(args) != 2 {
+			c.writeErrorf("ERR wrong number of arguments for 'cluster|%s' command", sub)
+			return
+		}
+		c.writeInt(int64(KeySlot(args[1])))
+	case "countkeysinslot", "getkeysinslot":
+		if (sub == "countkeysinslot" && len(args) != 2) || (sub == "getkeysinslot" && len(args) != 3) {
+			c.writeErrorf("ERR wrong number of arguments for 'cluster|%s' command", sub)
+			return
+		}
+		slot, ok := parseInt(args[1])
+		if !ok || slot < 0 || slot >= NumSlots {
+			c.writeError("ERR Invalid slot")
+			return
+		}
+		count := int64(-1)
+		if sub == "getkeysinslot" {
+			if count, ok = parseInt(args[2]); !ok || count < 0 {
+				c.writeError("ERR Invalid number of keys")
+				return
+			}
+		}
+		var keys []string
+		for _, key := range c.currentDB().sortedKeys(c.srv.now()) {
+			if KeySlot(key) == int(slot) {
+				keys = append(keys, key)
+			}
+		}
+		if sub == "countkeysinslot" {
+			c.writeInt(int64(len(keys)))
+			return
+		}
+		if int64(len(keys)) > count {
+			keys = keys[:count]
+		}
+		c.writeStrings(keys)
+	default:
+		c.writeErrorf("ERR unknown subcommand '%s'. Try CLUSTER HELP.", args[0])
+	}
+}
+
+type slotRange struct {
+	start, end int
+	master     *ClusterNode
+}
+
+// slotRanges returns the ranges of slots that are served by the same master.
+func (cl *Cluster) slotRanges() []slotRange {
+	var ranges []slotRange
+	for slot, master := range cl.slots {
+		if n := len(ranges); n > 0 && ranges[n-1].master == master {
+			ranges[n-1].end = slot
+			continue
+		}
+		ranges = append(ranges, slotRange{start: slot, end: slot, master: master})
+	}
+	return ranges
+}
+
+// replicas returns the replicas of the master.
+func (cl *Cluster) replicas(master *ClusterNode) []*ClusterNode {
+	var replicas []*ClusterNode
+	for _, node := range cl.nodes {
+		if node.master == master {
+			replicas = append(replicas, node)
+		}
+	}
+	return replicas
+}
+
+func splitAddr(addr string) (string, int) {
+	host, port, _ := net.SplitHostPort(addr)
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+func writeClusterSlots(c *conn, cl *Cluster) {
+	ranges := cl.slotRanges()
+	c.writeArrayLen(len(ranges))
+	for _, r := range ranges {
+		nodes := append([]*ClusterNode{r.master}, cl.replicas(r.master)...)
+		c.writeArrayLen(2 + len(nodes))
+		c.writeInt(int64(r.start))
+		c.writeInt(int64(r.end))
+		for _, node := range nodes {
+			host, port := splitAddr(node.Addr())
+			c.writeArrayLen(3)
+			c.writeBulk(host)
+			c.writeInt(int64(port))
+			c.writeBulk(node.ID)
+		}
+	}
+}
+
+func writeClusterShards(c *conn, cl *Cluster) {
+	var masters []*ClusterNode
+	for _, node := range cl.nodes {
+		if node.master == nil {
+			masters = append(masters, node)
+		}
+	}
+	ranges := cl.slotRanges()
+
+	c.writeArrayLen(len(masters))
+	for _, master := range masters {
+		var slots []int
+		for _, r := range ranges {
+			if r.master == master {
+				slots = append(slots, r.start, r.end)
+			}
+		}
+		nodes := append([]*ClusterNode{master}, cl.replicas(master)...)
+
+		c.writeMapLen(2)
+		c.writeBulk("slots")
+		c.writeArrayLen(len(slots))
+		for _, slot := range slots {
+			c.writeInt(int64(slot))
+		}
+		c.writeBulk("nodes")
+		c.writeArrayLen(len(nodes))
+		for _, node := range nodes {
+			host, port := splitAddr(node.Addr())
+			role, health := "master", "online"
+			if node.master != nil {
+				role = "replica"
+			}
+			if node.closed {
+				health = "fail"
+			}
+			c.writeMapLen(7)
+			c.writeBulk("id")
+			c.writeBulk(node.ID)
+			c.writeBulk("port")
+			c.writeInt(int64(port))
+			c.writeBulk("ip")
+			c.writeBulk(host)
+			c.writeBulk("endpoint")
+			c.writeBulk(host)
+			c.writeBulk("role")
+			c.writeBulk(role)
+			c.writeBulk("replication-offset")
+			c.writeInt(0)
+			c.writeBulk("health")
+			c.writeBulk(health)
+		}
+	}
+}
+
+func writeClusterNodes(c *conn, cl *Cluster, myself *ClusterNode) {
+	ranges := cl.slotRanges()
+
+	var b strings.Builder
+	for _, node := range cl.nodes {
+		var flags []string
+		if node == myself {
+			flags = append(flags, "myself")
+		}
+		master := "-"
+		if node.master != nil {
+			flags = append(flags, "slave")
+			master = node.master.ID
+		} else {
+			flags = append(flags, "master")
+		}
+		link := "connected"
+		if node.closed {
+			flags = append(flags, "fail")
+			link = "disconnected"
+		}
+		_, port := splitAddr(node.Addr())
+
+		b.WriteString(node.ID + " " + node.Addr() + "@" + strconv.Itoa(port+10000) + " " +
+			strings.Join(flags, ",") + " " + master + " 0 0 1 " + link)
+		for _, r := range ranges {
+			if r.master != node {
+				continue
+			}
+			if r.start == r.end {
+				b.WriteString(" " + strconv.Itoa(r.start))
+			} else {
+				b.WriteString(" " + strconv.Itoa(r.start) + "-" + strconv.Itoa(r.end))
+			}
+		}
+		for slot, m := range cl.migrations {
+			switch node {
+			case cl.slots[slot]:
+				b.WriteString(" [" + strconv.Itoa(slot) + "->-" + m.to.ID + "]")
+			case m.to:
+				b.WriteString(" [" + strconv.Itoa(slot) + "-<-" + cl.slots[slot].ID + "]")
+			}
+		}
+		b.WriteString("\n")
+	}
+	c.writeBulk(b.String())
+}
+
+func writeClusterInfo(c *conn, cl *Cluster) {
+	state := "ok"
+	if cl.down {
+		state = "fail"
+	}
+	var size int
+	for _, node := range cl.nodes {
+		if node.master == nil {
+			size++
+		}
+	}
+	c.writeBulk("cluster_state:" + state + "\r\n" +
+		"cluster_slots_assigned:" + strconv.Itoa(NumSlots) + "\r\n" +
+		"cluster_known_nodes:" + strconv.Itoa(len(cl.nodes)) + "\r\n" +
+		"cluster_size:" + strconv.Itoa(size) + "\r\n")
+}
@@ -0,0 +1,302 @@
+package redistest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func cmdAuth(c *conn, args []string) {
+	if len(args) > 2 {
+		c.writeError(errSyntax)
+		return
+	}
+	password := args[len(args)-1]
+	if c.srv.password == "" {
+		c.writeError("ERR AUTH <password> called without any password configured for the default user. " +
+			"Are you sure your configuration is correct?")
+		return
+	}
+	if password != c.srv.password {
+		c.writeError(errWrongPass)
+		return
+	}
+	c.authed = true
+	c.writeOK()
+}
+
+func cmdHello(c *conn, args []string) {
+	protocol := c.protocol
+	if len(args) > 0 {
+		n, ok := parseInt(args[0])
+		if !ok {
+			c.writeError("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if n != 2 && n != 3 {
+			c.writeError("NOPROTO unsupported protocol version")
+			return
+		}
+		protocol = int(n)
+		args = args[1:]
+	}
+
+	var name string
+	var setName bool
+	for len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "auth":
+			if len(args) < 3 {
+				c.writeError(errSyntax)
+				return
+			}
+			if c.srv.password != "" && args[2] != c.srv.password {
+				c.writeError(errWrongPass)
+				return
+			}
+			c.authed = true
+			args = args[3:]
+		case "setname":
+			if len(args) < 2 {
+				c.writeError(errSyntax)
+				return
+			}
+			name, setName = args[1], true
+			args = args[2:]
+		default:
+			c.writeErrorf("ERR Syntax error in HELLO option '%s'", args[0])
+			return
+		}
+	}
+	if c.srv.password != "" && !c.authed {
+		c.writeError(errNoAuth)
+		return
+	}
+
+	c.protocol = protocol
+	if setName {
+		c.name = name
+	}
+
+	c.writeMapLen(7)
+	c.writeBulk("server")
+	c.writeBulk("redis")
+	c.writeBulk("version")
+	c.writeBulk("7.2.0")
+	c.writeBulk("proto")
+	c.writeInt(int64(c.protocol))
+	c.writeBulk("id")
+	c.writeInt(c.id)
+	mode, role := "standalone", "master"
+	if node := c.srv.node; node != nil {
+		mode = "cluster"
+		if node.master != nil {
+			role = "replica"
+		}
+	}
+	c.writeBulk("mode")
+	c.writeBulk(mode)
+	c.writeBulk("role")
+	c.writeBulk(role)
+	c.writeBulk("modules")
+	c.writeArrayLen(0)
+}
+
+func cmdClient(c *conn, args []string) {
+	switch strings.ToLower(args[0]) {
+	case "id":
+		c.writeInt(c.id)
+	case "getname":
+		if c.name == "" {
+			c.writeNull()
+			return
+		}
+		c.writeBulk(c.name)
+	case "setname":
+		if len(args) != 2 {
+			c.writeError(errSyntax)
+			return
+		}
+		if strings.ContainsAny(args[1], " \n") {
+			c.writeError("ERR Client names cannot contain spaces, newlines or special characters.")
+			return
+		}
+		c.name = args[1]
+		c.writeOK()
+	case "setinfo":
+		if len(args) != 3 {
+			c.writeError(errSyntax)
+			return
+		}
+		c.writeOK()
+	default:
+		c.writeErrorf("ERR unknown subcommand '%s'. Try CLIENT HELP.", args[0])
+	}
+}
+
+func cmdEcho(c *conn, args []string) {
+	c.writeBulk(args[0])
+}
+
+func cmdPing(c *conn, args []string) {
+	if len(args) > 1 {
+		c.writeError("ERR wrong number of arguments for 'ping' command")
+		return
+	}
+	if c.protocol == 2 && c.subscriptions() > 0 {
+		c.writeArrayLen(2)
+		c.writeBulk("pong")
+		if len(args) == 1 {
+			c.writeBulk(args[0])
+		} else {
+			c.writeBulk("")
+		}
+		return
+	}
+	if len(args) == 1 {
+		c.writeBulk(args[0])
+		return
+	}
+	c.writeStatus("PONG")
+}
+
+func cmdQuit(c *conn, args []string) {
+	c.writeOK()
+}
+
+func cmdReset(c *conn, args []string) {
+	c.discard()
+	c.unwatch()
+	c.unsubscribeAll()
+	c.protocol = 2
+	c.db = 0
+	c.name = ""
+	c.authed = false
+	c.readOnly = false
+	c.asking = false
+	c.writeStatus("RESET")
+}
+
+func cmdSelect(c *conn, args []string) {
+	n, ok := c.intArg(args[0])
+	if !ok {
+		return
+	}
+	if n < 0 || n >= numDBs {
+		c.writeError("ERR DB index is out of range")
+		return
+	}
+	if c.srv.node != nil && n != 0 {
+		c.writeError("ERR SELECT is not allowed in cluster mode")
+		return
+	}
+	c.db = int(n)
+	c.writeOK()
+}
+
+//------------------------------------------------------------------------------
+
+func cmdDBSize(c *conn, args []string) {
+	c.writeInt(int64(len(c.keys())))
+}
+
+func cmdFlushAll(c *conn, args []string) {
+	if !flushArgs(c, args) {
+		return
+	}
+	for i := range c.srv.dbs {
+		c.srv.flushDB(i)
+	}
+	c.writeOK()
+}
+
+func cmdFlushDB(c *conn, args []string) {
+	if !flushArgs(c, args) {
+		return
+	}
+	c.srv.flushDB(c.db)
+	c.writeOK()
+}
+
+func flushArgs(c *conn, args []string) bool {
+	if len(args) > 1 {
+		c.writeError(errSyntax)
+		return false
+	}
+	if len(args) == 1 {
+		switch strings.ToLower(args[0]) {
+		case "async", "sync":
+		default:
+			c.writeError(errSyntax)
+			return false
+		}
+	}
+	return true
+}
+
+func cmdCommand(c *conn, args []string) {
+	if len(args) == 0 {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.writeArrayLen(len(names))
+		for _, name := range names {
+			writeCommandInfo(c, name, commands[name])
+		}
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "count":
+		c.writeInt(int64(len(commands)))
+	case "info":
+		c.writeArrayLen(len(args) - 1)
+		for _, name := range args[1:] {
+			name = strings.ToLower(name)
+			if cmd, ok := commands[name]; ok {
+				writeCommandInfo(c, name, cmd)
+			} else {
+				c.writeNullArray()
+			}
+		}
+	default:
+		c.writeErrorf("ERR unknown subcommand '%s'. Try COMMAND HELP.", args[0])
+	}
+}
+
+// writeCommandInfo writes the name, arity, flags and key positions of cmd.
+func writeCommandInfo(c *conn, name string, cmd *command) {
+	var flags []string
+	switch {
+	case cmd.flags&flagReadOnly != 0:
+		flags = append(flags, "readonly")
+	case cmd.keys.first != 0:
+		flags = append(flags, "write")
+	}
+	if cmd.flags&flagPubSub != 0 {
+		flags = append(flags, "pubsub")
+	}
+	if cmd.flags&flagNoAuth != 0 {
+		flags = append(flags, "no_auth")
+	}
+
+	c.writeArrayLen(6)
+	c.writeBulk(name)
+	c.writeInt(int64(cmd.arity))
+	c.writeSetLen(len(flags))
+	for _, flag := range flags {
+		c.writeStatus(flag)
+	}
+	c.writeInt(int64(cmd.keys.first))
+	c.writeInt(int64(cmd.keys.last))
+	c.writeInt(int64(cmd.keys.step))
+}
+
+func cmdTime(c *conn, args []string) {
+	now := c.srv.now()
+	c.writeArrayLen(2)
+	c.writeBulk(strconv.FormatInt(now.Unix(), 10))
+	c.writeBulk(strconv.Itoa(now.Nanosecond() / 1000))
+}
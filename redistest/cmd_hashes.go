@@ -0,0 +1,241 @@
+package redistest
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+func cmdHSet(c *conn, args []string) {
+	if len(args)%2 != 1 {
+		c.writeError("ERR wrong number of arguments for 'hset' command")
+		return
+	}
+	h, ok := c.hashValue(args[0], true)
+	if !ok {
+		return
+	}
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := h[args[i]]; !ok {
+			n++
+		}
+		h[args[i]] = args[i+1]
+	}
+	c.modified(args[0])
+	c.writeInt(n)
+}
+
+func cmdHMSet(c *conn, args []string) {
+	if len(args)%2 != 1 {
+		c.writeError("ERR wrong number of arguments for 'hmset' command")
+		return
+	}
+	h, ok := c.hashValue(args[0], true)
+	if !ok {
+		return
+	}
+	for i := 1; i < len(args); i += 2 {
+		h[args[i]] = args[i+1]
+	}
+	c.modified(args[0])
+	c.writeOK()
+}
+
+func cmdHSetNX(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], true)
+	if !ok {
+		return
+	}
+	if _, ok := h[args[1]]; ok {
+		c.writeInt(0)
+		return
+	}
+	h[args[1]] = args[2]
+	c.modified(args[0])
+	c.writeInt(1)
+}
+
+func cmdHGet(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	val, ok := h[args[1]]
+	if !ok {
+		c.writeNull()
+		return
+	}
+	c.writeBulk(val)
+}
+
+func cmdHMGet(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeArrayLen(len(args) - 1)
+	for _, field := range args[1:] {
+		if val, ok := h[field]; ok {
+			c.writeBulk(val)
+		} else {
+			c.writeNull()
+		}
+	}
+}
+
+func cmdHGetAll(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	fields := h.fields()
+	c.writeMapLen(len(fields))
+	for _, field := range fields {
+		c.writeBulk(field)
+		c.writeBulk(h[field])
+	}
+}
+
+func (h hashValue) fields() []string {
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func cmdHKeys(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeStrings(h.fields())
+}
+
+func cmdHVals(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	fields := h.fields()
+	c.writeArrayLen(len(fields))
+	for _, field := range fields {
+		c.writeBulk(h[field])
+	}
+}
+
+func cmdHLen(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeInt(int64(len(h)))
+}
+
+func cmdHExists(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	_, ok = h[args[1]]
+	c.writeBool(ok)
+}
+
+func cmdHDel(c *conn, args []string) {
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, field := range args[1:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	if n > 0 {
+		c.modified(args[0])
+	}
+	c.writeInt(n)
+}
+
+func cmdHIncrBy(c *conn, args []string) {
+	by, ok := c.intArg(args[2])
+	if !ok {
+		return
+	}
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	var n int64
+	if val, exists := h[args[1]]; exists {
+		if n, ok = parseInt(val); !ok {
+			c.writeError("ERR hash value is not an integer")
+			return
+		}
+	}
+	if by > 0 && n > math.MaxInt64-by || by < 0 && n < math.MinInt64-by {
+		c.writeError("ERR increment or decrement would overflow")
+		return
+	}
+	n += by
+	h, _ = c.hashValue(args[0], true)
+	h[args[1]] = strconv.FormatInt(n, 10)
+	c.modified(args[0])
+	c.writeInt(n)
+}
+
+func cmdHIncrByFloat(c *conn, args []string) {
+	by, ok := c.floatArg(args[2])
+	if !ok {
+		return
+	}
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+	var f float64
+	if val, exists := h[args[1]]; exists {
+		if f, ok = parseFloat(val); !ok {
+			c.writeError("ERR hash value is not a float")
+			return
+		}
+	}
+	f += by
+	if math.IsInf(f, 0) {
+		c.writeError("ERR increment would produce NaN or Infinity")
+		return
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	h, _ = c.hashValue(args[0], true)
+	h[args[1]] = s
+	c.modified(args[0])
+	c.writeBulk(s)
+}
+
+func cmdHScan(c *conn, args []string) {
+	opt, ok := parseScan(c, args[1:], "hscan")
+	if !ok {
+		return
+	}
+	h, ok := c.hashValue(args[0], false)
+	if !ok {
+		return
+	}
+
+	page, next := opt.page(h.fields())
+	var elems []string
+	for _, field := range page {
+		if opt.match != "" && !matchGlob(opt.match, field) {
+			continue
+		}
+		elems = append(elems, field)
+		if !opt.noValues {
+			elems = append(elems, h[field])
+		}
+	}
+	writeScan(c, next, elems)
+}
@@ -0,0 +1,318 @@
+package redistest
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func cmdDel(c *conn, args []string) {
+	var n int64
+	for _, key := range args {
+		if c.del(key) {
+			n++
+		}
+	}
+	c.writeInt(n)
+}
+
+func cmdExists(c *conn, args []string) {
+	var n int64
+	for _, key := range args {
+		if c.currentDB().get(key, c.srv.now()) != nil {
+			n++
+		}
+	}
+	c.writeInt(n)
+}
+
+func cmdType(c *conn, args []string) {
+	e := c.currentDB().get(args[0], c.srv.now())
+	if e == nil {
+		c.writeStatus("none")
+		return
+	}
+	c.writeStatus(typeName(e.val))
+}
+
+func cmdKeys(c *conn, args []string) {
+	var keys []string
+	for _, key := range c.keys() {
+		if matchGlob(args[0], key) {
+			keys = append(keys, key)
+		}
+	}
+	c.writeStrings(keys)
+}
+
+func cmdRename(c *conn, args []string) {
+	rename(c, args[0], args[1], false)
+}
+
+func cmdRenameNX(c *conn, args []string) {
+	rename(c, args[0], args[1], true)
+}
+
+func rename(c *conn, from, to string, nx bool) {
+	d := c.currentDB()
+	now := c.srv.now()
+	e := d.get(from, now)
+	if e == nil {
+		c.writeError(errNoSuchKey)
+		return
+	}
+	if nx && d.get(to, now) != nil {
+		c.writeInt(0)
+		return
+	}
+	if from != to {
+		delete(d.keys, from)
+		d.keys[to] = e
+		c.srv.touch(c.db, from)
+		c.srv.touch(c.db, to)
+	}
+	if nx {
+		c.writeInt(1)
+		return
+	}
+	c.writeOK()
+}
+
+//------------------------------------------------------------------------------
+
+func cmdExpire(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	expire(c, args[0], c.srv.now().Add(time.Duration(n)*time.Second), args[2:])
+}
+
+func cmdPExpire(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	expire(c, args[0], c.srv.now().Add(time.Duration(n)*time.Millisecond), args[2:])
+}
+
+func cmdExpireAt(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	expire(c, args[0], time.Unix(n, 0), args[2:])
+}
+
+func cmdPExpireAt(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	expire(c, args[0], time.UnixMilli(n), args[2:])
+}
+
+func expire(c *conn, key string, at time.Time, opts []string) {
+	var nx, xx, gt, lt bool
+	for _, opt := range opts {
+		switch strings.ToLower(opt) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "gt":
+			gt = true
+		case "lt":
+			lt = true
+		default:
+			c.writeErrorf("ERR Unsupported option %s", opt)
+			return
+		}
+	}
+	if nx && (xx || gt || lt) || gt && lt {
+		c.writeError("ERR NX and XX, GT or LT options at the same time are not compatible")
+		return
+	}
+
+	e := c.currentDB().get(key, c.srv.now())
+	if e == nil {
+		c.writeInt(0)
+		return
+	}
+	// Keys without a time to live have an infinite one for GT and LT.
+	persistent := e.expireAt.IsZero()
+	if nx && !persistent || xx && persistent ||
+		gt && (persistent || !at.After(e.expireAt)) ||
+		lt && !persistent && !at.Before(e.expireAt) {
+		c.writeInt(0)
+		return
+	}
+
+	if !at.After(c.srv.now()) {
+		c.del(key)
+	} else {
+		e.expireAt = at
+		c.srv.touch(c.db, key)
+	}
+	c.writeInt(1)
+}
+
+func cmdPersist(c *conn, args []string) {
+	e := c.currentDB().get(args[0], c.srv.now())
+	if e == nil || e.expireAt.IsZero() {
+		c.writeInt(0)
+		return
+	}
+	e.expireAt = time.Time{}
+	c.srv.touch(c.db, args[0])
+	c.writeInt(1)
+}
+
+func cmdTTL(c *conn, args []string) {
+	ttl(c, args[0], time.Second)
+}
+
+func cmdPTTL(c *conn, args []string) {
+	ttl(c, args[0], time.Millisecond)
+}
+
+func ttl(c *conn, key string, unit time.Duration) {
+	now := c.srv.now()
+	e := c.currentDB().get(key, now)
+	switch {
+	case e == nil:
+		c.writeInt(-2)
+	case e.expireAt.IsZero():
+		c.writeInt(-1)
+	default:
+		// Round up like redis-server, so keys that exist have a positive TTL.
+		d := e.expireAt.Sub(now)
+		c.writeInt(int64((d + unit - 1) / unit))
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type scanOptions struct {
+	cursor   uint64
+	match    string
+	count    int
+	typ      string
+	noValues bool
+}
+
+// parseScan parses the cursor and options of SCAN, HSCAN, SSCAN and ZSCAN.
+// Only SCAN supports TYPE and only HSCAN supports NOVALUES.
+func parseScan(c *conn, args []string, cmd string) (scanOptions, bool) {
+	opt := scanOptions{count: 10}
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		c.writeError("ERR invalid cursor")
+		return opt, false
+	}
+	opt.cursor = cursor
+
+	for args = args[1:]; len(args) > 0; {
+		name := strings.ToLower(args[0])
+		if name == "novalues" && cmd == "hscan" {
+			opt.noValues = true
+			args = args[1:]
+			continue
+		}
+		if len(args) < 2 {
+			c.writeError(errSyntax)
+			return scanOptions{}, false
+		}
+
+		switch {
+		case name == "match":
+			opt.match = args[1]
+		case name == "count":
+			n, ok := parseInt(args[1])
+			if !ok {
+				c.writeError(errNotInt)
+				return scanOptions{}, false
+			}
+			if n < 1 {
+				c.writeError(errSyntax)
+				return scanOptions{}, false
+			}
+			opt.count = int(n)
+		case name == "type" && cmd == "scan":
+			opt.typ = strings.ToLower(args[1])
+		default:
+			c.writeError(errSyntax)
+			return scanOptions{}, false
+		}
+		args = args[2:]
+	}
+	return opt, true
+}
+
+// page returns the elements of one SCAN call and the next cursor. The
+// elements are ordered by a hash, and the cursor is the hash to continue
+// from, so elements that exist during the whole iteration are returned even
+// if others are added or removed in between, like redis-server guarantees.
+func (opt scanOptions) page(elems []string) ([]string, uint64) {
+	sort.Slice(elems, func(i, j int) bool {
+		pi, pj := scanPos(elems[i]), scanPos(elems[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return elems[i] < elems[j]
+	})
+
+	start := sort.Search(len(elems), func(i int) bool {
+		return scanPos(elems[i]) >= opt.cursor
+	})
+	end := start + opt.count
+	if end >= len(elems) {
+		return elems[start:], 0
+	}
+	// Don't split elements with the same hash between calls.
+	last := scanPos(elems[end-1])
+	for end < len(elems) && scanPos(elems[end]) == last {
+		end++
+	}
+	if end == len(elems) {
+		return elems[start:], 0
+	}
+	return elems[start:end], last + 1
+}
+
+// scanPos returns the position of s in a SCAN. It is never 0,
+// the cursor that starts an iteration.
+func scanPos(s string) uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return uint64(h.Sum32()) + 1
+}
+
+func writeScan(c *conn, next uint64, elems []string) {
+	c.writeArrayLen(2)
+	c.writeBulk(strconv.FormatUint(next, 10))
+	c.writeStrings(elems)
+}
+
+func cmdScan(c *conn, args []string) {
+	opt, ok := parseScan(c, args, "scan")
+	if !ok {
+		return
+	}
+
+	d := c.currentDB()
+	page, next := opt.page(c.keys())
+	keys := make([]string, 0, len(page))
+	for _, key := range page {
+		if opt.match != "" && !matchGlob(opt.match, key) {
+			continue
+		}
+		if opt.typ != "" && typeName(d.keys[key].val) != opt.typ {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	writeScan(c, next, keys)
+}
@@ -0,0 +1,261 @@
+package redistest
+
+func cmdLPush(c *conn, args []string) {
+	push(c, args, true, false)
+}
+
+func cmdRPush(c *conn, args []string) {
+	push(c, args, false, false)
+}
+
+func cmdLPushX(c *conn, args []string) {
+	push(c, args, true, true)
+}
+
+func cmdRPushX(c *conn, args []string) {
+	push(c, args, false, true)
+}
+
+func push(c *conn, args []string, left, exists bool) {
+	l, ok := c.listValue(args[0], !exists)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeInt(0)
+		return
+	}
+	for _, val := range args[1:] {
+		if left {
+			l.elems = append([]string{val}, l.elems...)
+		} else {
+			l.elems = append(l.elems, val)
+		}
+	}
+	c.modified(args[0])
+	c.writeInt(int64(len(l.elems)))
+}
+
+func cmdLPop(c *conn, args []string) {
+	pop(c, args, true)
+}
+
+func cmdRPop(c *conn, args []string) {
+	pop(c, args, false)
+}
+
+func pop(c *conn, args []string, left bool) {
+	if len(args) > 2 {
+		c.writeError(errSyntax)
+		return
+	}
+	count := int64(1)
+	if len(args) == 2 {
+		var ok bool
+		if count, ok = parseInt(args[1]); !ok || count < 0 {
+			c.writeError("ERR value is out of range, must be positive")
+			return
+		}
+	}
+
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		if len(args) == 2 {
+			c.writeNullArray()
+		} else {
+			c.writeNull()
+		}
+		return
+	}
+
+	if count > int64(len(l.elems)) {
+		count = int64(len(l.elems))
+	}
+	popped := make([]string, count)
+	for i := range popped {
+		if left {
+			popped[i] = l.elems[0]
+			l.elems = l.elems[1:]
+		} else {
+			popped[i] = l.elems[len(l.elems)-1]
+			l.elems = l.elems[:len(l.elems)-1]
+		}
+	}
+	if count > 0 {
+		c.modified(args[0])
+	}
+
+	if len(args) == 2 {
+		c.writeStrings(popped)
+		return
+	}
+	c.writeBulk(popped[0])
+}
+
+func cmdLLen(c *conn, args []string) {
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeInt(0)
+		return
+	}
+	c.writeInt(int64(len(l.elems)))
+}
+
+func cmdLRange(c *conn, args []string) {
+	start, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	stop, ok := c.intArg(args[2])
+	if !ok {
+		return
+	}
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeStrings(nil)
+		return
+	}
+	lo, hi, ok := normalizeRange(start, stop, len(l.elems))
+	if !ok {
+		c.writeStrings(nil)
+		return
+	}
+	c.writeStrings(l.elems[lo : hi+1])
+}
+
+// listIndex resolves the negative index i of l.
+func listIndex(l *listValue, i int64) (int, bool) {
+	if i < 0 {
+		i += int64(len(l.elems))
+	}
+	if i < 0 || i >= int64(len(l.elems)) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+func cmdLIndex(c *conn, args []string) {
+	i, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeNull()
+		return
+	}
+	idx, ok := listIndex(l, i)
+	if !ok {
+		c.writeNull()
+		return
+	}
+	c.writeBulk(l.elems[idx])
+}
+
+func cmdLSet(c *conn, args []string) {
+	i, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeError(errNoSuchKey)
+		return
+	}
+	idx, ok := listIndex(l, i)
+	if !ok {
+		c.writeError(errOutOfRange)
+		return
+	}
+	l.elems[idx] = args[2]
+	c.modified(args[0])
+	c.writeOK()
+}
+
+func cmdLRem(c *conn, args []string) {
+	count, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeInt(0)
+		return
+	}
+
+	// Negative counts remove elements from the tail.
+	reverse := count < 0
+	if reverse {
+		count = -count
+		reverseStrings(l.elems)
+	}
+	var n int64
+	elems := l.elems[:0]
+	for _, elem := range l.elems {
+		if elem == args[2] && (count == 0 || n < count) {
+			n++
+			continue
+		}
+		elems = append(elems, elem)
+	}
+	l.elems = elems
+	if reverse {
+		reverseStrings(l.elems)
+	}
+
+	if n > 0 {
+		c.modified(args[0])
+	}
+	c.writeInt(n)
+}
+
+func reverseStrings(ss []string) {
+	for i, j := 0, len(ss)-1; i < j; i, j = i+1, j-1 {
+		ss[i], ss[j] = ss[j], ss[i]
+	}
+}
+
+func cmdLTrim(c *conn, args []string) {
+	start, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	stop, ok := c.intArg(args[2])
+	if !ok {
+		return
+	}
+	l, ok := c.listValue(args[0], false)
+	if !ok {
+		return
+	}
+	if l == nil {
+		c.writeOK()
+		return
+	}
+	lo, hi, ok := normalizeRange(start, stop, len(l.elems))
+	if ok {
+		l.elems = append([]string(nil), l.elems[lo:hi+1]...)
+	} else {
+		l.elems = nil
+	}
+	c.modified(args[0])
+	c.writeOK()
+}
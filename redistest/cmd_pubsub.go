@@ -0,0 +1,176 @@
+package redistest
+
+import (
+	"sort"
+	"strings"
+)
+
+func cmdSubscribe(c *conn, args []string) {
+	for _, channel := range args {
+		if _, ok := c.channels[channel]; !ok {
+			c.channels[channel] = struct{}{}
+			addSubscriber(c.srv.channels, channel, c)
+		}
+		c.writeSubscription("subscribe", channel)
+	}
+}
+
+func cmdPSubscribe(c *conn, args []string) {
+	for _, pattern := range args {
+		if _, ok := c.patterns[pattern]; !ok {
+			c.patterns[pattern] = struct{}{}
+			addSubscriber(c.srv.patterns, pattern, c)
+		}
+		c.writeSubscription("psubscribe", pattern)
+	}
+}
+
+func cmdUnsubscribe(c *conn, args []string) {
+	unsubscribe(c, args, "unsubscribe", c.channels, c.srv.channels)
+}
+
+func cmdPUnsubscribe(c *conn, args []string) {
+	unsubscribe(c, args, "punsubscribe", c.patterns, c.srv.patterns)
+}
+
+func unsubscribe(c *conn, args []string, kind string, subs map[string]struct{}, all map[string]map[*conn]struct{}) {
+	if len(args) == 0 {
+		if len(subs) == 0 {
+			c.writePushLen(3)
+			c.writeBulk(kind)
+			c.writeNull()
+			c.writeInt(int64(c.subscriptions()))
+			return
+		}
+		for name := range subs {
+			args = append(args, name)
+		}
+		sort.Strings(args)
+	}
+
+	for _, name := range args {
+		delete(subs, name)
+		removeSubscriber(all, name, c)
+		c.writeSubscription(kind, name)
+	}
+}
+
+func (c *conn) writeSubscription(kind, name string) {
+	c.writePushLen(3)
+	c.writeBulk(kind)
+	c.writeBulk(name)
+	c.writeInt(int64(c.subscriptions()))
+}
+
+func (c *conn) subscriptions() int {
+	return len(c.channels) + len(c.patterns)
+}
+
+// unsubscribeAll removes all subscriptions of c without replying.
+func (c *conn) unsubscribeAll() {
+	for channel := range c.channels {
+		removeSubscriber(c.srv.channels, channel, c)
+	}
+	for pattern := range c.patterns {
+		removeSubscriber(c.srv.patterns, pattern, c)
+	}
+	c.channels = make(map[string]struct{})
+	c.patterns = make(map[string]struct{})
+}
+
+func addSubscriber(subs map[string]map[*conn]struct{}, name string, c *conn) {
+	conns, ok := subs[name]
+	if !ok {
+		conns = make(map[*conn]struct{})
+		subs[name] = conns
+	}
+	conns[c] = struct{}{}
+}
+
+func removeSubscriber(subs map[string]map[*conn]struct{}, name string, c *conn) {
+	conns := subs[name]
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(subs, name)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type message struct {
+	pattern string
+	channel string
+	payload string
+}
+
+func cmdPublish(c *conn, args []string) {
+	channel, payload := args[0], args[1]
+
+	var n int64
+	for sub := range c.srv.channels[channel] {
+		c.deliver(sub, message{channel: channel, payload: payload})
+		n++
+	}
+	for pattern, conns := range c.srv.patterns {
+		if !matchGlob(pattern, channel) {
+			continue
+		}
+		for sub := range conns {
+			c.deliver(sub, message{pattern: pattern, channel: channel, payload: payload})
+			n++
+		}
+	}
+	c.writeInt(n)
+}
+
+// deliver sends msg to sub. RESP3 connections that publish to themselves
+// receive the message after the reply of the current command.
+func (c *conn) deliver(sub *conn, msg message) {
+	if sub == c {
+		c.messages = append(c.messages, msg)
+		return
+	}
+	sub.writeMessage(msg)
+	sub.commit()
+}
+
+func (c *conn) writeMessage(msg message) {
+	if msg.pattern != "" {
+		c.writePushLen(4)
+		c.writeBulk("pmessage")
+		c.writeBulk(msg.pattern)
+	} else {
+		c.writePushLen(3)
+		c.writeBulk("message")
+	}
+	c.writeBulk(msg.channel)
+	c.writeBulk(msg.payload)
+}
+
+func cmdPubSub(c *conn, args []string) {
+	switch strings.ToLower(args[0]) {
+	case "channels":
+		if len(args) > 2 {
+			c.writeError(errSyntax)
+			return
+		}
+		var channels []string
+		for channel := range c.srv.channels {
+			if len(args) == 1 || matchGlob(args[1], channel) {
+				channels = append(channels, channel)
+			}
+		}
+		sort.Strings(channels)
+		c.writeStrings(channels)
+	case "numsub":
+		c.writeMapLen(len(args) - 1)
+		for _, channel := range args[1:] {
+			c.writeBulk(channel)
+			c.writeInt(int64(len(c.srv.channels[channel])))
+		}
+	case "numpat":
+		c.writeInt(int64(len(c.srv.patterns)))
+	default:
+		c.writeErrorf("ERR unknown subcommand '%s'. Try PUBSUB HELP.", args[0])
+	}
+}
@@ -0,0 +1,192 @@
+package redistest
+
+import (
+	"strconv"
+)
+
+func cmdSAdd(c *conn, args []string) {
+	s, ok := c.setValue(args[0], true)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := s[member]; !ok {
+			s[member] = struct{}{}
+			n++
+		}
+	}
+	c.modified(args[0])
+	c.writeInt(n)
+}
+
+func cmdSRem(c *conn, args []string) {
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := s[member]; ok {
+			delete(s, member)
+			n++
+		}
+	}
+	if n > 0 {
+		c.modified(args[0])
+	}
+	c.writeInt(n)
+}
+
+func cmdSMembers(c *conn, args []string) {
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeStringSet(sortedMembers(s))
+}
+
+func cmdSIsMember(c *conn, args []string) {
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+	_, ok = s[args[1]]
+	c.writeBool(ok)
+}
+
+func cmdSCard(c *conn, args []string) {
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeInt(int64(len(s)))
+}
+
+func cmdSPop(c *conn, args []string) {
+	if len(args) > 2 {
+		c.writeError(errSyntax)
+		return
+	}
+	count := int64(1)
+	if len(args) == 2 {
+		var err error
+		if count, err = strconv.ParseInt(args[1], 10, 64); err != nil || count < 0 {
+			c.writeError("ERR value is out of range, must be positive")
+			return
+		}
+	}
+
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+
+	// Map iteration order makes the members random enough for tests.
+	var popped []string
+	for member := range s {
+		if int64(len(popped)) == count {
+			break
+		}
+		popped = append(popped, member)
+		delete(s, member)
+	}
+	if len(popped) > 0 {
+		c.modified(args[0])
+	}
+
+	if len(args) == 2 {
+		c.writeStringSet(popped)
+		return
+	}
+	if len(popped) == 0 {
+		c.writeNull()
+		return
+	}
+	c.writeBulk(popped[0])
+}
+
+func cmdSInter(c *conn, args []string) {
+	sets, ok := c.setValues(args)
+	if !ok {
+		return
+	}
+	result := make(setValue)
+	for member := range sets[0] {
+		inAll := true
+		for _, s := range sets[1:] {
+			if _, ok := s[member]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result[member] = struct{}{}
+		}
+	}
+	c.writeStringSet(sortedMembers(result))
+}
+
+func cmdSUnion(c *conn, args []string) {
+	sets, ok := c.setValues(args)
+	if !ok {
+		return
+	}
+	result := make(setValue)
+	for _, s := range sets {
+		for member := range s {
+			result[member] = struct{}{}
+		}
+	}
+	c.writeStringSet(sortedMembers(result))
+}
+
+func cmdSDiff(c *conn, args []string) {
+	sets, ok := c.setValues(args)
+	if !ok {
+		return
+	}
+	result := make(setValue)
+	for member := range sets[0] {
+		result[member] = struct{}{}
+	}
+	for _, s := range sets[1:] {
+		for member := range s {
+			delete(result, member)
+		}
+	}
+	c.writeStringSet(sortedMembers(result))
+}
+
+// setValues returns the sets of keys, with nil for missing ones.
+func (c *conn) setValues(keys []string) ([]setValue, bool) {
+	sets := make([]setValue, len(keys))
+	for i, key := range keys {
+		s, ok := c.setValue(key, false)
+		if !ok {
+			return nil, false
+		}
+		sets[i] = s
+	}
+	return sets, true
+}
+
+func cmdSScan(c *conn, args []string) {
+	opt, ok := parseScan(c, args[1:], "sscan")
+	if !ok {
+		return
+	}
+	s, ok := c.setValue(args[0], false)
+	if !ok {
+		return
+	}
+
+	page, next := opt.page(sortedMembers(s))
+	var members []string
+	for _, member := range page {
+		if opt.match == "" || matchGlob(opt.match, member) {
+			members = append(members, member)
+		}
+	}
+	writeScan(c, next, members)
+}
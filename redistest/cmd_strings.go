@@ -0,0 +1,361 @@
+package redistest
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func cmdGet(c *conn, args []string) {
+	val, exists, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	if !exists {
+		c.writeNull()
+		return
+	}
+	c.writeBulk(val)
+}
+
+func cmdGetDel(c *conn, args []string) {
+	val, exists, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	if !exists {
+		c.writeNull()
+		return
+	}
+	c.del(args[0])
+	c.writeBulk(val)
+}
+
+func cmdGetSet(c *conn, args []string) {
+	val, exists, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	c.set(args[0], args[1])
+	if !exists {
+		c.writeNull()
+		return
+	}
+	c.writeBulk(val)
+}
+
+func cmdGetRange(c *conn, args []string) {
+	start, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	end, ok := c.intArg(args[2])
+	if !ok {
+		return
+	}
+	val, _, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	lo, hi, ok := normalizeRange(start, end, len(val))
+	if !ok {
+		c.writeBulk("")
+		return
+	}
+	c.writeBulk(val[lo : hi+1])
+}
+
+// normalizeRange resolves the negative indexes of the inclusive range
+// start..end of a sequence of length n and reports if it isn't empty.
+func normalizeRange(start, end int64, n int) (int, int, bool) {
+	if start < 0 {
+		start += int64(n)
+	}
+	if end < 0 {
+		end += int64(n)
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= int64(n) {
+		end = int64(n) - 1
+	}
+	if start > end || start >= int64(n) {
+		return 0, 0, false
+	}
+	return int(start), int(end), true
+}
+
+func cmdSet(c *conn, args []string) {
+	key, val := args[0], args[1]
+
+	var nx, xx, get, keepTTL bool
+	var expireAt time.Time
+	for opts := args[2:]; len(opts) > 0; opts = opts[1:] {
+		opt := strings.ToLower(opts[0])
+		switch opt {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "get":
+			get = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px", "exat", "pxat":
+			if len(opts) < 2 || !expireAt.IsZero() {
+				c.writeError(errSyntax)
+				return
+			}
+			n, ok := c.intArg(opts[1])
+			if !ok {
+				return
+			}
+			if n <= 0 {
+				c.writeError("ERR invalid expire time in 'set' command")
+				return
+			}
+			expireAt = expireTime(c, opt, n)
+			opts = opts[1:]
+		default:
+			c.writeError(errSyntax)
+			return
+		}
+	}
+	if nx && xx || keepTTL && !expireAt.IsZero() {
+		c.writeError(errSyntax)
+		return
+	}
+
+	e := c.currentDB().get(key, c.srv.now())
+	exists := e != nil
+	var old string
+	if get && exists {
+		// Only SET with GET fails for values of other types.
+		var ok bool
+		if old, ok = e.val.(string); !ok {
+			c.writeError(errWrongType)
+			return
+		}
+	}
+
+	if nx && exists || xx && !exists {
+		if get && exists {
+			c.writeBulk(old)
+		} else {
+			c.writeNull()
+		}
+		return
+	}
+
+	if keepTTL && exists {
+		expireAt = e.expireAt
+	}
+	c.set(key, val)
+	c.currentDB().keys[key].expireAt = expireAt
+
+	switch {
+	case !get:
+		c.writeOK()
+	case exists:
+		c.writeBulk(old)
+	default:
+		c.writeNull()
+	}
+}
+
+func expireTime(c *conn, unit string, n int64) time.Time {
+	switch unit {
+	case "ex":
+		return c.srv.now().Add(time.Duration(n) * time.Second)
+	case "px":
+		return c.srv.now().Add(time.Duration(n) * time.Millisecond)
+	case "exat":
+		return time.Unix(n, 0)
+	default:
+		return time.UnixMilli(n)
+	}
+}
+
+func cmdSetNX(c *conn, args []string) {
+	if c.currentDB().get(args[0], c.srv.now()) != nil {
+		c.writeInt(0)
+		return
+	}
+	c.set(args[0], args[1])
+	c.writeInt(1)
+}
+
+func cmdSetEX(c *conn, args []string) {
+	setEX(c, args, "setex", "ex")
+}
+
+func cmdPSetEX(c *conn, args []string) {
+	setEX(c, args, "psetex", "px")
+}
+
+func setEX(c *conn, args []string, cmd, unit string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	if n <= 0 {
+		c.writeErrorf("ERR invalid expire time in '%s' command", cmd)
+		return
+	}
+	c.set(args[0], args[2])
+	c.currentDB().keys[args[0]].expireAt = expireTime(c, unit, n)
+	c.writeOK()
+}
+
+func cmdMGet(c *conn, args []string) {
+	c.writeArrayLen(len(args))
+	now := c.srv.now()
+	for _, key := range args {
+		e := c.currentDB().get(key, now)
+		if val, ok := e.stringVal(); ok {
+			c.writeBulk(val)
+		} else {
+			c.writeNull()
+		}
+	}
+}
+
+func (e *entry) stringVal() (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	val, ok := e.val.(string)
+	return val, ok
+}
+
+func cmdMSet(c *conn, args []string) {
+	if len(args)%2 != 0 {
+		c.writeError("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+	for i := 0; i < len(args); i += 2 {
+		c.set(args[i], args[i+1])
+	}
+	c.writeOK()
+}
+
+func cmdMSetNX(c *conn, args []string) {
+	if len(args)%2 != 0 {
+		c.writeError("ERR wrong number of arguments for 'msetnx' command")
+		return
+	}
+	for i := 0; i < len(args); i += 2 {
+		if c.currentDB().get(args[i], c.srv.now()) != nil {
+			c.writeInt(0)
+			return
+		}
+	}
+	for i := 0; i < len(args); i += 2 {
+		c.set(args[i], args[i+1])
+	}
+	c.writeInt(1)
+}
+
+func cmdAppend(c *conn, args []string) {
+	val, _, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	val += args[1]
+	setKeepTTL(c, args[0], val)
+	c.writeInt(int64(len(val)))
+}
+
+// setKeepTTL replaces the string value of key without clearing its TTL.
+func setKeepTTL(c *conn, key, val string) {
+	e := c.currentDB().get(key, c.srv.now())
+	if e == nil {
+		c.set(key, val)
+		return
+	}
+	e.val = val
+	c.srv.touch(c.db, key)
+}
+
+func cmdStrlen(c *conn, args []string) {
+	val, _, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	c.writeInt(int64(len(val)))
+}
+
+func cmdIncr(c *conn, args []string) {
+	incrBy(c, args[0], 1)
+}
+
+func cmdDecr(c *conn, args []string) {
+	incrBy(c, args[0], -1)
+}
+
+func cmdIncrBy(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	incrBy(c, args[0], n)
+}
+
+func cmdDecrBy(c *conn, args []string) {
+	n, ok := c.intArg(args[1])
+	if !ok {
+		return
+	}
+	if n == math.MinInt64 {
+		c.writeError("ERR decrement would overflow")
+		return
+	}
+	incrBy(c, args[0], -n)
+}
+
+func incrBy(c *conn, key string, by int64) {
+	val, exists, ok := c.stringValue(key)
+	if !ok {
+		return
+	}
+	var n int64
+	if exists {
+		if n, ok = c.intArg(val); !ok {
+			return
+		}
+	}
+	if by > 0 && n > math.MaxInt64-by || by < 0 && n < math.MinInt64-by {
+		c.writeError("ERR increment or decrement would overflow")
+		return
+	}
+	n += by
+	setKeepTTL(c, key, strconv.FormatInt(n, 10))
+	c.writeInt(n)
+}
+
+func cmdIncrByFloat(c *conn, args []string) {
+	by, ok := c.floatArg(args[1])
+	if !ok {
+		return
+	}
+	val, exists, ok := c.stringValue(args[0])
+	if !ok {
+		return
+	}
+	var f float64
+	if exists {
+		if f, ok = c.floatArg(val); !ok {
+			return
+		}
+	}
+	f += by
+	if math.IsInf(f, 0) {
+		c.writeError("ERR increment would produce NaN or Infinity")
+		return
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	setKeepTTL(c, args[0], s)
+	c.writeBulk(s)
+}
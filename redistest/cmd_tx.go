@@ -0,0 +1,89 @@
+package redistest
+
+func cmdMulti(c *conn, args []string) {
+	if c.multi {
+		c.writeError("ERR MULTI calls can not be nested")
+		return
+	}
+	c.multi = true
+	c.writeOK()
+}
+
+func cmdExec(c *conn, args []string) {
+	if !c.multi {
+		c.writeError("ERR EXEC without MULTI")
+		return
+	}
+	queued, dirty, dirtyCAS := c.queued, c.dirty, c.dirtyCAS
+	c.discard()
+	c.unwatch()
+
+	switch {
+	case dirty:
+		c.writeError("EXECABORT Transaction discarded because of previous errors.")
+	case dirtyCAS:
+		c.writeNullArray()
+	default:
+		c.writeArrayLen(len(queued))
+		for _, args := range queued {
+			call(c, args)
+		}
+	}
+}
+
+func cmdDiscard(c *conn, args []string) {
+	if !c.multi {
+		c.writeError("ERR DISCARD without MULTI")
+		return
+	}
+	c.discard()
+	c.unwatch()
+	c.writeOK()
+}
+
+func cmdWatch(c *conn, args []string) {
+	if c.multi {
+		// The server rejects the command, which discards the transaction.
+		c.dirty = true
+		c.writeError("ERR WATCH inside MULTI is not allowed")
+		return
+	}
+	for _, key := range args {
+		wk := watchKey{db: c.db, key: key}
+		conns, ok := c.srv.watchers[wk]
+		if !ok {
+			conns = make(map[*conn]struct{})
+			c.srv.watchers[wk] = conns
+		}
+		if _, ok := conns[c]; !ok {
+			conns[c] = struct{}{}
+			c.watched = append(c.watched, wk)
+		}
+	}
+	c.writeOK()
+}
+
+func cmdUnwatch(c *conn, args []string) {
+	c.unwatch()
+	c.writeOK()
+}
+
+// discard leaves the MULTI state.
+func (c *conn) discard() {
+	c.multi = false
+	c.queued = nil
+	c.dirty = false
+}
+
+// unwatch forgets about the keys watched by c.
+func (c *conn) unwatch() {
+	for _, wk := range c.watched {
+		conns := c.srv.watchers[wk]
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(c.srv.watchers, wk)
+		}
+	}
+	c.watched = nil
+	c.dirtyCAS = false
+}
@@ -0,0 +1,465 @@
+package redistest
+
+import (
+	"math"
+	"strings"
+)
+
+func cmdZAdd(c *conn, args []string) {
+	key := args[0]
+	var nx, xx, gt, lt, ch, incr bool
+	args = args[1:]
+loop:
+	for len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "gt":
+			gt = true
+		case "lt":
+			lt = true
+		case "ch":
+			ch = true
+		case "incr":
+			incr = true
+		default:
+			break loop
+		}
+		args = args[1:]
+	}
+
+	switch {
+	case len(args) == 0 || len(args)%2 != 0:
+		c.writeError(errSyntax)
+		return
+	case nx && xx:
+		c.writeError("ERR XX and NX options at the same time are not compatible")
+		return
+	case gt && lt || nx && (gt || lt):
+		c.writeError("ERR GT, LT, and/or NX options at the same time are not compatible")
+		return
+	case incr && len(args) > 2:
+		c.writeError("ERR INCR option supports a single increment-element pair")
+		return
+	}
+
+	scores := make([]float64, len(args)/2)
+	for i := range scores {
+		var ok bool
+		if scores[i], ok = c.floatArg(args[2*i]); !ok {
+			return
+		}
+	}
+
+	z, ok := c.zsetValue(key, false)
+	if !ok {
+		return
+	}
+	if z == nil && xx {
+		if incr {
+			c.writeNull()
+		} else {
+			c.writeInt(0)
+		}
+		return
+	}
+	z, _ = c.zsetValue(key, true)
+
+	var added, changed int64
+	var result float64
+	var skipped bool
+	for i, score := range scores {
+		member := args[2*i+1]
+		old, exists := z[member]
+		if nx && exists || xx && !exists {
+			skipped = true
+			continue
+		}
+		if incr && exists {
+			score += old
+			if math.IsNaN(score) {
+				c.modified(key)
+				c.writeError("ERR resulting score is not a number (NaN)")
+				return
+			}
+		}
+		if exists && (gt && score <= old || lt && score >= old) {
+			skipped = true
+			continue
+		}
+
+		z[member] = score
+		result = score
+		if !exists {
+			added++
+		} else if score != old {
+			changed++
+		}
+	}
+	c.modified(key)
+
+	switch {
+	case incr && skipped:
+		c.writeNull()
+	case incr:
+		c.writeFloat(result)
+	case ch:
+		c.writeInt(added + changed)
+	default:
+		c.writeInt(added)
+	}
+}
+
+func cmdZRem(c *conn, args []string) {
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := z[member]; ok {
+			delete(z, member)
+			n++
+		}
+	}
+	if n > 0 {
+		c.modified(args[0])
+	}
+	c.writeInt(n)
+}
+
+func cmdZCard(c *conn, args []string) {
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+	c.writeInt(int64(len(z)))
+}
+
+func cmdZScore(c *conn, args []string) {
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+	score, ok := z[args[1]]
+	if !ok {
+		c.writeNull()
+		return
+	}
+	c.writeFloat(score)
+}
+
+func cmdZIncrBy(c *conn, args []string) {
+	by, ok := c.floatArg(args[1])
+	if !ok {
+		return
+	}
+	z, ok := c.zsetValue(args[0], true)
+	if !ok {
+		return
+	}
+	score := z[args[2]] + by
+	if math.IsNaN(score) {
+		c.modified(args[0])
+		c.writeError("ERR resulting score is not a number (NaN)")
+		return
+	}
+	z[args[2]] = score
+	c.modified(args[0])
+	c.writeFloat(score)
+}
+
+func cmdZRank(c *conn, args []string) {
+	zrank(c, args, false)
+}
+
+func cmdZRevRank(c *conn, args []string) {
+	zrank(c, args, true)
+}
+
+func zrank(c *conn, args []string, rev bool) {
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+	members := z.sorted()
+	for i, m := range members {
+		if m.member != args[1] {
+			continue
+		}
+		if rev {
+			i = len(members) - 1 - i
+		}
+		c.writeInt(int64(i))
+		return
+	}
+	c.writeNull()
+}
+
+func cmdZCount(c *conn, args []string) {
+	min, ok := c.scoreBound(args[1])
+	if !ok {
+		return
+	}
+	max, ok := c.scoreBound(args[2])
+	if !ok {
+		return
+	}
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, score := range z {
+		if min.below(score) && max.above(score) {
+			n++
+		}
+	}
+	c.writeInt(n)
+}
+
+//------------------------------------------------------------------------------
+
+// scoreBound is a bound of a score range like "(1.5" or "-inf".
+type scoreBound struct {
+	score     float64
+	exclusive bool
+}
+
+func (c *conn) scoreBound(s string) (scoreBound, bool) {
+	var b scoreBound
+	if strings.HasPrefix(s, "(") {
+		b.exclusive = true
+		s = s[1:]
+	}
+	var ok bool
+	if b.score, ok = parseFloat(s); !ok {
+		c.writeError("ERR min or max is not a float")
+		return b, false
+	}
+	return b, true
+}
+
+// below reports whether the bound is a minimum of score.
+func (b scoreBound) below(score float64) bool {
+	if b.exclusive {
+		return b.score < score
+	}
+	return b.score <= score
+}
+
+// above reports whether the bound is a maximum of score.
+func (b scoreBound) above(score float64) bool {
+	if b.exclusive {
+		return b.score > score
+	}
+	return b.score >= score
+}
+
+type zrangeOptions struct {
+	byScore    bool
+	rev        bool
+	withScores bool
+	limit      bool
+	offset     int64
+	count      int64
+}
+
+func cmdZRange(c *conn, args []string) {
+	var opt zrangeOptions
+	for opts := args[3:]; len(opts) > 0; opts = opts[1:] {
+		switch strings.ToLower(opts[0]) {
+		case "byscore":
+			opt.byScore = true
+		case "rev":
+			opt.rev = true
+		case "withscores":
+			opt.withScores = true
+		case "limit":
+			if len(opts) < 3 {
+				c.writeError(errSyntax)
+				return
+			}
+			if !c.limitArgs(&opt, opts[1:3]) {
+				return
+			}
+			opts = opts[2:]
+		default:
+			c.writeError(errSyntax)
+			return
+		}
+	}
+	if opt.limit && !opt.byScore {
+		c.writeError("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+		return
+	}
+	zrange(c, args[0], args[1], args[2], opt)
+}
+
+func cmdZRevRange(c *conn, args []string) {
+	opt := zrangeOptions{rev: true}
+	if !c.withScoresArg(&opt, args[3:]) {
+		return
+	}
+	zrange(c, args[0], args[1], args[2], opt)
+}
+
+func cmdZRangeByScore(c *conn, args []string) {
+	opt := zrangeOptions{byScore: true}
+	for opts := args[3:]; len(opts) > 0; opts = opts[1:] {
+		switch strings.ToLower(opts[0]) {
+		case "withscores":
+			opt.withScores = true
+		case "limit":
+			if len(opts) < 3 {
+				c.writeError(errSyntax)
+				return
+			}
+			if !c.limitArgs(&opt, opts[1:3]) {
+				return
+			}
+			opts = opts[2:]
+		default:
+			c.writeError(errSyntax)
+			return
+		}
+	}
+	zrange(c, args[0], args[1], args[2], opt)
+}
+
+func (c *conn) withScoresArg(opt *zrangeOptions, args []string) bool {
+	switch {
+	case len(args) == 0:
+		return true
+	case len(args) == 1 && strings.EqualFold(args[0], "withscores"):
+		opt.withScores = true
+		return true
+	}
+	c.writeError(errSyntax)
+	return false
+}
+
+func (c *conn) limitArgs(opt *zrangeOptions, args []string) bool {
+	var ok bool
+	if opt.offset, ok = c.intArg(args[0]); !ok {
+		return false
+	}
+	if opt.count, ok = c.intArg(args[1]); !ok {
+		return false
+	}
+	opt.limit = true
+	return true
+}
+
+// zrange replies with the members between start and stop, which are
+// indexes or score bounds. In reverse order, score bounds are max and min.
+func zrange(c *conn, key, start, stop string, opt zrangeOptions) {
+	var min, max scoreBound
+	var lo, hi int64
+	if opt.byScore {
+		if opt.rev {
+			start, stop = stop, start
+		}
+		var ok bool
+		if min, ok = c.scoreBound(start); !ok {
+			return
+		}
+		if max, ok = c.scoreBound(stop); !ok {
+			return
+		}
+	} else {
+		var ok bool
+		if lo, ok = c.intArg(start); !ok {
+			return
+		}
+		if hi, ok = c.intArg(stop); !ok {
+			return
+		}
+	}
+
+	z, ok := c.zsetValue(key, false)
+	if !ok {
+		return
+	}
+	members := z.sorted()
+	if opt.rev {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+
+	var result []zsetMember
+	if opt.byScore {
+		for _, m := range members {
+			if min.below(m.score) && max.above(m.score) {
+				result = append(result, m)
+			}
+		}
+		if opt.limit {
+			result = limitMembers(result, opt.offset, opt.count)
+		}
+	} else if i, j, ok := normalizeRange(lo, hi, len(members)); ok {
+		result = members[i : j+1]
+	}
+
+	writeMembers(c, result, opt.withScores)
+}
+
+func limitMembers(members []zsetMember, offset, count int64) []zsetMember {
+	if offset < 0 || offset >= int64(len(members)) {
+		return nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < int64(len(members)) {
+		members = members[:count]
+	}
+	return members
+}
+
+// writeMembers writes members, with scores as pairs in RESP3
+// and as a flat array in RESP2.
+func writeMembers(c *conn, members []zsetMember, withScores bool) {
+	switch {
+	case !withScores:
+		c.writeArrayLen(len(members))
+	case c.protocol == 3:
+		c.writeArrayLen(len(members))
+	default:
+		c.writeArrayLen(2 * len(members))
+	}
+	for _, m := range members {
+		if withScores && c.protocol == 3 {
+			c.writeArrayLen(2)
+		}
+		c.writeBulk(m.member)
+		if withScores {
+			c.writeFloat(m.score)
+		}
+	}
+}
+
+func cmdZScan(c *conn, args []string) {
+	opt, ok := parseScan(c, args[1:], "zscan")
+	if !ok {
+		return
+	}
+	z, ok := c.zsetValue(args[0], false)
+	if !ok {
+		return
+	}
+
+	members := make([]string, 0, len(z))
+	for member := range z {
+		members = append(members, member)
+	}
+	page, next := opt.page(members)
+	var elems []string
+	for _, member := range page {
+		if opt.match == "" || matchGlob(opt.match, member) {
+			elems = append(elems, member, formatFloat(z[member]))
+		}
+	}
+	writeScan(c, next, elems)
+}
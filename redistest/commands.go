@@ -0,0 +1,247 @@
+package redistest
+
+import (
+	"strings"
+)
+
+type command struct {
+	fn func(c *conn, args []string)
+
+	// arity is the number of arguments including the command name.
+	// Negative values are the minimum number of arguments.
+	arity int
+
+	flags int
+	keys  keySpec
+}
+
+// keySpec are the positions of the keys in the arguments like COMMAND reports.
+type keySpec struct {
+	first, last, step int
+}
+
+var (
+	firstKey = keySpec{first: 1, last: 1, step: 1}
+	allKeys  = keySpec{first: 1, last: -1, step: 1}
+	pairKeys = keySpec{first: 1, last: -1, step: 2}
+	twoKeys  = keySpec{first: 1, last: 2, step: 1}
+)
+
+// keysOf returns the keys of the command args.
+func (cmd *command) keysOf(args []string) []string {
+	spec := cmd.keys
+	if spec.first == 0 || spec.first >= len(args) {
+		return nil
+	}
+	last := spec.last
+	if last < 0 {
+		last += len(args)
+	}
+	var keys []string
+	for i := spec.first; i <= last && i < len(args); i += spec.step {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
+
+const (
+	// flagNoAuth commands run before connections authenticate.
+	flagNoAuth = 1 << iota
+	// flagPubSub commands run on RESP2 connections in pub/sub mode.
+	flagPubSub
+	// flagTx commands aren't queued by MULTI.
+	flagTx
+	// flagReadOnly commands don't modify data and run on replicas.
+	flagReadOnly
+)
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		// Connection
+		"auth":   {fn: cmdAuth, arity: -2, flags: flagNoAuth},
+		"client": {fn: cmdClient, arity: -2},
+		"echo":   {fn: cmdEcho, arity: 2},
+		"hello":  {fn: cmdHello, arity: -1, flags: flagNoAuth},
+		"ping":   {fn: cmdPing, arity: -1, flags: flagPubSub},
+		"quit":   {fn: cmdQuit, arity: -1, flags: flagNoAuth | flagPubSub},
+		"reset":  {fn: cmdReset, arity: 1, flags: flagNoAuth | flagPubSub | flagTx},
+		"select": {fn: cmdSelect, arity: 2},
+
+		// Server
+		"dbsize":   {fn: cmdDBSize, arity: 1, flags: flagReadOnly},
+		"flushall": {fn: cmdFlushAll, arity: -1},
+		"flushdb":  {fn: cmdFlushDB, arity: -1},
+		"command":  {fn: cmdCommand, arity: -1},
+		"time":     {fn: cmdTime, arity: 1},
+
+		// Cluster
+		"asking":    {fn: cmdAsking, arity: 1},
+		"cluster":   {fn: cmdCluster, arity: -2},
+		"readonly":  {fn: cmdReadOnly, arity: 1},
+		"readwrite": {fn: cmdReadWrite, arity: 1},
+
+		// Keys
+		"del":       {fn: cmdDel, arity: -2, keys: allKeys},
+		"exists":    {fn: cmdExists, arity: -2, flags: flagReadOnly, keys: allKeys},
+		"expire":    {fn: cmdExpire, arity: -3, keys: firstKey},
+		"expireat":  {fn: cmdExpireAt, arity: -3, keys: firstKey},
+		"keys":      {fn: cmdKeys, arity: 2, flags: flagReadOnly},
+		"persist":   {fn: cmdPersist, arity: 2, keys: firstKey},
+		"pexpire":   {fn: cmdPExpire, arity: -3, keys: firstKey},
+		"pexpireat": {fn: cmdPExpireAt, arity: -3, keys: firstKey},
+		"pttl":      {fn: cmdPTTL, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"rename":    {fn: cmdRename, arity: 3, keys: twoKeys},
+		"renamenx":  {fn: cmdRenameNX, arity: 3, keys: twoKeys},
+		"scan":      {fn: cmdScan, arity: -2, flags: flagReadOnly},
+		"ttl":       {fn: cmdTTL, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"type":      {fn: cmdType, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"unlink":    {fn: cmdDel, arity: -2, keys: allKeys},
+
+		// Strings
+		"append":      {fn: cmdAppend, arity: 3, keys: firstKey},
+		"decr":        {fn: cmdDecr, arity: 2, keys: firstKey},
+		"decrby":      {fn: cmdDecrBy, arity: 3, keys: firstKey},
+		"get":         {fn: cmdGet, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"getdel":      {fn: cmdGetDel, arity: 2, keys: firstKey},
+		"getrange":    {fn: cmdGetRange, arity: 4, flags: flagReadOnly, keys: firstKey},
+		"getset":      {fn: cmdGetSet, arity: 3, keys: firstKey},
+		"incr":        {fn: cmdIncr, arity: 2, keys: firstKey},
+		"incrby":      {fn: cmdIncrBy, arity: 3, keys: firstKey},
+		"incrbyfloat": {fn: cmdIncrByFloat, arity: 3, keys: firstKey},
+		"mget":        {fn: cmdMGet, arity: -2, flags: flagReadOnly, keys: allKeys},
+		"mset":        {fn: cmdMSet, arity: -3, keys: pairKeys},
+		"msetnx":      {fn: cmdMSetNX, arity: -3, keys: pairKeys},
+		"psetex":      {fn: cmdPSetEX, arity: 4, keys: firstKey},
+		"set":         {fn: cmdSet, arity: -3, keys: firstKey},
+		"setex":       {fn: cmdSetEX, arity: 4, keys: firstKey},
+		"setnx":       {fn: cmdSetNX, arity: 3, keys: firstKey},
+		"strlen":      {fn: cmdStrlen, arity: 2, flags: flagReadOnly, keys: firstKey},
+
+		// Hashes
+		"hdel":         {fn: cmdHDel, arity: -3, keys: firstKey},
+		"hexists":      {fn: cmdHExists, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"hget":         {fn: cmdHGet, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"hgetall":      {fn: cmdHGetAll, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"hincrby":      {fn: cmdHIncrBy, arity: 4, keys: firstKey},
+		"hincrbyfloat": {fn: cmdHIncrByFloat, arity: 4, keys: firstKey},
+		"hkeys":        {fn: cmdHKeys, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"hlen":         {fn: cmdHLen, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"hmget":        {fn: cmdHMGet, arity: -3, flags: flagReadOnly, keys: firstKey},
+		"hmset":        {fn: cmdHMSet, arity: -4, keys: firstKey},
+		"hscan":        {fn: cmdHScan, arity: -3, flags: flagReadOnly, keys: firstKey},
+		"hset":         {fn: cmdHSet, arity: -4, keys: firstKey},
+		"hsetnx":       {fn: cmdHSetNX, arity: 4, keys: firstKey},
+		"hvals":        {fn: cmdHVals, arity: 2, flags: flagReadOnly, keys: firstKey},
+
+		// Lists
+		"lindex": {fn: cmdLIndex, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"llen":   {fn: cmdLLen, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"lpop":   {fn: cmdLPop, arity: -2, keys: firstKey},
+		"lpush":  {fn: cmdLPush, arity: -3, keys: firstKey},
+		"lpushx": {fn: cmdLPushX, arity: -3, keys: firstKey},
+		"lrange": {fn: cmdLRange, arity: 4, flags: flagReadOnly, keys: firstKey},
+		"lrem":   {fn: cmdLRem, arity: 4, keys: firstKey},
+		"lset":   {fn: cmdLSet, arity: 4, keys: firstKey},
+		"ltrim":  {fn: cmdLTrim, arity: 4, keys: firstKey},
+		"rpop":   {fn: cmdRPop, arity: -2, keys: firstKey},
+		"rpush":  {fn: cmdRPush, arity: -3, keys: firstKey},
+		"rpushx": {fn: cmdRPushX, arity: -3, keys: firstKey},
+
+		// Sets
+		"sadd":      {fn: cmdSAdd, arity: -3, keys: firstKey},
+		"scard":     {fn: cmdSCard, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"sdiff":     {fn: cmdSDiff, arity: -2, flags: flagReadOnly, keys: allKeys},
+		"sinter":    {fn: cmdSInter, arity: -2, flags: flagReadOnly, keys: allKeys},
+		"sismember": {fn: cmdSIsMember, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"smembers":  {fn: cmdSMembers, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"spop":      {fn: cmdSPop, arity: -2, keys: firstKey},
+		"srem":      {fn: cmdSRem, arity: -3, keys: firstKey},
+		"sscan":     {fn: cmdSScan, arity: -3, flags: flagReadOnly, keys: firstKey},
+		"sunion":    {fn: cmdSUnion, arity: -2, flags: flagReadOnly, keys: allKeys},
+
+		// Sorted sets
+		"zadd":          {fn: cmdZAdd, arity: -4, keys: firstKey},
+		"zcard":         {fn: cmdZCard, arity: 2, flags: flagReadOnly, keys: firstKey},
+		"zcount":        {fn: cmdZCount, arity: 4, flags: flagReadOnly, keys: firstKey},
+		"zincrby":       {fn: cmdZIncrBy, arity: 4, keys: firstKey},
+		"zrange":        {fn: cmdZRange, arity: -4, flags: flagReadOnly, keys: firstKey},
+		"zrangebyscore": {fn: cmdZRangeByScore, arity: -4, flags: flagReadOnly, keys: firstKey},
+		"zrank":         {fn: cmdZRank, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"zrem":          {fn: cmdZRem, arity: -3, keys: firstKey},
+		"zrevrange":     {fn: cmdZRevRange, arity: -4, flags: flagReadOnly, keys: firstKey},
+		"zrevrank":      {fn: cmdZRevRank, arity: 3, flags: flagReadOnly, keys: firstKey},
+		"zscan":         {fn: cmdZScan, arity: -3, flags: flagReadOnly, keys: firstKey},
+		"zscore":        {fn: cmdZScore, arity: 3, flags: flagReadOnly, keys: firstKey},
+
+		// Transactions
+		"discard": {fn: cmdDiscard, arity: 1, flags: flagTx},
+		"exec":    {fn: cmdExec, arity: 1, flags: flagTx},
+		"multi":   {fn: cmdMulti, arity: 1, flags: flagTx},
+		"unwatch": {fn: cmdUnwatch, arity: 1, flags: flagTx},
+		"watch":   {fn: cmdWatch, arity: -2, flags: flagTx, keys: allKeys},
+
+		// Pub/Sub
+		"psubscribe":   {fn: cmdPSubscribe, arity: -2, flags: flagPubSub},
+		"publish":      {fn: cmdPublish, arity: 3},
+		"pubsub":       {fn: cmdPubSub, arity: -2},
+		"punsubscribe": {fn: cmdPUnsubscribe, arity: -1, flags: flagPubSub},
+		"subscribe":    {fn: cmdSubscribe, arity: -2, flags: flagPubSub},
+		"unsubscribe":  {fn: cmdUnsubscribe, arity: -1, flags: flagPubSub},
+	}
+}
+
+// exec runs the command args for c and reports whether c should be closed.
+func (s *Server) exec(c *conn, args []string) (quit bool) {
+	name := strings.ToLower(args[0])
+	cmd, ok := commands[name]
+	if !ok {
+		c.dirty = c.multi
+		c.writeErrorf("ERR unknown command '%s', with args beginning with: %s",
+			args[0], formatArgs(args[1:]))
+		return false
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || len(args) < -cmd.arity {
+		c.dirty = c.multi
+		c.writeErrorf("ERR wrong number of arguments for '%s' command", name)
+		return false
+	}
+	if s.password != "" && !c.authed && cmd.flags&flagNoAuth == 0 {
+		c.dirty = c.multi
+		c.writeError(errNoAuth)
+		return false
+	}
+	if c.protocol == 2 && c.subscriptions() > 0 && cmd.flags&flagPubSub == 0 {
+		c.writeErrorf("ERR Can't execute '%s': only (P|S)SUBSCRIBE / "+
+			"(P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context", name)
+		return false
+	}
+	if s.node != nil && !s.node.route(c, cmd, args) {
+		c.dirty = c.multi
+		return false
+	}
+	if c.multi && cmd.flags&flagTx == 0 {
+		c.queued = append(c.queued, args)
+		c.writeStatus("QUEUED")
+		return false
+	}
+
+	cmd.fn(c, args[1:])
+	return name == "quit"
+}
+
+// call runs a command queued by MULTI, which exec has checked already.
+func call(c *conn, args []string) {
+	commands[strings.ToLower(args[0])].fn(c, args[1:])
+}
+
+func formatArgs(args []string) string {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString("'")
+		b.WriteString(arg)
+		b.WriteString("' ")
+	}
+	return b.String()
+}
@@ -0,0 +1,488 @@
+package redistest
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9/internal/proto"
+)
+
+type conn struct {
+	srv *Server
+	id  int64
+	nc  net.Conn
+	rd  *proto.Reader
+
+	// The fields below are protected by srv.mu.
+	protocol int
+	db       int
+	name     string
+	authed   bool
+	readOnly bool // READONLY allows reads on cluster replicas
+	asking   bool // ASKING allows the next command on a migration target
+
+	multi    bool
+	queued   [][]string
+	dirty    bool // a queued command failed
+	dirtyCAS bool // a watched key was modified
+	watched  []watchKey
+
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	messages []message // published by c to itself
+
+	// buf holds the replies of the current command.
+	buf []byte
+
+	// out holds the replies that the write loop hasn't written yet.
+	outMu  sync.Mutex
+	out    []byte
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func newConn(srv *Server, nc net.Conn, id int64) *conn {
+	return &conn{
+		srv:      srv,
+		id:       id,
+		nc:       nc,
+		rd:       proto.NewReader(nc),
+		protocol: 2,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *conn) serve() {
+	defer c.srv.wg.Done()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writeLoop()
+	}()
+
+	for {
+		args, err := c.readCommand()
+		if err != nil {
+			break
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		c.srv.mu.Lock()
+		quit := c.srv.exec(c, args)
+		for _, msg := range c.messages {
+			c.writeMessage(msg)
+		}
+		c.messages = nil
+		c.commit()
+		c.srv.mu.Unlock()
+
+		if quit {
+			break
+		}
+	}
+
+	c.srv.removeConn(c)
+	close(c.done)
+	<-writerDone
+}
+
+func (c *conn) readCommand() ([]string, error) {
+	reply, err := c.rd.ReadReply()
+	if err != nil {
+		if _, ok := err.(proto.RedisError); !ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("redistest: unexpected error %q", err)
+	}
+
+	vals, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redistest: unexpected command %T", reply)
+	}
+	args := make([]string, len(vals))
+	for i, val := range vals {
+		args[i] = fmt.Sprint(val)
+	}
+	return args, nil
+}
+
+// writeLoop writes the replies to the network connection, so slow
+// readers don't block the server and pipelines of any size work.
+func (c *conn) writeLoop() {
+	defer c.nc.Close()
+	for {
+		select {
+		case <-c.notify:
+			if !c.writeOut() {
+				return
+			}
+		case <-c.done:
+			c.writeOut()
+			return
+		}
+	}
+}
+
+func (c *conn) writeOut() bool {
+	c.outMu.Lock()
+	out := c.out
+	c.out = nil
+	c.outMu.Unlock()
+
+	if len(out) == 0 {
+		return true
+	}
+	_, err := c.nc.Write(out)
+	return err == nil
+}
+
+// commit hands the buffered replies to the write loop.
+func (c *conn) commit() {
+	if len(c.buf) == 0 {
+		return
+	}
+	c.outMu.Lock()
+	c.out = append(c.out, c.buf...)
+	c.outMu.Unlock()
+	c.buf = c.buf[:0]
+
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (c *conn) writeLine(prefix byte, s string) {
+	c.buf = append(c.buf, prefix)
+	c.buf = append(c.buf, s...)
+	c.buf = append(c.buf, "\r\n"...)
+}
+
+func (c *conn) writeLen(prefix byte, n int) {
+	c.buf = append(c.buf, prefix)
+	c.buf = strconv.AppendInt(c.buf, int64(n), 10)
+	c.buf = append(c.buf, "\r\n"...)
+}
+
+func (c *conn) writeOK() {
+	c.writeStatus("OK")
+}
+
+func (c *conn) writeStatus(s string) {
+	c.writeLine(proto.RespStatus, s)
+}
+
+func (c *conn) writeError(s string) {
+	c.writeLine(proto.RespError, s)
+}
+
+func (c *conn) writeErrorf(format string, args ...interface{}) {
+	c.writeError(fmt.Sprintf(format, args...))
+}
+
+func (c *conn) writeInt(n int64) {
+	c.buf = append(c.buf, proto.RespInt)
+	c.buf = strconv.AppendInt(c.buf, n, 10)
+	c.buf = append(c.buf, "\r\n"...)
+}
+
+func (c *conn) writeBool(b bool) {
+	if c.protocol == 3 {
+		if b {
+			c.writeLine(proto.RespBool, "t")
+		} else {
+			c.writeLine(proto.RespBool, "f")
+		}
+		return
+	}
+	if b {
+		c.writeInt(1)
+	} else {
+		c.writeInt(0)
+	}
+}
+
+func (c *conn) writeBulk(s string) {
+	c.writeLen(proto.RespString, len(s))
+	c.buf = append(c.buf, s...)
+	c.buf = append(c.buf, "\r\n"...)
+}
+
+func (c *conn) writeFloat(f float64) {
+	if c.protocol == 3 {
+		c.writeLine(proto.RespFloat, formatFloat(f))
+		return
+	}
+	c.writeBulk(formatFloat(f))
+}
+
+// writeNull writes a null bulk string.
+func (c *conn) writeNull() {
+	if c.protocol == 3 {
+		c.writeLine(proto.RespNil, "")
+		return
+	}
+	c.buf = append(c.buf, "$-1\r\n"...)
+}
+
+// writeNullArray writes a null array, e.g. the reply of an aborted EXEC.
+func (c *conn) writeNullArray() {
+	if c.protocol == 3 {
+		c.writeLine(proto.RespNil, "")
+		return
+	}
+	c.buf = append(c.buf, "*-1\r\n"...)
+}
+
+func (c *conn) writeArrayLen(n int) {
+	c.writeLen(proto.RespArray, n)
+}
+
+// writeMapLen writes the header of a map of n pairs,
+// that RESP2 sends as an array of keys and values.
+func (c *conn) writeMapLen(n int) {
+	if c.protocol == 3 {
+		c.writeLen(proto.RespMap, n)
+		return
+	}
+	c.writeLen(proto.RespArray, 2*n)
+}
+
+func (c *conn) writeSetLen(n int) {
+	if c.protocol == 3 {
+		c.writeLen(proto.RespSet, n)
+		return
+	}
+	c.writeLen(proto.RespArray, n)
+}
+
+func (c *conn) writePushLen(n int) {
+	if c.protocol == 3 {
+		c.writeLen(proto.RespPush, n)
+		return
+	}
+	c.writeLen(proto.RespArray, n)
+}
+
+func (c *conn) writeStrings(ss []string) {
+	c.writeArrayLen(len(ss))
+	for _, s := range ss {
+		c.writeBulk(s)
+	}
+}
+
+func (c *conn) writeStringSet(ss []string) {
+	c.writeSetLen(len(ss))
+	for _, s := range ss {
+		c.writeBulk(s)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	errSyntax     = "ERR syntax error"
+	errNotInt     = "ERR value is not an integer or out of range"
+	errNotFloat   = "ERR value is not a valid float"
+	errWrongType  = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errNoAuth     = "NOAUTH Authentication required."
+	errWrongPass  = "WRONGPASS invalid username-password pair or user is disabled."
+	errOutOfRange = "ERR index out of range"
+	errNoSuchKey  = "ERR no such key"
+)
+
+func (c *conn) currentDB() *db {
+	return c.srv.dbs[c.db]
+}
+
+// keys returns the keys of the current database that
+// the server serves in lexicographical order.
+func (c *conn) keys() []string {
+	keys := c.currentDB().sortedKeys(c.srv.now())
+	if c.srv.node == nil {
+		return keys
+	}
+	served := keys[:0]
+	for _, key := range keys {
+		if c.srv.servesKey(key) {
+			served = append(served, key)
+		}
+	}
+	return served
+}
+
+// lookup returns the value of key or nil when it doesn't exist. It writes
+// a WRONGTYPE error and returns false when the value isn't of type typ.
+func (c *conn) lookup(key, typ string) (interface{}, bool) {
+	e := c.currentDB().get(key, c.srv.now())
+	if e == nil {
+		return nil, true
+	}
+	if typeName(e.val) != typ {
+		c.writeError(errWrongType)
+		return nil, false
+	}
+	return e.val, true
+}
+
+// set replaces the value of key, clearing its time to live.
+func (c *conn) set(key string, val interface{}) {
+	c.currentDB().keys[key] = &entry{val: val}
+	c.srv.touch(c.db, key)
+}
+
+// create stores a new aggregate value.
+func (c *conn) create(key string, val interface{}) {
+	c.currentDB().keys[key] = &entry{val: val}
+}
+
+// modified must be called after changing the value of key in place.
+// It removes empty aggregates like redis-server does.
+func (c *conn) modified(key string) {
+	d := c.currentDB()
+	if e, ok := d.keys[key]; ok && isEmpty(e.val) {
+		delete(d.keys, key)
+	}
+	c.srv.touch(c.db, key)
+}
+
+// del removes key and reports whether it existed.
+func (c *conn) del(key string) bool {
+	d := c.currentDB()
+	if d.get(key, c.srv.now()) == nil {
+		return false
+	}
+	delete(d.keys, key)
+	c.srv.touch(c.db, key)
+	return true
+}
+
+func (c *conn) stringValue(key string) (string, bool, bool) {
+	val, ok := c.lookup(key, "string")
+	if !ok || val == nil {
+		return "", false, ok
+	}
+	return val.(string), true, true
+}
+
+func (c *conn) hashValue(key string, create bool) (hashValue, bool) {
+	val, ok := c.lookup(key, "hash")
+	if !ok {
+		return nil, false
+	}
+	if val == nil {
+		if !create {
+			return nil, true
+		}
+		h := make(hashValue)
+		c.create(key, h)
+		return h, true
+	}
+	return val.(hashValue), true
+}
+
+func (c *conn) listValue(key string, create bool) (*listValue, bool) {
+	val, ok := c.lookup(key, "list")
+	if !ok {
+		return nil, false
+	}
+	if val == nil {
+		if !create {
+			return nil, true
+		}
+		l := new(listValue)
+		c.create(key, l)
+		return l, true
+	}
+	return val.(*listValue), true
+}
+
+func (c *conn) setValue(key string, create bool) (setValue, bool) {
+	val, ok := c.lookup(key, "set")
+	if !ok {
+		return nil, false
+	}
+	if val == nil {
+		if !create {
+			return nil, true
+		}
+		s := make(setValue)
+		c.create(key, s)
+		return s, true
+	}
+	return val.(setValue), true
+}
+
+func (c *conn) zsetValue(key string, create bool) (zsetValue, bool) {
+	val, ok := c.lookup(key, "zset")
+	if !ok {
+		return nil, false
+	}
+	if val == nil {
+		if !create {
+			return nil, true
+		}
+		z := make(zsetValue)
+		c.create(key, z)
+		return z, true
+	}
+	return val.(zsetValue), true
+}
+
+//------------------------------------------------------------------------------
+
+func parseInt(s string) (int64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+func parseFloat(s string) (float64, bool) {
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		return math.Inf(1), true
+	case "-inf":
+		return math.Inf(-1), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil && !math.IsNaN(f)
+}
+
+// intArg parses an integer argument and writes an error if it isn't one.
+func (c *conn) intArg(s string) (int64, bool) {
+	n, ok := parseInt(s)
+	if !ok {
+		c.writeError(errNotInt)
+	}
+	return n, ok
+}
+
+// floatArg parses a float argument and writes an error if it isn't one.
+func (c *conn) floatArg(s string) (float64, bool) {
+	f, ok := parseFloat(s)
+	if !ok {
+		c.writeError(errNotFloat)
+	}
+	return f, ok
+}
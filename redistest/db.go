@@ -0,0 +1,203 @@
+package redistest
+
+import (
+	"sort"
+	"time"
+)
+
+type db struct {
+	keys map[string]*entry
+}
+
+func newDB() *db {
+	return &db{
+		keys: make(map[string]*entry),
+	}
+}
+
+// entry is a key of a database. The value is one of string, hashValue,
+// *listValue, setValue or zsetValue.
+type entry struct {
+	val      interface{}
+	expireAt time.Time
+}
+
+type (
+	hashValue map[string]string
+	setValue  map[string]struct{}
+	zsetValue map[string]float64
+)
+
+type listValue struct {
+	elems []string
+}
+
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case hashValue:
+		return "hash"
+	case *listValue:
+		return "list"
+	case setValue:
+		return "set"
+	case zsetValue:
+		return "zset"
+	}
+	return "none"
+}
+
+// isEmpty reports whether the value is an aggregate without elements
+// that redis-server removes.
+func isEmpty(val interface{}) bool {
+	switch val := val.(type) {
+	case hashValue:
+		return len(val) == 0
+	case *listValue:
+		return len(val.elems) == 0
+	case setValue:
+		return len(val) == 0
+	case zsetValue:
+		return len(val) == 0
+	}
+	return false
+}
+
+// get returns the entry of key, removing it when it has expired.
+func (d *db) get(key string, now time.Time) *entry {
+	e, ok := d.keys[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !now.Before(e.expireAt) {
+		delete(d.keys, key)
+		return nil
+	}
+	return e
+}
+
+// sortedKeys returns the keys that haven't expired in lexicographical order.
+func (d *db) sortedKeys(now time.Time) []string {
+	keys := make([]string, 0, len(d.keys))
+	for key := range d.keys {
+		if d.get(key, now) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//------------------------------------------------------------------------------
+
+type zsetMember struct {
+	member string
+	score  float64
+}
+
+// sorted returns the members of the sorted set ordered by score and member.
+func (z zsetValue) sorted() []zsetMember {
+	members := make([]zsetMember, 0, len(z))
+	for member, score := range z {
+		members = append(members, zsetMember{member: member, score: score})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].score != members[j].score {
+			return members[i].score < members[j].score
+		}
+		return members[i].member < members[j].member
+	})
+	return members
+}
+
+func sortedMembers(set setValue) []string {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+//------------------------------------------------------------------------------
+
+// matchGlob reports whether s matches the glob-style pattern of KEYS,
+// SCAN and PSUBSCRIBE that supports *, ?, [abc], [^a-z] and \ escapes.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			n, ok := matchClass(pattern, s[0])
+			if !ok {
+				return false
+			}
+			pattern = pattern[n:]
+			s = s[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the class at the start of pattern
+// and returns the length of the class.
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	not := i < len(pattern) && pattern[i] == '^'
+	if not {
+		i++
+	}
+	var match bool
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			match = match || pattern[i] == c
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			match = match || (c >= lo && c <= hi)
+			i += 2
+		default:
+			match = match || pattern[i] == c
+		}
+	}
+	if i < len(pattern) {
+		i++ // ]
+	}
+	return i, match != not
+}
@@ -0,0 +1,283 @@
+// Package redistest implements an in-memory Redis server for tests.
+//
+// The server speaks RESP2 and RESP3 on a local TCP listener, so clients
+// created by redis.NewClient or redis.NewUniversalClient can use it without
+// an external redis-server process:
+//
+//	s := redistest.Run(t)
+//	rdb := redis.NewClient(s.Options())
+//
+// It implements the common commands for strings, hashes, lists, sets and
+// sorted sets as well as key expiry, MULTI/EXEC/WATCH, pub/sub and SCAN.
+// Keys expire by the clock of the server that tests can advance with
+// FastForward instead of sleeping.
+//
+// NewCluster simulates a Redis Cluster for redis.NewClusterClient, whose
+// nodes redirect commands for slots they don't serve. Tests can move slots,
+// migrate keys, inject errors and kill nodes to test resharding and failover.
+package redistest
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const numDBs = 16
+
+// Server is an in-memory Redis server listening on a local address.
+type Server struct {
+	addr string
+	wg   sync.WaitGroup
+
+	*state
+
+	// node is the cluster node the server runs or nil.
+	node *ClusterNode
+
+	// Protected by mu.
+	ln     net.Listener
+	closed bool
+	conns  map[*conn]struct{}
+}
+
+// state is the data of a server. The nodes of a Cluster share it.
+type state struct {
+	// mu protects the state and the servers using it, and serializes
+	// the commands of all connections like redis-server does.
+	mu       sync.Mutex
+	dbs      [numDBs]*db
+	offset   time.Duration
+	password string
+	lastID   int64
+	watchers map[watchKey]map[*conn]struct{}
+	channels map[string]map[*conn]struct{}
+	patterns map[string]map[*conn]struct{}
+}
+
+func newState() *state {
+	st := &state{
+		watchers: make(map[watchKey]map[*conn]struct{}),
+		channels: make(map[string]map[*conn]struct{}),
+		patterns: make(map[string]map[*conn]struct{}),
+	}
+	for i := range st.dbs {
+		st.dbs[i] = newDB()
+	}
+	return st
+}
+
+// NewServer starts a server listening on a random port of 127.0.0.1.
+func NewServer() (*Server, error) {
+	return NewServerAddr("127.0.0.1:0")
+}
+
+// NewServerAddr starts a server listening on the TCP address addr.
+func NewServerAddr(addr string) (*Server, error) {
+	return startServer(addr, newState(), nil)
+}
+
+func startServer(addr string, st *state, node *ClusterNode) (*Server, error) {
+	s := &Server{
+		addr:  addr,
+		state: st,
+		node:  node,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.listen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run starts a server for the test tb and closes it when the test finishes.
+func Run(tb testing.TB) *Server {
+	tb.Helper()
+	s, err := NewServer()
+	if err != nil {
+		tb.Fatalf("redistest: %s", err)
+	}
+	tb.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Options returns client options that connect to the server.
+func (s *Server) Options() *redis.Options {
+	return &redis.Options{
+		Addr:     s.Addr(),
+		Password: s.getPassword(),
+	}
+}
+
+// UniversalOptions returns options for redis.NewUniversalClient
+// that connect to the server.
+func (s *Server) UniversalOptions() *redis.UniversalOptions {
+	return &redis.UniversalOptions{
+		Addrs:    []string{s.Addr()},
+		Password: s.getPassword(),
+	}
+}
+
+// RequireAuth makes connections authenticate with password by AUTH or HELLO
+// before they can run other commands. An empty password disables it.
+func (s *Server) RequireAuth(password string) {
+	s.mu.Lock()
+	s.password = password
+	s.mu.Unlock()
+}
+
+func (s *Server) getPassword() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.password
+}
+
+// FastForward advances the clock of the server by d, expiring keys
+// whose time to live is shorter than that.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	s.offset += d
+	s.mu.Unlock()
+}
+
+// FlushAll removes all keys from all databases. Cluster nodes
+// only remove the keys of the slots they serve.
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.dbs {
+		s.flushDB(i)
+	}
+}
+
+// Close stops the listener, closes all connections and waits for them.
+// The server keeps its data, so Restart can start it again.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for c := range s.conns {
+		_ = c.nc.Close()
+	}
+	ln := s.ln
+	s.mu.Unlock()
+
+	err := ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Restart starts listening on the address of a closed server again,
+// e.g. to test how clients recover from a server restart.
+func (s *Server) Restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		return errors.New("redistest: server is running")
+	}
+	return s.listen()
+}
+
+func (s *Server) listen() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if s.ln == nil {
+		// Restarts reuse the port picked the first time.
+		s.addr = ln.Addr().String()
+	}
+	s.ln = ln
+	s.closed = false
+	s.conns = make(map[*conn]struct{})
+
+	s.wg.Add(1)
+	go s.serve(ln)
+	return nil
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+func (s *Server) serve(ln net.Listener) {
+	defer s.wg.Done()
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = nc.Close()
+			return
+		}
+		s.lastID++
+		c := newConn(s, nc, s.lastID)
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go c.serve()
+	}
+}
+
+// removeConn forgets about c when it has been closed.
+func (s *Server) removeConn(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, c)
+	c.unwatch()
+	c.unsubscribeAll()
+}
+
+//------------------------------------------------------------------------------
+
+type watchKey struct {
+	db  int
+	key string
+}
+
+// touch marks the key as modified for connections that WATCH it.
+func (s *Server) touch(db int, key string) {
+	for c := range s.watchers[watchKey{db: db, key: key}] {
+		c.dirtyCAS = true
+	}
+}
+
+// flushDB removes the keys of a database that are served by s.
+func (s *Server) flushDB(i int) {
+	for key := range s.dbs[i].keys {
+		if s.servesKey(key) {
+			s.touch(i, key)
+			delete(s.dbs[i].keys, key)
+		}
+	}
+}
+
+// servesKey reports whether the key belongs to the server,
+// which is always the case for servers that don't run a cluster node.
+func (s *Server) servesKey(key string) bool {
+	return s.node == nil || s.node.servesSlot(KeySlot(key))
+}
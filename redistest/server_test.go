@@ -0,0 +1,272 @@
+package redistest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redistest"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "redistest")
+}
+
+var ctx = context.Background()
+
+var _ = Describe("Server", func() {
+	var srv *redistest.Server
+
+	BeforeEach(func() {
+		var err error
+		srv, err = redistest.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(srv.Close()).NotTo(HaveOccurred())
+	})
+
+	for _, protocol := range []int{2, 3} {
+		protocol := protocol
+
+		Describe(fmt.Sprintf("RESP%d", protocol), func() {
+			var client *redis.Client
+
+			BeforeEach(func() {
+				opt := srv.Options()
+				opt.Protocol = protocol
+				client = redis.NewClient(opt)
+			})
+
+			AfterEach(func() {
+				Expect(client.Close()).NotTo(HaveOccurred())
+			})
+
+			It("serves strings", func() {
+				Expect(client.Ping(ctx).Val()).To(Equal("PONG"))
+				Expect(client.Set(ctx, "key", "hello", 0).Err()).NotTo(HaveOccurred())
+				Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+				Expect(client.Append(ctx, "key", " world").Val()).To(Equal(int64(11)))
+				Expect(client.GetRange(ctx, "key", -5, -1).Val()).To(Equal("world"))
+				Expect(client.Get(ctx, "missing").Err()).To(Equal(redis.Nil))
+
+				Expect(client.Incr(ctx, "counter").Val()).To(Equal(int64(1)))
+				Expect(client.IncrBy(ctx, "counter", 10).Val()).To(Equal(int64(11)))
+				Expect(client.IncrByFloat(ctx, "counter", 0.5).Val()).To(Equal(11.5))
+				Expect(client.Incr(ctx, "key").Err()).To(MatchError("ERR value is not an integer or out of range"))
+
+				Expect(client.SetNX(ctx, "key", "other", 0).Val()).To(BeFalse())
+				Expect(client.SetArgs(ctx, "key", "new", redis.SetArgs{Get: true}).Val()).To(Equal("hello world"))
+				Expect(client.MGet(ctx, "key", "missing").Val()).To(Equal([]interface{}{"new", nil}))
+			})
+
+			It("expires keys", func() {
+				Expect(client.Set(ctx, "key", "value", time.Minute).Err()).NotTo(HaveOccurred())
+				Expect(client.TTL(ctx, "key").Val()).To(Equal(time.Minute))
+
+				srv.FastForward(30 * time.Second)
+				Expect(client.TTL(ctx, "key").Val()).To(Equal(30 * time.Second))
+				Expect(client.Exists(ctx, "key").Val()).To(Equal(int64(1)))
+
+				srv.FastForward(30 * time.Second)
+				Expect(client.Exists(ctx, "key").Val()).To(Equal(int64(0)))
+				Expect(client.TTL(ctx, "key").Val()).To(Equal(time.Duration(-2)))
+
+				Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+				Expect(client.Expire(ctx, "key", time.Second).Val()).To(BeTrue())
+				Expect(client.Persist(ctx, "key").Val()).To(BeTrue())
+				Expect(client.TTL(ctx, "key").Val()).To(Equal(time.Duration(-1)))
+			})
+
+			It("serves hashes", func() {
+				Expect(client.HSet(ctx, "hash", "a", "1", "b", "2").Val()).To(Equal(int64(2)))
+				Expect(client.HGet(ctx, "hash", "a").Val()).To(Equal("1"))
+				Expect(client.HGetAll(ctx, "hash").Val()).To(Equal(map[string]string{"a": "1", "b": "2"}))
+				Expect(client.HIncrBy(ctx, "hash", "a", 5).Val()).To(Equal(int64(6)))
+				Expect(client.HExists(ctx, "hash", "b").Val()).To(BeTrue())
+				Expect(client.HDel(ctx, "hash", "a", "b").Val()).To(Equal(int64(2)))
+				Expect(client.Exists(ctx, "hash").Val()).To(Equal(int64(0)))
+
+				Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+				Expect(client.HGet(ctx, "key", "a").Err()).To(MatchError(ContainSubstring("WRONGTYPE")))
+			})
+
+			It("serves lists", func() {
+				Expect(client.RPush(ctx, "list", "a", "b", "c").Val()).To(Equal(int64(3)))
+				Expect(client.LPush(ctx, "list", "z").Val()).To(Equal(int64(4)))
+				Expect(client.LRange(ctx, "list", 0, -1).Val()).To(Equal([]string{"z", "a", "b", "c"}))
+				Expect(client.LIndex(ctx, "list", -1).Val()).To(Equal("c"))
+				Expect(client.LPop(ctx, "list").Val()).To(Equal("z"))
+				Expect(client.RPopCount(ctx, "list", 2).Val()).To(Equal([]string{"c", "b"}))
+				Expect(client.LLen(ctx, "list").Val()).To(Equal(int64(1)))
+			})
+
+			It("serves sets", func() {
+				Expect(client.SAdd(ctx, "s1", "a", "b", "c").Val()).To(Equal(int64(3)))
+				Expect(client.SAdd(ctx, "s2", "b", "c", "d").Val()).To(Equal(int64(3)))
+				Expect(client.SIsMember(ctx, "s1", "a").Val()).To(BeTrue())
+				Expect(client.SMembers(ctx, "s1").Val()).To(ConsistOf("a", "b", "c"))
+				Expect(client.SInter(ctx, "s1", "s2").Val()).To(ConsistOf("b", "c"))
+				Expect(client.SUnion(ctx, "s1", "s2").Val()).To(ConsistOf("a", "b", "c", "d"))
+				Expect(client.SDiff(ctx, "s1", "s2").Val()).To(ConsistOf("a"))
+			})
+
+			It("serves sorted sets", func() {
+				Expect(client.ZAdd(ctx, "zset",
+					redis.Z{Score: 1, Member: "one"},
+					redis.Z{Score: 2, Member: "two"},
+					redis.Z{Score: 3, Member: "three"},
+				).Val()).To(Equal(int64(3)))
+
+				Expect(client.ZScore(ctx, "zset", "two").Val()).To(Equal(2.0))
+				Expect(client.ZRange(ctx, "zset", 0, -1).Val()).To(Equal([]string{"one", "two", "three"}))
+				Expect(client.ZRevRangeWithScores(ctx, "zset", 0, 0).Val()).To(Equal([]redis.Z{{Score: 3, Member: "three"}}))
+				Expect(client.ZRangeByScore(ctx, "zset", &redis.ZRangeBy{Min: "(1", Max: "+inf"}).Val()).
+					To(Equal([]string{"two", "three"}))
+				Expect(client.ZIncrBy(ctx, "zset", 5, "one").Val()).To(Equal(6.0))
+				Expect(client.ZRank(ctx, "zset", "one").Val()).To(Equal(int64(2)))
+				Expect(client.ZCount(ctx, "zset", "2", "3").Val()).To(Equal(int64(2)))
+			})
+
+			It("runs transactions", func() {
+				cmds, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					pipe.Set(ctx, "key", "1", 0)
+					pipe.Incr(ctx, "key")
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmds[1].(*redis.IntCmd).Val()).To(Equal(int64(2)))
+
+				err = client.Watch(ctx, func(tx *redis.Tx) error {
+					Expect(tx.Get(ctx, "key").Val()).To(Equal("2"))
+					// Another client modifies the key while it is watched.
+					Expect(client.Set(ctx, "key", "3", 0).Err()).NotTo(HaveOccurred())
+					_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+						pipe.Set(ctx, "key", "4", 0)
+						return nil
+					})
+					return err
+				}, "key")
+				Expect(err).To(Equal(redis.TxFailedErr))
+				Expect(client.Get(ctx, "key").Val()).To(Equal("3"))
+			})
+
+			It("publishes messages", func() {
+				pubsub := client.Subscribe(ctx, "news")
+				defer pubsub.Close()
+				_, err := pubsub.Receive(ctx)
+				Expect(err).NotTo(HaveOccurred())
+
+				psub := client.PSubscribe(ctx, "n*")
+				defer psub.Close()
+				_, err = psub.Receive(ctx)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.Publish(ctx, "news", "hello").Val()).To(Equal(int64(2)))
+
+				msg, err := pubsub.ReceiveMessage(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(msg.Channel).To(Equal("news"))
+				Expect(msg.Payload).To(Equal("hello"))
+
+				msg, err = psub.ReceiveMessage(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(msg.Pattern).To(Equal("n*"))
+				Expect(msg.Payload).To(Equal("hello"))
+
+				Expect(pubsub.Ping(ctx)).NotTo(HaveOccurred())
+				Expect(client.PubSubNumSub(ctx, "news").Val()).To(Equal(map[string]int64{"news": 1}))
+			})
+
+			It("scans keys", func() {
+				for i := 0; i < 100; i++ {
+					Expect(client.Set(ctx, fmt.Sprintf("key%d", i), "", 0).Err()).NotTo(HaveOccurred())
+				}
+				Expect(client.SAdd(ctx, "set", "member").Err()).NotTo(HaveOccurred())
+
+				var keys []string
+				iter := client.Scan(ctx, 0, "key*", 7).Iterator()
+				for iter.Next(ctx) {
+					keys = append(keys, iter.Val())
+					// Keys removed during the iteration don't hide the others.
+					Expect(client.Del(ctx, iter.Val()).Err()).NotTo(HaveOccurred())
+				}
+				Expect(iter.Err()).NotTo(HaveOccurred())
+				Expect(keys).To(HaveLen(100))
+
+				keys, _, err := client.ScanType(ctx, 0, "", 100, "set").Result()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keys).To(Equal([]string{"set"}))
+			})
+		})
+	}
+
+	It("supports universal clients", func() {
+		client := redis.NewUniversalClient(srv.UniversalOptions())
+		defer client.Close()
+
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("value"))
+	})
+
+	It("selects databases", func() {
+		opt := srv.Options()
+		opt.DB = 1
+		client := redis.NewClient(opt)
+		defer client.Close()
+		other := redis.NewClient(srv.Options())
+		defer other.Close()
+
+		Expect(client.Set(ctx, "key", "value", 0).Err()).NotTo(HaveOccurred())
+		Expect(other.Exists(ctx, "key").Val()).To(Equal(int64(0)))
+		Expect(client.DBSize(ctx).Val()).To(Equal(int64(1)))
+	})
+
+	It("requires authentication", func() {
+		srv.RequireAuth("secret")
+
+		client := redis.NewClient(srv.Options())
+		defer client.Close()
+		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
+
+		opt := srv.Options()
+		opt.Password = "wrong"
+		client = redis.NewClient(opt)
+		defer client.Close()
+		Expect(client.Ping(ctx).Err()).To(MatchError(ContainSubstring("WRONGPASS")))
+	})
+
+	It("rejects WATCH inside MULTI", func() {
+		client := redis.NewClient(srv.Options())
+		defer client.Close()
+
+		_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Do(ctx, "watch", "key")
+			pipe.Set(ctx, "key", "1", 0)
+			return nil
+		})
+		Expect(err).To(MatchError(ContainSubstring("EXECABORT")))
+		Expect(client.Get(ctx, "key").Err()).To(Equal(redis.Nil))
+
+		conn := client.Conn()
+		defer conn.Close()
+		Expect(conn.Process(ctx, redis.NewCmd(ctx, "multi"))).To(Succeed())
+		err = conn.Process(ctx, redis.NewCmd(ctx, "watch", "key"))
+		Expect(err).To(MatchError("ERR WATCH inside MULTI is not allowed"))
+	})
+
+	It("rejects unknown commands", func() {
+		client := redis.NewClient(srv.Options())
+		defer client.Close()
+
+		err := client.Do(ctx, "nosuchcommand", "arg").Err()
+		Expect(err).To(MatchError("ERR unknown command 'nosuchcommand', with args beginning with: 'arg' "))
+	})
+})
@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9/internal"
+	"github.com/redis/go-redis/v9/internal/pool"
+)
+
+// RetryPolicy decides which failed commands are retried and how long
+// to back off before retrying them.
+type RetryPolicy interface {
+	// ShouldRetry reports whether to retry cmds after their attempt,
+	// counting from 0, failed with err. cmds holds a single command or
+	// the commands of a pipeline or transaction. It returns false when
+	// the retry budget is exhausted.
+	ShouldRetry(ctx context.Context, cmds []Cmder, attempt int, err error) bool
+	// Backoff returns how long to wait before the retry, counting from 1.
+	Backoff(retry int) time.Duration
+}
+
+// retryPolicy is the default RetryPolicy. It retries up to maxRetries times
+// with an exponential backoff and jitter, but it doesn't retry commands
+// that may have been applied by the server unless they are idempotent.
+type retryPolicy struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// cmdsInfo returns the COMMAND flags to tell the idempotent commands.
+	// It is nil when they aren't available.
+	cmdsInfo func(ctx context.Context) (map[string]*CommandInfo, error)
+}
+
+var _ RetryPolicy = (*retryPolicy)(nil)
+
+// newRetryPolicy returns policy, or the default policy when it is nil.
+func newRetryPolicy(
+	policy RetryPolicy,
+	maxRetries int,
+	minBackoff, maxBackoff time.Duration,
+	cmdsInfo func(ctx context.Context) (map[string]*CommandInfo, error),
+) RetryPolicy {
+	if policy != nil {
+		return policy
+	}
+	return &retryPolicy{
+		maxRetries: maxRetries,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		cmdsInfo:   cmdsInfo,
+	}
+}
+
+func (p *retryPolicy) ShouldRetry(ctx context.Context, cmds []Cmder, attempt int, err error) bool {
+	if attempt >= p.maxRetries {
+		return false
+	}
+	switch err {
+	case pool.ErrPoolTimeout, pool.ErrPoolExhausted, pool.ErrBudgetTimeout:
+		// No connection was available to send the commands.
+		return true
+	}
+	if !shouldRetry(err, true) {
+		return false
+	}
+	if !mayBeApplied(err) {
+		return true
+	}
+
+	var info map[string]*CommandInfo
+	var loaded bool
+	for _, cmd := range cmds {
+		if cmd.readTimeout() != nil && isTimeoutError(err) {
+			// Blocking commands time out waiting for data.
+			return false
+		}
+
+		if isIdempotentCmd(cmd) {
+			continue
+		}
+		if !loaded && p.cmdsInfo != nil {
+			info, _ = p.cmdsInfo(ctx)
+			loaded = true
+		}
+		if !isIdempotent(info[cmd.Name()]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *retryPolicy) Backoff(retry int) time.Duration {
+	return internal.RetryBackoff(retry, p.minBackoff, p.maxBackoff)
+}
+
+// mayBeApplied reports whether the server may have processed the commands
+// that failed with err.
+func mayBeApplied(err error) bool {
+	switch err {
+	case pool.ErrPoolTimeout, pool.ErrPoolExhausted, pool.ErrBudgetTimeout, pool.ErrClosed:
+		// The commands were never sent.
+		return false
+	}
+	switch err := err.(type) {
+	case *HandshakeError:
+		return false
+	case *net.OpError:
+		return err.Op != "dial"
+	}
+	// The server rejected the commands, e.g. with LOADING or TRYAGAIN.
+	return !isRedisError(err)
+}
+
+func isTimeoutError(err error) bool {
+	v, ok := err.(timeoutError)
+	return ok && v.Timeout()
+}
+
+// idempotentCmds are commands that can be applied twice with the same
+// effect and reply. The others are idempotent when COMMAND doesn't flag them
+// as writes, scripts or publishing.
+var idempotentCmds = map[string]bool{
+	// COMMAND itself, to not look up its flags when it fails.
+	"command": true,
+
+	"ping":    true,
+	"echo":    true,
+	"multi":   true,
+	"exec":    true,
+	"discard": true,
+	"watch":   true,
+	"unwatch": true,
+
+	"setex":  true,
+	"psetex": true,
+	"mset":   true,
+	"hmset":  true,
+}
+
+// isIdempotentCmd reports whether cmd is idempotent judging by its name
+// and arguments.
+func isIdempotentCmd(cmd Cmder) bool {
+	name := cmd.Name()
+	if name == "set" {
+		// The replies of SET with NX, XX or GET depend on the value
+		// the first attempt may have written. The options follow the value.
+		for i := 3; i < len(cmd.Args()); i++ {
+			switch strings.ToLower(cmd.stringArg(i)) {
+			case "nx", "xx", "get":
+				return false
+			}
+		}
+		return true
+	}
+	return idempotentCmds[name]
+}
+
+func isIdempotent(info *CommandInfo) bool {
+	if info == nil {
+		return false
+	}
+	var noScript, movableKeys bool
+	for _, flag := range info.Flags {
+		switch flag {
+		case "write", "may_replicate", "pubsub":
+			return false
+		case "noscript":
+			noScript = true
+		case "movablekeys":
+			movableKeys = true
+		}
+	}
+	// Before Redis 6.2, EVAL and EVALSHA are only flagged this way.
+	return !(noScript && movableKeys)
+}
@@ -69,6 +69,7 @@ type RingOptions struct {
 
 	Dialer    func(ctx context.Context, network, addr string) (net.Conn, error)
 	OnConnect func(ctx context.Context, cn *Conn) error
+	OnPush    func(ctx context.Context, push *Push)
 
 	Protocol int
 	Username string
@@ -78,28 +79,41 @@ type RingOptions struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+	// RetryPolicy decides which failed commands are retried. The default
+	// policy retries up to MaxRetries times. See Options.RetryPolicy.
+	RetryPolicy RetryPolicy
 
 	DialTimeout           time.Duration
 	ReadTimeout           time.Duration
 	WriteTimeout          time.Duration
 	ContextTimeoutEnabled bool
 
+	MaxBulkLen      int
+	MaxAggregateLen int
+	MaxReplyDepth   int
+
 	// PoolFIFO uses FIFO mode for each node connection pool GET/PUT (default LIFO).
 	PoolFIFO bool
 
-	PoolSize        int
-	PoolTimeout     time.Duration
-	MinIdleConns    int
-	MaxIdleConns    int
-	MaxActiveConns  int
-	ConnMaxIdleTime time.Duration
-	ConnMaxLifetime time.Duration
+	PoolSize            int
+	PoolTimeout         time.Duration
+	MinIdleConns        int
+	MaxIdleConns        int
+	MaxActiveConns      int
+	ConnMaxIdleTime     time.Duration
+	ConnMaxLifetime     time.Duration
+	IdleCheckFrequency  time.Duration
+	HealthCheckInterval time.Duration
 
 	TLSConfig *tls.Config
 	Limiter   Limiter
 
 	DisableIndentity bool
 	IdentitySuffix   string
+
+	// ConnBudget limits the connections of all shards together.
+	// See ConnBudgetOptions. Default is nil, which only limits them per shard.
+	ConnBudget *ConnBudgetOptions
 }
 
 func (opt *RingOptions) init() {
@@ -141,6 +155,7 @@ func (opt *RingOptions) clientOptions() *Options {
 		ClientName: opt.ClientName,
 		Dialer:     opt.Dialer,
 		OnConnect:  opt.OnConnect,
+		OnPush:     opt.OnPush,
 
 		Protocol: opt.Protocol,
 		Username: opt.Username,
@@ -153,15 +168,20 @@ func (opt *RingOptions) clientOptions() *Options {
 		ReadTimeout:           opt.ReadTimeout,
 		WriteTimeout:          opt.WriteTimeout,
 		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
-
-		PoolFIFO:        opt.PoolFIFO,
-		PoolSize:        opt.PoolSize,
-		PoolTimeout:     opt.PoolTimeout,
-		MinIdleConns:    opt.MinIdleConns,
-		MaxIdleConns:    opt.MaxIdleConns,
-		MaxActiveConns:  opt.MaxActiveConns,
-		ConnMaxIdleTime: opt.ConnMaxIdleTime,
-		ConnMaxLifetime: opt.ConnMaxLifetime,
+		MaxBulkLen:            opt.MaxBulkLen,
+		MaxAggregateLen:       opt.MaxAggregateLen,
+		MaxReplyDepth:         opt.MaxReplyDepth,
+
+		PoolFIFO:            opt.PoolFIFO,
+		PoolSize:            opt.PoolSize,
+		PoolTimeout:         opt.PoolTimeout,
+		MinIdleConns:        opt.MinIdleConns,
+		MaxIdleConns:        opt.MaxIdleConns,
+		MaxActiveConns:      opt.MaxActiveConns,
+		ConnMaxIdleTime:     opt.ConnMaxIdleTime,
+		ConnMaxLifetime:     opt.ConnMaxLifetime,
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheckInterval: opt.HealthCheckInterval,
 
 		TLSConfig: opt.TLSConfig,
 		Limiter:   opt.Limiter,
@@ -179,9 +199,10 @@ type ringShard struct {
 	addr   string
 }
 
-func newRingShard(opt *RingOptions, addr string) *ringShard {
+func newRingShard(opt *RingOptions, addr string, budget *pool.Budget) *ringShard {
 	clopt := opt.clientOptions()
 	clopt.Addr = addr
+	clopt.connBudget = budget
 
 	return &ringShard{
 		Client: opt.NewClient(clopt),
@@ -227,7 +248,8 @@ func (shard *ringShard) Vote(up bool) bool {
 //------------------------------------------------------------------------------
 
 type ringSharding struct {
-	opt *RingOptions
+	opt    *RingOptions
+	budget *pool.Budget
 
 	mu        sync.RWMutex
 	shards    *ringShards
@@ -248,7 +270,8 @@ type ringShards struct {
 
 func newRingSharding(opt *RingOptions) *ringSharding {
 	c := &ringSharding{
-		opt: opt,
+		opt:    opt,
+		budget: newConnBudget(opt.ConnBudget, opt.clientOptions()),
 	}
 	c.SetAddrs(opt.Addrs)
 
@@ -317,7 +340,7 @@ func (c *ringSharding) newRingShards(
 			shards.m[name] = shard
 			delete(unused, addr)
 		} else {
-			shard := newRingShard(c.opt, addr)
+			shard := newRingShard(c.opt, addr, c.budget)
 			shards.m[name] = shard
 			created[addr] = shard
 
@@ -409,7 +432,7 @@ func (c *ringSharding) Heartbeat(ctx context.Context, frequency time.Duration) {
 
 			for _, shard := range c.List() {
 				err := shard.Client.Ping(ctx).Err()
-				isUp := err == nil || err == pool.ErrPoolTimeout
+				isUp := err == nil || err == pool.ErrPoolTimeout || err == pool.ErrBudgetTimeout
 				if shard.Vote(isUp) {
 					internal.Logger.Printf(ctx, "ring shard state changed: %s", shard)
 					rebalance = true
@@ -503,6 +526,7 @@ type Ring struct {
 	opt               *RingOptions
 	sharding          *ringSharding
 	cmdsInfoCache     *cmdsInfoCache
+	retryPolicy       RetryPolicy
 	heartbeatCancelFn context.CancelFunc
 }
 
@@ -518,6 +542,9 @@ func NewRing(opt *RingOptions) *Ring {
 	}
 
 	ring.cmdsInfoCache = newCmdsInfoCache(ring.cmdsInfo)
+	ring.retryPolicy = newRetryPolicy(
+		opt.RetryPolicy, opt.MaxRetries, opt.MinRetryBackoff, opt.MaxRetryBackoff, ring.cmdsInfoCache.Get,
+	)
 	ring.cmdable = ring.Process
 
 	ring.initHooks(hooks{
@@ -546,6 +573,13 @@ func (c *Ring) Do(ctx context.Context, args ...interface{}) *Cmd {
 	return cmd
 }
 
+// DoValue creates a ValueCmd from the args and processes the cmd.
+func (c *Ring) DoValue(ctx context.Context, args ...interface{}) *ValueCmd {
+	cmd := NewValueCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
 func (c *Ring) Process(ctx context.Context, cmd Cmder) error {
 	err := c.processHook(ctx, cmd)
 	cmd.SetErr(err)
@@ -557,25 +591,22 @@ func (c *Ring) Options() *RingOptions {
 	return c.opt
 }
 
-func (c *Ring) retryBackoff(attempt int) time.Duration {
-	return internal.RetryBackoff(attempt, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff)
-}
-
 // PoolStats returns accumulated connection pool stats.
 func (c *Ring) PoolStats() *PoolStats {
 	shards := c.sharding.List()
 	var acc PoolStats
 	for _, shard := range shards {
-		s := shard.Client.connPool.Stats()
-		acc.Hits += s.Hits
-		acc.Misses += s.Misses
-		acc.Timeouts += s.Timeouts
-		acc.TotalConns += s.TotalConns
-		acc.IdleConns += s.IdleConns
+		acc.add(shard.Client.connPool.Stats())
 	}
 	return &acc
 }
 
+// ConnBudgetStats returns the stats of the connection budget shared by
+// all shards. They are zero when ConnBudget isn't set.
+func (c *Ring) ConnBudgetStats() *ConnBudgetStats {
+	return connBudgetStats(c.sharding.budget)
+}
+
 // Len returns the current number of shards in the ring.
 func (c *Ring) Len() int {
 	return c.sharding.Len()
@@ -681,6 +712,10 @@ func (c *Ring) cmdsInfo(ctx context.Context) (map[string]*CommandInfo, error) {
 }
 
 func (c *Ring) cmdShard(ctx context.Context, cmd Cmder) (*ringShard, error) {
+	if shard, err := c.callShard(ctx); shard != nil || err != nil {
+		return shard, err
+	}
+
 	pos := cmdFirstKeyPos(cmd)
 	if pos == 0 {
 		return c.sharding.Random()
@@ -689,11 +724,24 @@ func (c *Ring) cmdShard(ctx context.Context, cmd Cmder) (*ringShard, error) {
 	return c.sharding.GetByKey(firstKey)
 }
 
+// callShard returns the shard the call options of ctx pin the commands to,
+// or nil when they don't.
+func (c *Ring) callShard(ctx context.Context) (*ringShard, error) {
+	opt := CallOptionsFromContext(ctx)
+	if opt == nil || opt.Node == "" {
+		return nil, nil
+	}
+	shard, err := c.sharding.GetByName(opt.Node)
+	if err == nil && shard == nil {
+		err = fmt.Errorf("redis: ring shard %q not found", opt.Node)
+	}
+	return shard, err
+}
+
 func (c *Ring) process(ctx context.Context, cmd Cmder) error {
-	var lastErr error
-	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
+	for attempt := 0; ; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, c.retry(ctx).Backoff(attempt)); err != nil {
 				return err
 			}
 		}
@@ -703,12 +751,15 @@ func (c *Ring) process(ctx context.Context, cmd Cmder) error {
 			return err
 		}
 
-		lastErr = shard.Client.Process(ctx, cmd)
-		if lastErr == nil || !shouldRetry(lastErr, cmd.readTimeout() == nil) {
-			return lastErr
+		err = shard.Client.Process(ctx, cmd)
+		if err == nil || !c.retry(ctx).ShouldRetry(ctx, []Cmder{cmd}, attempt, err) {
+			return err
 		}
 	}
-	return lastErr
+}
+
+func (c *Ring) retry(ctx context.Context) RetryPolicy {
+	return callRetryPolicy(ctx, c.retryPolicy)
 }
 
 func (c *Ring) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
@@ -746,6 +797,20 @@ func (c *Ring) generalProcessPipeline(
 		cmds = cmds[1 : len(cmds)-1]
 	}
 
+	shard, err := c.callShard(ctx)
+	if err != nil {
+		setCmdsErr(cmds, err)
+		return err
+	}
+	if shard != nil {
+		if tx {
+			_ = shard.Client.processTxPipelineHook(ctx, wrapMultiExec(ctx, cmds))
+		} else {
+			_ = shard.Client.processPipelineHook(ctx, cmds)
+		}
+		return cmdsFirstErr(cmds)
+	}
+
 	cmdsMap := make(map[string][]Cmder)
 
 	for _, cmd := range cmds {
@@ -787,6 +852,13 @@ func (c *Ring) Watch(ctx context.Context, fn func(*Tx) error, keys ...string) er
 		return fmt.Errorf("redis: Watch requires at least one key")
 	}
 
+	if shard, err := c.callShard(ctx); shard != nil || err != nil {
+		if err != nil {
+			return err
+		}
+		return shard.Client.Watch(ctx, fn, keys...)
+	}
+
 	var shards []*ringShard
 
 	for _, key := range keys {
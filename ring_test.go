@@ -168,6 +168,38 @@ var _ = Describe("Redis Ring", func() {
 		Expect(ringShard2.Info(ctx, "keyspace").Val()).To(ContainSubstring("keys=100"))
 	})
 
+	It("pins commands to a shard with CallOptions", func() {
+		callCtx := redis.WithCallOptions(ctx, &redis.CallOptions{Node: "ringShardOne"})
+		for i := 0; i < 100; i++ {
+			err := ring.Set(callCtx, fmt.Sprintf("key%d", i), "value", 0).Err()
+			Expect(err).NotTo(HaveOccurred())
+		}
+		cmds, err := ring.Pipelined(callCtx, func(pipe redis.Pipeliner) error {
+			for i := 0; i < 100; i++ {
+				pipe.Get(ctx, fmt.Sprintf("key%d", i))
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmds).To(HaveLen(100))
+
+		err = ring.Watch(callCtx, func(tx *redis.Tx) error {
+			_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, "key100", "value", 0)
+				return nil
+			})
+			return err
+		}, "key100")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(ringShard1.Info(ctx, "keyspace").Val()).To(ContainSubstring("keys=101"))
+		Expect(ringShard2.Info(ctx, "keyspace").Val()).ToNot(ContainSubstring("keys="))
+
+		callCtx = redis.WithCallOptions(ctx, &redis.CallOptions{Node: "missing"})
+		err = ring.Get(callCtx, "key").Err()
+		Expect(err).To(MatchError(`redis: ring shard "missing" not found`))
+	})
+
 	Describe("[new] dynamic setting ring shards", func() {
 		It("downscale shard and check reuse shard, upscale shard and check reuse", func() {
 			Expect(ring.Len(), 2)
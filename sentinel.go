@@ -42,6 +42,12 @@ type FailoverOptions struct {
 	// This option only works with NewFailoverClusterClient.
 	RouteRandomly bool
 
+	// ReadRouter picks the master or replica node for read-only commands
+	// with NewFailoverClusterClient, and the replica node to connect to
+	// with ReplicaOnly. The labels of the replicas are the fields of
+	// SENTINEL REPLICAS.
+	ReadRouter ReadRouter
+
 	// Route all commands to replica read-only nodes.
 	ReplicaOnly bool
 
@@ -53,6 +59,7 @@ type FailoverOptions struct {
 
 	Dialer    func(ctx context.Context, network, addr string) (net.Conn, error)
 	OnConnect func(ctx context.Context, cn *Conn) error
+	OnPush    func(ctx context.Context, push *Push)
 
 	Protocol int
 	Username string
@@ -62,21 +69,30 @@ type FailoverOptions struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+	// RetryPolicy decides which failed commands are retried.
+	// See Options.RetryPolicy.
+	RetryPolicy RetryPolicy
 
 	DialTimeout           time.Duration
 	ReadTimeout           time.Duration
 	WriteTimeout          time.Duration
 	ContextTimeoutEnabled bool
 
+	MaxBulkLen      int
+	MaxAggregateLen int
+	MaxReplyDepth   int
+
 	PoolFIFO bool
 
-	PoolSize        int
-	PoolTimeout     time.Duration
-	MinIdleConns    int
-	MaxIdleConns    int
-	MaxActiveConns  int
-	ConnMaxIdleTime time.Duration
-	ConnMaxLifetime time.Duration
+	PoolSize            int
+	PoolTimeout         time.Duration
+	MinIdleConns        int
+	MaxIdleConns        int
+	MaxActiveConns      int
+	ConnMaxIdleTime     time.Duration
+	ConnMaxLifetime     time.Duration
+	IdleCheckFrequency  time.Duration
+	HealthCheckInterval time.Duration
 
 	TLSConfig *tls.Config
 
@@ -91,6 +107,7 @@ func (opt *FailoverOptions) clientOptions() *Options {
 
 		Dialer:    opt.Dialer,
 		OnConnect: opt.OnConnect,
+		OnPush:    opt.OnPush,
 
 		DB:       opt.DB,
 		Protocol: opt.Protocol,
@@ -100,20 +117,26 @@ func (opt *FailoverOptions) clientOptions() *Options {
 		MaxRetries:      opt.MaxRetries,
 		MinRetryBackoff: opt.MinRetryBackoff,
 		MaxRetryBackoff: opt.MaxRetryBackoff,
+		RetryPolicy:     opt.RetryPolicy,
 
 		DialTimeout:           opt.DialTimeout,
 		ReadTimeout:           opt.ReadTimeout,
 		WriteTimeout:          opt.WriteTimeout,
 		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
-
-		PoolFIFO:        opt.PoolFIFO,
-		PoolSize:        opt.PoolSize,
-		PoolTimeout:     opt.PoolTimeout,
-		MinIdleConns:    opt.MinIdleConns,
-		MaxIdleConns:    opt.MaxIdleConns,
-		MaxActiveConns:  opt.MaxActiveConns,
-		ConnMaxIdleTime: opt.ConnMaxIdleTime,
-		ConnMaxLifetime: opt.ConnMaxLifetime,
+		MaxBulkLen:            opt.MaxBulkLen,
+		MaxAggregateLen:       opt.MaxAggregateLen,
+		MaxReplyDepth:         opt.MaxReplyDepth,
+
+		PoolFIFO:            opt.PoolFIFO,
+		PoolSize:            opt.PoolSize,
+		PoolTimeout:         opt.PoolTimeout,
+		MinIdleConns:        opt.MinIdleConns,
+		MaxIdleConns:        opt.MaxIdleConns,
+		MaxActiveConns:      opt.MaxActiveConns,
+		ConnMaxIdleTime:     opt.ConnMaxIdleTime,
+		ConnMaxLifetime:     opt.ConnMaxLifetime,
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheckInterval: opt.HealthCheckInterval,
 
 		TLSConfig: opt.TLSConfig,
 
@@ -142,15 +165,20 @@ func (opt *FailoverOptions) sentinelOptions(addr string) *Options {
 		ReadTimeout:           opt.ReadTimeout,
 		WriteTimeout:          opt.WriteTimeout,
 		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
-
-		PoolFIFO:        opt.PoolFIFO,
-		PoolSize:        opt.PoolSize,
-		PoolTimeout:     opt.PoolTimeout,
-		MinIdleConns:    opt.MinIdleConns,
-		MaxIdleConns:    opt.MaxIdleConns,
-		MaxActiveConns:  opt.MaxActiveConns,
-		ConnMaxIdleTime: opt.ConnMaxIdleTime,
-		ConnMaxLifetime: opt.ConnMaxLifetime,
+		MaxBulkLen:            opt.MaxBulkLen,
+		MaxAggregateLen:       opt.MaxAggregateLen,
+		MaxReplyDepth:         opt.MaxReplyDepth,
+
+		PoolFIFO:            opt.PoolFIFO,
+		PoolSize:            opt.PoolSize,
+		PoolTimeout:         opt.PoolTimeout,
+		MinIdleConns:        opt.MinIdleConns,
+		MaxIdleConns:        opt.MaxIdleConns,
+		MaxActiveConns:      opt.MaxActiveConns,
+		ConnMaxIdleTime:     opt.ConnMaxIdleTime,
+		ConnMaxLifetime:     opt.ConnMaxLifetime,
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheckInterval: opt.HealthCheckInterval,
 
 		TLSConfig: opt.TLSConfig,
 	}
@@ -162,6 +190,7 @@ func (opt *FailoverOptions) clusterOptions() *ClusterOptions {
 
 		Dialer:    opt.Dialer,
 		OnConnect: opt.OnConnect,
+		OnPush:    opt.OnPush,
 
 		Protocol: opt.Protocol,
 		Username: opt.Username,
@@ -171,23 +200,30 @@ func (opt *FailoverOptions) clusterOptions() *ClusterOptions {
 
 		RouteByLatency: opt.RouteByLatency,
 		RouteRandomly:  opt.RouteRandomly,
+		ReadRouter:     opt.ReadRouter,
 
 		MinRetryBackoff: opt.MinRetryBackoff,
 		MaxRetryBackoff: opt.MaxRetryBackoff,
+		RetryPolicy:     opt.RetryPolicy,
 
 		DialTimeout:           opt.DialTimeout,
 		ReadTimeout:           opt.ReadTimeout,
 		WriteTimeout:          opt.WriteTimeout,
 		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
-
-		PoolFIFO:        opt.PoolFIFO,
-		PoolSize:        opt.PoolSize,
-		PoolTimeout:     opt.PoolTimeout,
-		MinIdleConns:    opt.MinIdleConns,
-		MaxIdleConns:    opt.MaxIdleConns,
-		MaxActiveConns:  opt.MaxActiveConns,
-		ConnMaxIdleTime: opt.ConnMaxIdleTime,
-		ConnMaxLifetime: opt.ConnMaxLifetime,
+		MaxBulkLen:            opt.MaxBulkLen,
+		MaxAggregateLen:       opt.MaxAggregateLen,
+		MaxReplyDepth:         opt.MaxReplyDepth,
+
+		PoolFIFO:            opt.PoolFIFO,
+		PoolSize:            opt.PoolSize,
+		PoolTimeout:         opt.PoolTimeout,
+		MinIdleConns:        opt.MinIdleConns,
+		MaxIdleConns:        opt.MaxIdleConns,
+		MaxActiveConns:      opt.MaxActiveConns,
+		ConnMaxIdleTime:     opt.ConnMaxIdleTime,
+		ConnMaxLifetime:     opt.ConnMaxLifetime,
+		IdleCheckFrequency:  opt.IdleCheckFrequency,
+		HealthCheckInterval: opt.HealthCheckInterval,
 
 		TLSConfig: opt.TLSConfig,
 	}
@@ -228,8 +264,9 @@ func NewFailoverClient(failoverOpt *FailoverOptions) *Client {
 		},
 	}
 	rdb.init()
+	rdb.initRetryPolicy()
 
-	connPool = newConnPool(opt, rdb.dialHook)
+	connPool = newConnPool(opt, rdb.dialHook, rdb.healthCheck)
 	rdb.connPool = connPool
 	rdb.onClose = failover.Close
 
@@ -292,12 +329,13 @@ func NewSentinelClient(opt *Options) *SentinelClient {
 			opt: opt,
 		},
 	}
+	c.initRetryPolicy()
 
 	c.initHooks(hooks{
 		dial:    c.baseClient.dial,
 		process: c.baseClient.process,
 	})
-	c.connPool = newConnPool(opt, c.dialHook)
+	c.connPool = newConnPool(opt, c.dialHook, c.healthCheck)
 
 	return c
 }
@@ -485,22 +523,38 @@ func (c *sentinelFailover) RandomReplicaAddr(ctx context.Context) (string, error
 		return "", errors.New("opt is nil")
 	}
 
-	addresses, err := c.replicaAddrs(ctx, false)
+	replicas, err := c.replicas(ctx, false)
 	if err != nil {
 		return "", err
 	}
 
-	if len(addresses) == 0 && c.opt.UseDisconnectedReplicas {
-		addresses, err = c.replicaAddrs(ctx, true)
+	if len(replicas) == 0 && c.opt.UseDisconnectedReplicas {
+		replicas, err = c.replicas(ctx, true)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	if len(addresses) == 0 {
+	if len(replicas) == 0 {
 		return c.MasterAddr(ctx)
 	}
-	return addresses[rand.Intn(len(addresses))], nil
+	if c.opt.ReadRouter != nil {
+		return routeReplica(ctx, c.opt.ReadRouter, replicas), nil
+	}
+	return replicas[rand.Intn(len(replicas))].Addr, nil
+}
+
+// routeReplica returns the address of the replica the router picks.
+func routeReplica(ctx context.Context, router ReadRouter, replicas []ClusterNode) string {
+	nodes := make([]ReadNode, len(replicas))
+	for i, replica := range replicas {
+		nodes[i] = ReadNode{
+			Addr:    replica.Addr,
+			Failing: strings.Contains(replica.NetworkingMetadata["flags"], "disconnected"),
+			Labels:  replica.NetworkingMetadata,
+		}
+	}
+	return replicas[routeRead(ctx, router, nodes)].Addr
 }
 
 func (c *sentinelFailover) MasterAddr(ctx context.Context) (string, error) {
@@ -565,13 +619,15 @@ func (c *sentinelFailover) MasterAddr(ctx context.Context) (string, error) {
 	return "", errors.New("redis: all sentinels specified in configuration are unreachable")
 }
 
-func (c *sentinelFailover) replicaAddrs(ctx context.Context, useDisconnected bool) ([]string, error) {
+// replicas returns the replicas with the fields of SENTINEL REPLICAS
+// as their networking metadata.
+func (c *sentinelFailover) replicas(ctx context.Context, useDisconnected bool) ([]ClusterNode, error) {
 	c.mu.RLock()
 	sentinel := c.sentinel
 	c.mu.RUnlock()
 
 	if sentinel != nil {
-		addrs, err := c.getReplicaAddrs(ctx, sentinel)
+		nodes, err := c.getReplicas(ctx, sentinel)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				return nil, err
@@ -579,8 +635,8 @@ func (c *sentinelFailover) replicaAddrs(ctx context.Context, useDisconnected boo
 			// Continue on other errors
 			internal.Logger.Printf(ctx, "sentinel: Replicas name=%q failed: %s",
 				c.opt.MasterName, err)
-		} else if len(addrs) > 0 {
-			return addrs, nil
+		} else if len(nodes) > 0 {
+			return nodes, nil
 		}
 	}
 
@@ -588,7 +644,7 @@ func (c *sentinelFailover) replicaAddrs(ctx context.Context, useDisconnected boo
 	defer c.mu.Unlock()
 
 	if c.sentinel != nil {
-		addrs, err := c.getReplicaAddrs(ctx, c.sentinel)
+		nodes, err := c.getReplicas(ctx, c.sentinel)
 		if err != nil {
 			_ = c.closeSentinel()
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
@@ -597,8 +653,8 @@ func (c *sentinelFailover) replicaAddrs(ctx context.Context, useDisconnected boo
 			// Continue on other errors
 			internal.Logger.Printf(ctx, "sentinel: Replicas name=%q failed: %s",
 				c.opt.MasterName, err)
-		} else if len(addrs) > 0 {
-			return addrs, nil
+		} else if len(nodes) > 0 {
+			return nodes, nil
 		} else {
 			// No error and no replicas.
 			_ = c.closeSentinel()
@@ -621,21 +677,21 @@ func (c *sentinelFailover) replicaAddrs(ctx context.Context, useDisconnected boo
 			continue
 		}
 		sentinelReachable = true
-		addrs := parseReplicaAddrs(replicas, useDisconnected)
-		if len(addrs) == 0 {
+		nodes := parseReplicas(replicas, useDisconnected)
+		if len(nodes) == 0 {
 			continue
 		}
 		// Push working sentinel to the top.
 		c.sentinelAddrs[0], c.sentinelAddrs[i] = c.sentinelAddrs[i], c.sentinelAddrs[0]
 		c.setSentinel(ctx, sentinel)
 
-		return addrs, nil
+		return nodes, nil
 	}
 
 	if sentinelReachable {
-		return []string{}, nil
+		return []ClusterNode{}, nil
 	}
-	return []string{}, errors.New("redis: all sentinels specified in configuration are unreachable")
+	return []ClusterNode{}, errors.New("redis: all sentinels specified in configuration are unreachable")
 }
 
 func (c *sentinelFailover) getMasterAddr(ctx context.Context, sentinel *SentinelClient) (string, error) {
@@ -646,18 +702,18 @@ func (c *sentinelFailover) getMasterAddr(ctx context.Context, sentinel *Sentinel
 	return net.JoinHostPort(addr[0], addr[1]), nil
 }
 
-func (c *sentinelFailover) getReplicaAddrs(ctx context.Context, sentinel *SentinelClient) ([]string, error) {
+func (c *sentinelFailover) getReplicas(ctx context.Context, sentinel *SentinelClient) ([]ClusterNode, error) {
 	addrs, err := sentinel.Replicas(ctx, c.opt.MasterName).Result()
 	if err != nil {
 		internal.Logger.Printf(ctx, "sentinel: Replicas name=%q failed: %s",
 			c.opt.MasterName, err)
 		return nil, err
 	}
-	return parseReplicaAddrs(addrs, false), nil
+	return parseReplicas(addrs, false), nil
 }
 
-func parseReplicaAddrs(addrs []map[string]string, keepDisconnected bool) []string {
-	nodes := make([]string, 0, len(addrs))
+func parseReplicas(addrs []map[string]string, keepDisconnected bool) []ClusterNode {
+	nodes := make([]ClusterNode, 0, len(addrs))
 	for _, node := range addrs {
 		isDown := false
 		if flags, ok := node["flags"]; ok {
@@ -673,13 +729,24 @@ func parseReplicaAddrs(addrs []map[string]string, keepDisconnected bool) []strin
 			}
 		}
 		if !isDown && node["ip"] != "" && node["port"] != "" {
-			nodes = append(nodes, net.JoinHostPort(node["ip"], node["port"]))
+			nodes = append(nodes, ClusterNode{
+				Addr:               net.JoinHostPort(node["ip"], node["port"]),
+				NetworkingMetadata: node,
+			})
 		}
 	}
 
 	return nodes
 }
 
+func nodeAddrs(nodes []ClusterNode) []string {
+	addrs := make([]string, len(nodes))
+	for i, node := range nodes {
+		addrs[i] = node.Addr
+	}
+	return addrs
+}
+
 func (c *sentinelFailover) trySwitchMaster(ctx context.Context, addr string) {
 	c.mu.RLock()
 	currentAddr := c._masterAddr //nolint:ifshort
@@ -799,16 +866,11 @@ func NewFailoverClusterClient(failoverOpt *FailoverOptions) *ClusterClient {
 			Addr: masterAddr,
 		}}
 
-		replicaAddrs, err := failover.replicaAddrs(ctx, false)
+		replicas, err := failover.replicas(ctx, false)
 		if err != nil {
 			return nil, err
 		}
-
-		for _, replicaAddr := range replicaAddrs {
-			nodes = append(nodes, ClusterNode{
-				Addr: replicaAddr,
-			})
-		}
+		nodes = append(nodes, replicas...)
 
 		slots := []ClusterSlot{
 			{
@@ -8,6 +8,7 @@ import (
 	. "github.com/bsm/gomega"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/redisroute"
 )
 
 var _ = Describe("Sentinel PROTO 2", func() {
@@ -152,6 +153,23 @@ var _ = Describe("Sentinel", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("routes the replica connections with ReadRouter", func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+
+		replica := net.JoinHostPort("127.0.0.1", sentinelSlave2Port)
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    sentinelName,
+			SentinelAddrs: sentinelAddrs,
+			ReplicaOnly:   true,
+			ReadRouter:    redisroute.Zone("a", redisroute.ZoneByAddr(map[string]string{replica: "a"}), nil),
+		})
+		for i := 0; i < 10; i++ {
+			info, err := client.Info(ctx, "server").Result()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(ContainSubstring("tcp_port:" + sentinelSlave2Port))
+		}
+	})
+
 	It("should sentinel client setname", func() {
 		Expect(client.Ping(ctx).Err()).NotTo(HaveOccurred())
 		val, err := client.ClientList(ctx).Result()
@@ -2,7 +2,10 @@ package redis
 
 import (
 	"context"
+	"io"
 	"time"
+
+	"github.com/redis/go-redis/v9/internal/proto"
 )
 
 type StringCmdable interface {
@@ -10,6 +13,7 @@ type StringCmdable interface {
 	Decr(ctx context.Context, key string) *IntCmd
 	DecrBy(ctx context.Context, key string, decrement int64) *IntCmd
 	Get(ctx context.Context, key string) *StringCmd
+	GetToWriter(ctx context.Context, key string, w io.Writer) *WriterCmd
 	GetRange(ctx context.Context, key string, start, end int64) *StringCmd
 	GetSet(ctx context.Context, key string, value interface{}) *StringCmd
 	GetEx(ctx context.Context, key string, expiration time.Duration) *StringCmd
@@ -26,6 +30,7 @@ type StringCmdable interface {
 	SetEx(ctx context.Context, key string, value interface{}, expiration time.Duration) *StatusCmd
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *BoolCmd
 	SetXX(ctx context.Context, key string, value interface{}, expiration time.Duration) *BoolCmd
+	SetFromReader(ctx context.Context, key string, r io.Reader, size int64, expiration time.Duration) *StatusCmd
 	SetRange(ctx context.Context, key string, offset int64, value string) *IntCmd
 	StrLen(ctx context.Context, key string) *IntCmd
 }
@@ -55,6 +60,15 @@ func (c cmdable) Get(ctx context.Context, key string) *StringCmd {
 	return cmd
 }
 
+// GetToWriter Redis `GET key` command that copies the value to w
+// without buffering it. It returns redis.Nil error when key does not exist.
+// When w fails, the rest of the value is discarded and the error is returned.
+func (c cmdable) GetToWriter(ctx context.Context, key string, w io.Writer) *WriterCmd {
+	cmd := NewWriterCmd(ctx, w, "get", key)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
 func (c cmdable) GetRange(ctx context.Context, key string, start, end int64) *StringCmd {
 	cmd := NewStringCmd(ctx, "getrange", key, start, end)
 	_ = c(ctx, cmd)
@@ -183,6 +197,15 @@ func (c cmdable) Set(ctx context.Context, key string, value interface{}, expirat
 	return cmd
 }
 
+// SetFromReader Redis `SET key value [expiration]` command that copies
+// the value of size bytes from r without buffering it.
+// The command can't be retried because r can be read only once.
+func (c cmdable) SetFromReader(
+	ctx context.Context, key string, r io.Reader, size int64, expiration time.Duration,
+) *StatusCmd {
+	return c.Set(ctx, key, &proto.StreamArg{R: r, N: size}, expiration)
+}
+
 // SetArgs provides arguments for the SetArgs function.
 type SetArgs struct {
 	// Mode can be `NX` or `XX` or empty.
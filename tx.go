@@ -20,13 +20,18 @@ type Tx struct {
 	cmdable
 	statefulCmdable
 	hooksMixin
+
+	// processed are the commands sent, recorded when not nil.
+	processed *[]Cmder
 }
 
 func (c *Client) newTx() *Tx {
 	tx := Tx{
 		baseClient: baseClient{
-			opt:      c.opt,
-			connPool: pool.NewStickyConnPool(c.connPool),
+			opt:         c.opt,
+			connPool:    pool.NewStickyConnPool(c.connPool),
+			tracker:     c.tracker,
+			retryPolicy: c.retryPolicy,
 		},
 		hooksMixin: c.hooksMixin.clone(),
 	}
@@ -47,6 +52,7 @@ func (c *Tx) init() {
 }
 
 func (c *Tx) Process(ctx context.Context, cmd Cmder) error {
+	c.record(cmd)
 	err := c.processHook(ctx, cmd)
 	cmd.SetErr(err)
 	return err
@@ -57,14 +63,30 @@ func (c *Tx) Process(ctx context.Context, cmd Cmder) error {
 //
 // The transaction is automatically closed when fn exits.
 func (c *Client) Watch(ctx context.Context, fn func(*Tx) error, keys ...string) error {
+	_, err := c.watch(ctx, fn, keys...)
+	return err
+}
+
+// watch is Watch that also returns the commands sent by the transaction,
+// which tell whether it can be retried.
+func (c *Client) watch(ctx context.Context, fn func(*Tx) error, keys ...string) ([]Cmder, error) {
+	var processed []Cmder
 	tx := c.newTx()
+	tx.processed = &processed
 	defer tx.Close(ctx)
 	if len(keys) > 0 {
 		if err := tx.Watch(ctx, keys...).Err(); err != nil {
-			return err
+			return processed, err
 		}
 	}
-	return fn(tx)
+	err := fn(tx)
+	return processed, err
+}
+
+func (c *Tx) record(cmds ...Cmder) {
+	if c.processed != nil {
+		*c.processed = append(*c.processed, cmds...)
+	}
 }
 
 // Close closes the transaction, releasing any open resources.
@@ -102,6 +124,7 @@ func (c *Tx) Unwatch(ctx context.Context, keys ...string) *StatusCmd {
 func (c *Tx) Pipeline() Pipeliner {
 	pipe := Pipeline{
 		exec: func(ctx context.Context, cmds []Cmder) error {
+			c.record(cmds...)
 			return c.processPipelineHook(ctx, cmds)
 		},
 	}
@@ -132,6 +155,7 @@ func (c *Tx) TxPipeline() Pipeliner {
 	pipe := Pipeline{
 		exec: func(ctx context.Context, cmds []Cmder) error {
 			cmds = wrapMultiExec(ctx, cmds)
+			c.record(cmds...)
 			return c.processTxPipelineHook(ctx, cmds)
 		},
 	}
@@ -25,6 +25,7 @@ type UniversalOptions struct {
 
 	Dialer    func(ctx context.Context, network, addr string) (net.Conn, error)
 	OnConnect func(ctx context.Context, cn *Conn) error
+	OnPush    func(ctx context.Context, push *Push)
 
 	Protocol         int
 	Username         string
@@ -35,22 +36,29 @@ type UniversalOptions struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+	RetryPolicy     RetryPolicy
 
 	DialTimeout           time.Duration
 	ReadTimeout           time.Duration
 	WriteTimeout          time.Duration
 	ContextTimeoutEnabled bool
 
+	MaxBulkLen      int
+	MaxAggregateLen int
+	MaxReplyDepth   int
+
 	// PoolFIFO uses FIFO mode for each node connection pool GET/PUT (default LIFO).
 	PoolFIFO bool
 
-	PoolSize        int
-	PoolTimeout     time.Duration
-	MinIdleConns    int
-	MaxIdleConns    int
-	MaxActiveConns  int
-	ConnMaxIdleTime time.Duration
-	ConnMaxLifetime time.Duration
+	PoolSize            int
+	PoolTimeout         time.Duration
+	MinIdleConns        int
+	MaxIdleConns        int
+	MaxActiveConns      int
+	ConnMaxIdleTime     time.Duration
+	ConnMaxLifetime     time.Duration
+	IdleCheckFrequency  time.Duration
+	HealthCheckInterval time.Duration
 
 	TLSConfig *tls.Config
 
@@ -60,12 +68,20 @@ type UniversalOptions struct {
 	ReadOnly       bool
 	RouteByLatency bool
 	RouteRandomly  bool
+	ReadRouter     ReadRouter
+	ConnBudget     *ConnBudgetOptions
+	Hedge          *HedgeOptions
 
 	// The sentinel master name.
 	// Only failover clients.
 
 	MasterName string
 
+	// Only single-node and cluster clients.
+
+	ClientSideCache *CacheOptions
+	AutoPipeline    *AutoPipelineOptions
+
 	DisableIndentity bool
 	IdentitySuffix   string
 }
@@ -81,6 +97,7 @@ func (o *UniversalOptions) Cluster() *ClusterOptions {
 		ClientName: o.ClientName,
 		Dialer:     o.Dialer,
 		OnConnect:  o.OnConnect,
+		OnPush:     o.OnPush,
 
 		Protocol: o.Protocol,
 		Username: o.Username,
@@ -90,30 +107,42 @@ func (o *UniversalOptions) Cluster() *ClusterOptions {
 		ReadOnly:       o.ReadOnly,
 		RouteByLatency: o.RouteByLatency,
 		RouteRandomly:  o.RouteRandomly,
+		ReadRouter:     o.ReadRouter,
+		ConnBudget:     o.ConnBudget,
+		Hedge:          o.Hedge,
 
 		MaxRetries:      o.MaxRetries,
 		MinRetryBackoff: o.MinRetryBackoff,
 		MaxRetryBackoff: o.MaxRetryBackoff,
+		RetryPolicy:     o.RetryPolicy,
 
 		DialTimeout:           o.DialTimeout,
 		ReadTimeout:           o.ReadTimeout,
 		WriteTimeout:          o.WriteTimeout,
 		ContextTimeoutEnabled: o.ContextTimeoutEnabled,
+		MaxBulkLen:            o.MaxBulkLen,
+		MaxAggregateLen:       o.MaxAggregateLen,
+		MaxReplyDepth:         o.MaxReplyDepth,
 
 		PoolFIFO: o.PoolFIFO,
 
-		PoolSize:        o.PoolSize,
-		PoolTimeout:     o.PoolTimeout,
-		MinIdleConns:    o.MinIdleConns,
-		MaxIdleConns:    o.MaxIdleConns,
-		MaxActiveConns:  o.MaxActiveConns,
-		ConnMaxIdleTime: o.ConnMaxIdleTime,
-		ConnMaxLifetime: o.ConnMaxLifetime,
+		PoolSize:            o.PoolSize,
+		PoolTimeout:         o.PoolTimeout,
+		MinIdleConns:        o.MinIdleConns,
+		MaxIdleConns:        o.MaxIdleConns,
+		MaxActiveConns:      o.MaxActiveConns,
+		ConnMaxIdleTime:     o.ConnMaxIdleTime,
+		ConnMaxLifetime:     o.ConnMaxLifetime,
+		IdleCheckFrequency:  o.IdleCheckFrequency,
+		HealthCheckInterval: o.HealthCheckInterval,
 
 		TLSConfig: o.TLSConfig,
 
 		DisableIndentity: o.DisableIndentity,
 		IdentitySuffix:   o.IdentitySuffix,
+
+		ClientSideCache: o.ClientSideCache,
+		AutoPipeline:    o.AutoPipeline,
 	}
 }
 
@@ -130,6 +159,7 @@ func (o *UniversalOptions) Failover() *FailoverOptions {
 
 		Dialer:    o.Dialer,
 		OnConnect: o.OnConnect,
+		OnPush:    o.OnPush,
 
 		DB:               o.DB,
 		Protocol:         o.Protocol,
@@ -141,20 +171,26 @@ func (o *UniversalOptions) Failover() *FailoverOptions {
 		MaxRetries:      o.MaxRetries,
 		MinRetryBackoff: o.MinRetryBackoff,
 		MaxRetryBackoff: o.MaxRetryBackoff,
+		RetryPolicy:     o.RetryPolicy,
 
 		DialTimeout:           o.DialTimeout,
 		ReadTimeout:           o.ReadTimeout,
 		WriteTimeout:          o.WriteTimeout,
 		ContextTimeoutEnabled: o.ContextTimeoutEnabled,
-
-		PoolFIFO:        o.PoolFIFO,
-		PoolSize:        o.PoolSize,
-		PoolTimeout:     o.PoolTimeout,
-		MinIdleConns:    o.MinIdleConns,
-		MaxIdleConns:    o.MaxIdleConns,
-		MaxActiveConns:  o.MaxActiveConns,
-		ConnMaxIdleTime: o.ConnMaxIdleTime,
-		ConnMaxLifetime: o.ConnMaxLifetime,
+		MaxBulkLen:            o.MaxBulkLen,
+		MaxAggregateLen:       o.MaxAggregateLen,
+		MaxReplyDepth:         o.MaxReplyDepth,
+
+		PoolFIFO:            o.PoolFIFO,
+		PoolSize:            o.PoolSize,
+		PoolTimeout:         o.PoolTimeout,
+		MinIdleConns:        o.MinIdleConns,
+		MaxIdleConns:        o.MaxIdleConns,
+		MaxActiveConns:      o.MaxActiveConns,
+		ConnMaxIdleTime:     o.ConnMaxIdleTime,
+		ConnMaxLifetime:     o.ConnMaxLifetime,
+		IdleCheckFrequency:  o.IdleCheckFrequency,
+		HealthCheckInterval: o.HealthCheckInterval,
 
 		TLSConfig: o.TLSConfig,
 
@@ -175,6 +211,7 @@ func (o *UniversalOptions) Simple() *Options {
 		ClientName: o.ClientName,
 		Dialer:     o.Dialer,
 		OnConnect:  o.OnConnect,
+		OnPush:     o.OnPush,
 
 		DB:       o.DB,
 		Protocol: o.Protocol,
@@ -184,25 +221,34 @@ func (o *UniversalOptions) Simple() *Options {
 		MaxRetries:      o.MaxRetries,
 		MinRetryBackoff: o.MinRetryBackoff,
 		MaxRetryBackoff: o.MaxRetryBackoff,
+		RetryPolicy:     o.RetryPolicy,
 
 		DialTimeout:           o.DialTimeout,
 		ReadTimeout:           o.ReadTimeout,
 		WriteTimeout:          o.WriteTimeout,
 		ContextTimeoutEnabled: o.ContextTimeoutEnabled,
-
-		PoolFIFO:        o.PoolFIFO,
-		PoolSize:        o.PoolSize,
-		PoolTimeout:     o.PoolTimeout,
-		MinIdleConns:    o.MinIdleConns,
-		MaxIdleConns:    o.MaxIdleConns,
-		MaxActiveConns:  o.MaxActiveConns,
-		ConnMaxIdleTime: o.ConnMaxIdleTime,
-		ConnMaxLifetime: o.ConnMaxLifetime,
+		MaxBulkLen:            o.MaxBulkLen,
+		MaxAggregateLen:       o.MaxAggregateLen,
+		MaxReplyDepth:         o.MaxReplyDepth,
+
+		PoolFIFO:            o.PoolFIFO,
+		PoolSize:            o.PoolSize,
+		PoolTimeout:         o.PoolTimeout,
+		MinIdleConns:        o.MinIdleConns,
+		MaxIdleConns:        o.MaxIdleConns,
+		MaxActiveConns:      o.MaxActiveConns,
+		ConnMaxIdleTime:     o.ConnMaxIdleTime,
+		ConnMaxLifetime:     o.ConnMaxLifetime,
+		IdleCheckFrequency:  o.IdleCheckFrequency,
+		HealthCheckInterval: o.HealthCheckInterval,
 
 		TLSConfig: o.TLSConfig,
 
 		DisableIndentity: o.DisableIndentity,
 		IdentitySuffix:   o.IdentitySuffix,
+
+		ClientSideCache: o.ClientSideCache,
+		AutoPipeline:    o.AutoPipeline,
 	}
 }
 
@@ -217,6 +263,7 @@ type UniversalClient interface {
 	AddHook(Hook)
 	Watch(ctx context.Context, fn func(*Tx) error, keys ...string) error
 	Do(ctx context.Context, args ...interface{}) *Cmd
+	DoValue(ctx context.Context, args ...interface{}) *ValueCmd
 	Process(ctx context.Context, cmd Cmder) error
 	Subscribe(ctx context.Context, channels ...string) *PubSub
 	PSubscribe(ctx context.Context, channels ...string) *PubSub